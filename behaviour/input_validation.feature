@@ -25,7 +25,7 @@ Feature: Validate the input of a shipment
 
     And "weight" validation rules
   ```
-  - Weight is in kg
+  - Weight is in kg, unless another weight unit is given
   - Maximum: 1000kg
   ```
 
@@ -100,8 +100,8 @@ Feature: Validate the input of a shipment
       | message | <error> |
 
     Examples:
-      | weight | error                                                                                               | comment          |
-      | -1     | shipment was invalid: failed to validate package: package weight: -1 can't be below minimum: 0      | invalid          |
-      | 0      |                                                                                                     | min valid weight |
-      | 1000   |                                                                                                     | max valid weight |
-      | 1001   | shipment was invalid: failed to validate package: package weight: 1001 can't be above maximum: 1000 | invalid          |
\ No newline at end of file
+      | weight   | error                                                                                                         | comment          |
+      | -1       | shipment was invalid: failed to validate package: package weight: -1000 g can't be below minimum: 0 g         | invalid          |
+      | 0        |                                                                                                               | min valid weight |
+      | 1000     |                                                                                                               | max valid weight |
+      | 1000.001 | shipment was invalid: failed to validate package: package weight: 1000001 g can't be above maximum: 1000000 g | invalid          |
\ No newline at end of file
@@ -10,10 +10,10 @@ Feature: Calculate the price of a shipment
 
     And "weight-class" price rules
     ```
-    - Small (0 - 10kg): 100sek
-    - Medium (10 - 25kg): 300sek
-    - Large (26 - 50kg): 500sek
-    - Huge (51 - 1000kg): 2000sek
+    - Small (0 - 10kg, inclusive): 100sek
+    - Medium (above 10 - 25kg, inclusive): 300sek
+    - Large (above 25 - 50kg, inclusive): 500sek
+    - Huge (above 50 - 1000kg, inclusive): 2000sek
     ```
 
     And price equation "{region}*{weight_class}"
@@ -29,3 +29,8 @@ Feature: Calculate the price of a shipment
       | sender | package (kg) | price (SEK) |
       | US     | 45           | 1250        |
       | SE     | 45           | 500         |
+      | SE     | 10           | 100         |
+      | SE     | 10.001       | 300         |
+      | SE     | 10.5         | 300         |
+      | SE     | 25           | 300         |
+      | SE     | 25.5         | 500         |
@@ -1,6 +1,7 @@
 package rest
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -17,9 +18,10 @@ import (
 )
 
 type API struct {
-	server *http.Server
-	router *mux.Router
-	err    error
+	server        *http.Server
+	router        *mux.Router
+	shutdownHooks []func(context.Context) error
+	err           error
 }
 
 // New will take a pointer to the businesslogic and return a pointer to a REST API boundary.
@@ -33,8 +35,12 @@ func New(publicURL url.URL, logic *businesslogic.BusinessLogic) (*API, error) {
 		withMiddleware().
 		withHealthServer()
 
+	idempotency := utils.NewIdempotency(utils.NewIdempotencyMemoryStorage(), config.GetIdempotencyKeyTTL())
+
+	adminGuard := utils.NewAdminGuard(config.GetAdminToken())
+
 	v1SubRouter := api.router.PathPrefix("/v1").Subrouter()
-	v1.New(v1SubRouter, publicURL).WithLogic(logic)
+	v1.New(v1SubRouter, publicURL, idempotency, adminGuard).WithLogic(logic)
 
 	return &api, api.err
 }
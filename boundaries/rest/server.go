@@ -42,10 +42,24 @@ func (api *API) ListenAndServe(servicePort string) {
 	log.Printf("Listening on %s.", server.Addr)
 }
 
+// WithShutdownHook will register a hook which is called by Shutdown
+// after the server has stopped serving requests, the hooks are called
+// in the order they were registered.
+func (api *API) WithShutdownHook(hook func(context.Context) error) *API {
+	api.shutdownHooks = append(api.shutdownHooks, hook)
+	return api
+}
+
 func (api *API) Shutdown(ctx context.Context) {
 	if err := api.server.Shutdown(ctx); err != nil {
 		log.Printf("Unable to shutdown server: %s", err.Error())
 	}
 
 	log.Printf("Server gracefully stopped listening on %s.", api.server.Addr)
+
+	for _, hook := range api.shutdownHooks {
+		if err := hook(ctx); err != nil {
+			log.Printf("Shutdown hook failed: %s", err.Error())
+		}
+	}
 }
@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+const (
+	HeaderAuthorization   = "Authorization"
+	HeaderWWWAuthenticate = "WWW-Authenticate"
+
+	schemeBearer = "Bearer"
+)
+
+var (
+	errAdminDisabled     = errors.New("the admin endpoints are disabled, as no admin token is configured")
+	errAdminUnauthorized = errors.New("a valid admin token is required as a Bearer token in the Authorization header")
+)
+
+// AdminGuard is a middleware which will only let a request with the admin
+// token as a Bearer token in the Authorization header through, other
+// requests are responded with a 401. When no admin token is configured,
+// every request is responded with a 403.
+type AdminGuard struct {
+	token string
+}
+
+// NewAdminGuard will take the admin token and return
+// a pointer to a new AdminGuard middleware.
+func NewAdminGuard(token string) *AdminGuard {
+	return &AdminGuard{token: token}
+}
+
+// Middleware will wrap the handler with the AdminGuard.
+func (ag *AdminGuard) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if ag.token == "" {
+			WrapErrorAndWriteJSONResponse(w, http.StatusForbidden, errAdminDisabled)
+			return
+		}
+
+		if !ag.authorized(req.Header.Get(HeaderAuthorization)) {
+			w.Header().Set(HeaderWWWAuthenticate, schemeBearer)
+			WrapErrorAndWriteJSONResponse(w, http.StatusUnauthorized, errAdminUnauthorized)
+
+			return
+		}
+
+		next.ServeHTTP(w, req)
+	})
+}
+
+// authorized will compare the token in constant time, so that
+// the time of the comparison doesn't reveal the admin token.
+func (ag *AdminGuard) authorized(header string) bool {
+	const parts = 2
+
+	schemeAndToken := strings.SplitN(header, " ", parts)
+	if len(schemeAndToken) != parts || !strings.EqualFold(schemeAndToken[0], schemeBearer) {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(schemeAndToken[1]), []byte(ag.token)) == 1
+}
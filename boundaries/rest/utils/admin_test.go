@@ -0,0 +1,58 @@
+package utils_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lonnblad/shipment-service-backend/boundaries/rest/utils"
+)
+
+func Test_AdminGuard(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	serve := func(guard *utils.AdminGuard, authorization string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+
+		if authorization != "" {
+			req.Header.Set(utils.HeaderAuthorization, authorization)
+		}
+
+		rec := httptest.NewRecorder()
+		guard.Middleware(handler).ServeHTTP(rec, req)
+
+		return rec
+	}
+
+	guard := utils.NewAdminGuard("secret")
+
+	tcs := []struct {
+		authorization string
+		statusCode    int
+	}{
+		{authorization: "Bearer secret", statusCode: http.StatusOK},
+		{authorization: "bearer secret", statusCode: http.StatusOK},
+		{authorization: "", statusCode: http.StatusUnauthorized},
+		{authorization: "Bearer", statusCode: http.StatusUnauthorized},
+		{authorization: "Bearer other", statusCode: http.StatusUnauthorized},
+		{authorization: "Basic secret", statusCode: http.StatusUnauthorized},
+	}
+
+	for _, tc := range tcs {
+		rec := serve(guard, tc.authorization)
+		assert.Equal(t, tc.statusCode, rec.Code, tc.authorization)
+
+		if tc.statusCode == http.StatusUnauthorized {
+			assert.Equal(t, "Bearer", rec.Header().Get(utils.HeaderWWWAuthenticate))
+		}
+	}
+
+	// The admin endpoints are disabled without an admin token.
+	disabled := utils.NewAdminGuard("")
+	assert.Equal(t, http.StatusForbidden, serve(disabled, "Bearer ").Code)
+	assert.Equal(t, http.StatusForbidden, serve(disabled, "").Code)
+}
@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	HeaderETag        = "ETag"
+	HeaderIfMatch     = "If-Match"
+	HeaderIfNoneMatch = "If-None-Match"
+
+	etagAny        = "*"
+	etagWeakPrefix = "W/"
+)
+
+// ETag will return the version as a strong entity tag.
+func ETag(version int) string {
+	return fmt.Sprintf(`"%d"`, version)
+}
+
+// MatchesIfMatch will take the value of an If-Match header and an entity
+// tag and return true if the header is * or if one of its entity tags is
+// the same as the entity tag. Weak entity tags never match, as If-Match
+// uses the strong comparison of RFC 7232.
+func MatchesIfMatch(header, etag string) bool {
+	for _, candidate := range splitETags(header) {
+		if candidate == etagAny || (candidate == etag && !strings.HasPrefix(candidate, etagWeakPrefix)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// MatchesIfNoneMatch will take the value of an If-None-Match header and an
+// entity tag and return true if the header is * or if one of its entity tags
+// is the same as the entity tag, when compared with the weak comparison of
+// RFC 7232, which ignores if the entity tags are weak.
+func MatchesIfNoneMatch(header, etag string) bool {
+	etag = strings.TrimPrefix(etag, etagWeakPrefix)
+
+	for _, candidate := range splitETags(header) {
+		if candidate == etagAny || strings.TrimPrefix(candidate, etagWeakPrefix) == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
+func splitETags(header string) (etags []string) {
+	for _, etag := range strings.Split(header, ",") {
+		if etag = strings.TrimSpace(etag); etag != "" {
+			etags = append(etags, etag)
+		}
+	}
+
+	return
+}
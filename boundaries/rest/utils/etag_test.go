@@ -0,0 +1,32 @@
+package utils_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lonnblad/shipment-service-backend/boundaries/rest/utils"
+)
+
+func Test_MatchesETag(t *testing.T) {
+	etag := utils.ETag(2)
+	assert.Equal(t, `"2"`, etag)
+
+	tcs := []struct {
+		header               string
+		ifMatch, ifNoneMatch bool
+	}{
+		{header: `"2"`, ifMatch: true, ifNoneMatch: true},
+		{header: `*`, ifMatch: true, ifNoneMatch: true},
+		{header: `"1", "2"`, ifMatch: true, ifNoneMatch: true},
+		{header: `W/"2"`, ifMatch: false, ifNoneMatch: true},
+		{header: `"1"`, ifMatch: false, ifNoneMatch: false},
+		{header: `2`, ifMatch: false, ifNoneMatch: false},
+		{header: ``, ifMatch: false, ifNoneMatch: false},
+	}
+
+	for _, tc := range tcs {
+		assert.Equal(t, tc.ifMatch, utils.MatchesIfMatch(tc.header, etag), "If-Match: %s", tc.header)
+		assert.Equal(t, tc.ifNoneMatch, utils.MatchesIfNoneMatch(tc.header, etag), "If-None-Match: %s", tc.header)
+	}
+}
@@ -0,0 +1,294 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	HeaderIdempotencyKey      = "Idempotency-Key"
+	HeaderIdempotencyReplayed = "Idempotency-Replayed"
+
+	maxLengthIdempotencyKey = 255
+
+	// idempotencySweepInterval is how often the expired
+	// records are removed from the IdempotencyMemoryStorage.
+	idempotencySweepInterval = time.Minute
+)
+
+// IdempotencyRecord is the stored response of the first request with an
+// idempotency key, the Fingerprint identifies the method, path and body
+// of the request. A record that isn't Completed is reserved by a request
+// that is still in progress.
+type IdempotencyRecord struct {
+	Fingerprint string
+	Completed   bool
+	StatusCode  int
+	Header      http.Header
+	Body        []byte
+	ExpiresAt   time.Time
+}
+
+// IdempotencyStorage is an interface for storing the responses of
+// requests with idempotency keys, records are expected to be
+// forgotten when they have expired.
+type IdempotencyStorage interface {
+	// Reserve will store the record for the key, unless there already
+	// is a record for the key, in which case that record is returned
+	// and reserved is false.
+	Reserve(_ context.Context, key string, record IdempotencyRecord) (_ IdempotencyRecord, reserved bool, err error)
+
+	// Complete will replace the reserved record of the key.
+	Complete(_ context.Context, key string, record IdempotencyRecord) error
+
+	// Release will remove the reserved record of the key.
+	Release(_ context.Context, key string) error
+}
+
+// Idempotency is a middleware which will store the response of the first
+// request with an Idempotency-Key header and replay it for every request
+// with the same key until it expires, instead of handling the request
+// again. The keys are scoped by the scope of the request.
+//
+// A request with a key that was used for another request is responded
+// with a 422, and a request with a key that is used by a request that is
+// still in progress is responded with a 409. Responses with a 5xx status
+// code are not stored, the request can be retried with the same key.
+type Idempotency struct {
+	storage IdempotencyStorage
+	ttl     time.Duration
+	scope   func(*http.Request) string
+}
+
+// NewIdempotency will take an IdempotencyStorage and the time to live of the
+// stored responses and return a pointer to a new Idempotency middleware.
+func NewIdempotency(storage IdempotencyStorage, ttl time.Duration) *Idempotency {
+	return &Idempotency{storage: storage, ttl: ttl}
+}
+
+// WithScope will set the function that returns the scope of a request, like
+// the tenant, which makes the same key of two scopes two different keys.
+func (i *Idempotency) WithScope(scope func(*http.Request) string) *Idempotency {
+	i.scope = scope
+	return i
+}
+
+// Middleware will make the next handler idempotent for
+// requests with an Idempotency-Key header.
+func (i *Idempotency) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		key := req.Header.Get(HeaderIdempotencyKey)
+		if key == "" {
+			next.ServeHTTP(w, req)
+			return
+		}
+
+		if len(key) > maxLengthIdempotencyKey {
+			err := fmt.Errorf("idempotency key is longer than max length: %d", maxLengthIdempotencyKey)
+			WrapErrorAndWriteJSONResponse(w, http.StatusBadRequest, err)
+
+			return
+		}
+
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+
+		if err != nil {
+			WrapErrorAndWriteJSONResponse(w, http.StatusBadRequest, fmt.Errorf("failed to read request body: %w", err))
+			return
+		}
+
+		req.Body = io.NopCloser(bytes.NewReader(body))
+
+		if i.scope != nil {
+			key = i.scope(req) + "/" + key
+		}
+
+		reservation := IdempotencyRecord{Fingerprint: fingerprint(req, body), ExpiresAt: time.Now().Add(i.ttl)}
+
+		record, reserved, err := i.storage.Reserve(req.Context(), key, reservation)
+		if err != nil {
+			err = fmt.Errorf("failed to reserve idempotency key: %w", err)
+			WrapErrorAndWriteJSONResponse(w, http.StatusServiceUnavailable, err)
+
+			return
+		}
+
+		if !reserved {
+			replay(w, req, record, reservation.Fingerprint)
+			return
+		}
+
+		i.serveAndStore(w, req, next, key, reservation)
+	})
+}
+
+// serveAndStore will serve the request with the next handler and store the
+// response for the reserved key, or release the key if no response is stored.
+func (i *Idempotency) serveAndStore(
+	w http.ResponseWriter, req *http.Request, next http.Handler, key string, reservation IdempotencyRecord,
+) {
+	recorder := &responseRecorder{ResponseWriter: w, statusCode: http.StatusOK}
+	completed := false
+
+	// The key is released if the handler panics, which
+	// makes it possible to retry the request with the key.
+	defer func() {
+		if completed {
+			return
+		}
+
+		if err := i.storage.Release(context.Background(), key); err != nil {
+			log.Printf("Failed to release idempotency key: %s, error: %s", key, err.Error())
+		}
+	}()
+
+	next.ServeHTTP(recorder, req)
+
+	if recorder.statusCode >= http.StatusInternalServerError {
+		return
+	}
+
+	reservation.Completed = true
+	reservation.StatusCode = recorder.statusCode
+	reservation.Header = recorder.header
+	reservation.Body = recorder.body.Bytes()
+
+	if err := i.storage.Complete(req.Context(), key, reservation); err != nil {
+		log.Printf("Failed to store the response of idempotency key: %s, error: %s", key, err.Error())
+		return
+	}
+
+	completed = true
+}
+
+func replay(w http.ResponseWriter, req *http.Request, record IdempotencyRecord, fingerprint string) {
+	key := req.Header.Get(HeaderIdempotencyKey)
+
+	switch {
+	case record.Fingerprint != fingerprint:
+		err := fmt.Errorf("idempotency key: %s has already been used for another request", key)
+		WrapErrorAndWriteJSONResponse(w, http.StatusUnprocessableEntity, err)
+	case !record.Completed:
+		err := fmt.Errorf("a request with idempotency key: %s is in progress", key)
+		WrapErrorAndWriteJSONResponse(w, http.StatusConflict, err)
+	default:
+		for name, values := range record.Header {
+			w.Header()[name] = values
+		}
+
+		w.Header().Set(HeaderIdempotencyReplayed, "true")
+		w.WriteHeader(record.StatusCode)
+
+		if _, err := w.Write(record.Body); err != nil {
+			log.Printf("Failed to write response: %s", err.Error())
+		}
+	}
+}
+
+// fingerprint will return a hash of the method, path and body of the request.
+func fingerprint(req *http.Request, body []byte) string {
+	data := append([]byte(req.Method+" "+req.URL.Path+"\n"), body...)
+	hash := sha256.Sum256(data)
+
+	return hex.EncodeToString(hash[:])
+}
+
+// responseRecorder will write the response to the
+// ResponseWriter and keep a copy of it.
+type responseRecorder struct {
+	http.ResponseWriter
+	statusCode  int
+	header      http.Header
+	body        bytes.Buffer
+	wroteHeader bool
+}
+
+func (r *responseRecorder) WriteHeader(statusCode int) {
+	if !r.wroteHeader {
+		r.wroteHeader = true
+		r.statusCode = statusCode
+		r.header = r.ResponseWriter.Header().Clone()
+	}
+
+	r.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	if !r.wroteHeader {
+		r.WriteHeader(http.StatusOK)
+	}
+
+	r.body.Write(b)
+
+	return r.ResponseWriter.Write(b)
+}
+
+// IdempotencyMemoryStorage implements IdempotencyStorage in-memory, which
+// means that the keys are only idempotent within a single process.
+type IdempotencyMemoryStorage struct {
+	mutex     sync.Mutex
+	records   map[string]IdempotencyRecord
+	lastSweep time.Time
+}
+
+var _ IdempotencyStorage = &IdempotencyMemoryStorage{}
+
+// NewIdempotencyMemoryStorage will return a pointer
+// to a new and empty IdempotencyMemoryStorage.
+func NewIdempotencyMemoryStorage() *IdempotencyMemoryStorage {
+	return &IdempotencyMemoryStorage{records: map[string]IdempotencyRecord{}, lastSweep: time.Now()}
+}
+
+func (s *IdempotencyMemoryStorage) Reserve(
+	_ context.Context, key string, record IdempotencyRecord,
+) (_ IdempotencyRecord, reserved bool, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+
+	if now.Sub(s.lastSweep) > idempotencySweepInterval {
+		for storedKey, stored := range s.records {
+			if now.After(stored.ExpiresAt) {
+				delete(s.records, storedKey)
+			}
+		}
+
+		s.lastSweep = now
+	}
+
+	if stored, ok := s.records[key]; ok && !now.After(stored.ExpiresAt) {
+		return stored, false, nil
+	}
+
+	s.records[key] = record
+
+	return record, true, nil
+}
+
+func (s *IdempotencyMemoryStorage) Complete(_ context.Context, key string, record IdempotencyRecord) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.records[key] = record
+
+	return nil
+}
+
+func (s *IdempotencyMemoryStorage) Release(_ context.Context, key string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.records, key)
+
+	return nil
+}
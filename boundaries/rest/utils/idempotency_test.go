@@ -0,0 +1,104 @@
+package utils_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lonnblad/shipment-service-backend/boundaries/rest/utils"
+)
+
+func Test_Idempotency(t *testing.T) {
+	calls := 0
+	handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		calls++
+
+		body, _ := io.ReadAll(req.Body)
+		statusCode := http.StatusCreated
+
+		if string(body) == "fail" {
+			statusCode = http.StatusServiceUnavailable
+		}
+
+		w.Header().Set("ETag", `"1"`)
+		utils.WriteJSONResponse(w, statusCode, body)
+	})
+
+	idempotency := utils.NewIdempotency(utils.NewIdempotencyMemoryStorage(), time.Hour).
+		WithScope(func(req *http.Request) string { return req.Header.Get("Tenant") })
+	middleware := idempotency.Middleware(handler)
+
+	serve := func(tenant, key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/shipments", strings.NewReader(body))
+		req.Header.Set("Tenant", tenant)
+
+		if key != "" {
+			req.Header.Set(utils.HeaderIdempotencyKey, key)
+		}
+
+		rec := httptest.NewRecorder()
+		middleware.ServeHTTP(rec, req)
+
+		return rec
+	}
+
+	first := serve("a", "key", "body")
+	assert.Equal(t, http.StatusCreated, first.Code)
+	assert.Equal(t, 1, calls)
+
+	// The stored response is replayed for the same key and body.
+	replayed := serve("a", "key", "body")
+	assert.Equal(t, http.StatusCreated, replayed.Code)
+	assert.Equal(t, "body", replayed.Body.String())
+	assert.Equal(t, `"1"`, replayed.Header().Get("ETag"))
+	assert.Equal(t, "true", replayed.Header().Get(utils.HeaderIdempotencyReplayed))
+	assert.Equal(t, 1, calls)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, serve("a", "key", "other body").Code)
+	assert.Equal(t, 1, calls)
+
+	// The keys are scoped, and requests without a key are always handled.
+	assert.Equal(t, http.StatusCreated, serve("b", "key", "other body").Code)
+	assert.Equal(t, http.StatusCreated, serve("a", "", "body").Code)
+	assert.Equal(t, 3, calls)
+
+	// A failed response isn't stored, which allows a retry with the same key.
+	assert.Equal(t, http.StatusServiceUnavailable, serve("a", "retry", "fail").Code)
+	assert.Equal(t, http.StatusServiceUnavailable, serve("a", "retry", "fail").Code)
+	assert.Equal(t, 5, calls)
+}
+
+func Test_IdempotencyMemoryStorage(t *testing.T) {
+	ctx := httptest.NewRequest(http.MethodPost, "/", nil).Context()
+	storage := utils.NewIdempotencyMemoryStorage()
+
+	reservation := utils.IdempotencyRecord{Fingerprint: "a", ExpiresAt: time.Now().Add(time.Hour)}
+
+	_, reserved, err := storage.Reserve(ctx, "key", reservation)
+	assert.NoError(t, err)
+	assert.True(t, reserved)
+
+	// A reserved key is in progress until it is completed.
+	record, reserved, err := storage.Reserve(ctx, "key", utils.IdempotencyRecord{Fingerprint: "b"})
+	assert.NoError(t, err)
+	assert.False(t, reserved)
+	assert.Equal(t, reservation, record)
+
+	assert.NoError(t, storage.Release(ctx, "key"))
+
+	expired := utils.IdempotencyRecord{Fingerprint: "c", ExpiresAt: time.Now().Add(-time.Second)}
+
+	_, reserved, err = storage.Reserve(ctx, "key", expired)
+	assert.NoError(t, err)
+	assert.True(t, reserved)
+
+	// An expired record is replaced.
+	_, reserved, err = storage.Reserve(ctx, "key", reservation)
+	assert.NoError(t, err)
+	assert.True(t, reserved)
+}
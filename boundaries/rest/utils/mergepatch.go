@@ -0,0 +1,65 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// ContentTypeMergePatch is the media type of a JSON Merge Patch.
+const ContentTypeMergePatch = "application/merge-patch+json"
+
+// MergePatch will take a JSON document and a JSON Merge Patch, as
+// specified by RFC 7396, and return the patched document.
+//
+// A member of the patch with the value null removes the member from
+// the document, any other member replaces the member in the document,
+// where objects are merged recursively. A patch that isn't an object
+// replaces the whole document.
+func MergePatch(document, patch []byte) (_ []byte, err error) {
+	var patchValue, documentValue interface{}
+
+	if err = unmarshalNumbers(patch, &patchValue); err != nil {
+		err = fmt.Errorf("failed to unmarshal merge patch: %w", err)
+		return
+	}
+
+	if err = unmarshalNumbers(document, &documentValue); err != nil {
+		err = fmt.Errorf("failed to unmarshal document: %w", err)
+		return
+	}
+
+	return json.Marshal(mergePatch(documentValue, patchValue))
+}
+
+func mergePatch(document, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	documentObject, ok := document.(map[string]interface{})
+	if !ok {
+		documentObject = map[string]interface{}{}
+	}
+
+	for key, value := range patchObject {
+		if value == nil {
+			delete(documentObject, key)
+			continue
+		}
+
+		documentObject[key] = mergePatch(documentObject[key], value)
+	}
+
+	return documentObject
+}
+
+// unmarshalNumbers will unmarshal the data and keep
+// numbers as they are, instead of as float64.
+func unmarshalNumbers(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	return decoder.Decode(v)
+}
@@ -0,0 +1,42 @@
+package utils_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lonnblad/shipment-service-backend/boundaries/rest/utils"
+)
+
+// The test cases are the examples of RFC 7396, Appendix A.
+func Test_MergePatch(t *testing.T) {
+	tcs := []struct {
+		document, patch, expected string
+	}{
+		{document: `{"a":"b"}`, patch: `{"a":"c"}`, expected: `{"a":"c"}`},
+		{document: `{"a":"b"}`, patch: `{"b":"c"}`, expected: `{"a":"b","b":"c"}`},
+		{document: `{"a":"b"}`, patch: `{"a":null}`, expected: `{}`},
+		{document: `{"a":"b","b":"c"}`, patch: `{"a":null}`, expected: `{"b":"c"}`},
+		{document: `{"a":["b"]}`, patch: `{"a":"c"}`, expected: `{"a":"c"}`},
+		{document: `{"a":"c"}`, patch: `{"a":["b"]}`, expected: `{"a":["b"]}`},
+		{document: `{"a":{"b":"c"}}`, patch: `{"a":{"b":"d","c":null}}`, expected: `{"a":{"b":"d"}}`},
+		{document: `{"a":[{"b":"c"}]}`, patch: `{"a":[1]}`, expected: `{"a":[1]}`},
+		{document: `["a","b"]`, patch: `["c","d"]`, expected: `["c","d"]`},
+		{document: `{"a":"b"}`, patch: `["c"]`, expected: `["c"]`},
+		{document: `{"a":"foo"}`, patch: `null`, expected: `null`},
+		{document: `{"a":"foo"}`, patch: `"bar"`, expected: `"bar"`},
+		{document: `{"e":null}`, patch: `{"a":1}`, expected: `{"a":1,"e":null}`},
+		{document: `[1,2]`, patch: `{"a":"b","c":null}`, expected: `{"a":"b"}`},
+		{document: `{}`, patch: `{"a":{"bb":{"ccc":null}}}`, expected: `{"a":{"bb":{}}}`},
+	}
+
+	for _, tc := range tcs {
+		actual, err := utils.MergePatch([]byte(tc.document), []byte(tc.patch))
+		require.NoError(t, err)
+		assert.JSONEq(t, tc.expected, string(actual), "document: %s, patch: %s", tc.document, tc.patch)
+	}
+
+	_, err := utils.MergePatch([]byte(`{"a":"b"}`), []byte(`{"a":`))
+	assert.Error(t, err)
+}
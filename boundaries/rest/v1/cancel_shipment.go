@@ -0,0 +1,105 @@
+package v1
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/lonnblad/shipment-service-backend/boundaries/rest/utils"
+	"github.com/lonnblad/shipment-service-backend/businesslogic/models"
+	"github.com/lonnblad/shipment-service-backend/trace"
+)
+
+// @Summary Cancel Shipment
+// @Description Cancel a Shipment that hasn't been picked up yet, the price is refunded minus a cancellation fee,
+// @Description which depends on if the Shipment has been booked.
+// @Accept json
+// @Produce json
+// @Param tenant_id path string true "Tenant ID"
+// @Param shipment_id path string true "Shipment ID"
+// @Param If-Match header string false "ETag of the version of the Shipment that the request is based on"
+// @Param body body CancelShipmentRequest true "Cancellation Data"
+// @Success 200 {object} getShipmentResponse
+// @Header 200 {string} ETag "The version of the Shipment"
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 409 {object} utils.ErrorResponse
+// @Failure 412 {object} utils.ErrorResponse
+// @Failure 422 {object} utils.ErrorResponse
+// @Failure 503 {object} utils.ErrorResponse
+// @Router /v1/tenants/{tenant_id}/shipments/{shipment_id}/cancel [post]
+func (api *API) withCancelShipmentHandler() *API {
+	api.router.
+		Path(pathCancel).
+		Methods(http.MethodPost).
+		HandlerFunc(api.cancelShipmentHandler)
+
+	return api
+}
+
+func (api *API) cancelShipmentHandler(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+
+	ctx, span := trace.Tracer().Start(ctx, "v1.cancelShipmentHandler")
+	defer span.End()
+
+	reqData, err := parsedCancelShipmentRequest{}.parse(req)
+	if err != nil {
+		utils.WrapErrorAndWriteJSONResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	span.SetAttributes(
+		attribute.String("req.path.tenant_id", reqData.tenantID.String()),
+		attribute.String("req.path.shipment_id", reqData.shipmentID.String()),
+	)
+
+	internalShipment, err := api.logic.CancelShipment(
+		ctx, reqData.tenantID, reqData.shipmentID, reqData.precondition, reqData.body.toInternal(),
+	)
+	if err != nil {
+		writeErrorResponse(w, err)
+		return
+	}
+
+	output := getShipmentResponse{}.fromInternal(internalShipment)
+	output = output.decorateWithLinks(api.publicURL)
+
+	writeETag(w, internalShipment)
+	utils.MarshalAndWriteJSONResponse(w, http.StatusOK, output)
+}
+
+type parsedCancelShipmentRequest struct {
+	tenantID     uuid.UUID
+	shipmentID   uuid.UUID
+	precondition models.Precondition
+	body         CancelShipmentRequest
+}
+
+func (parsedCancelShipmentRequest) parse(req *http.Request) (_ parsedCancelShipmentRequest, err error) {
+	var out parsedCancelShipmentRequest
+
+	params := mux.Vars(req)
+
+	if out.tenantID, err = uuid.Parse(params[keyTenantID]); err != nil {
+		err = fmt.Errorf("could not parse tenant ID: %s, error: %w", params[keyTenantID], err)
+		return
+	}
+
+	if out.shipmentID, err = uuid.Parse(params[keyShipmentID]); err != nil {
+		err = fmt.Errorf("could not parse shipment ID: %s, error: %w", params[keyShipmentID], err)
+		return
+	}
+
+	out.precondition = parsePrecondition(req)
+
+	if err = utils.UnmarshalRequest(req.Body, &out.body); err != nil {
+		err = fmt.Errorf("could not parse request body: %w", err)
+		return
+	}
+
+	return out, nil
+}
@@ -0,0 +1,90 @@
+package v1
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/lonnblad/shipment-service-backend/boundaries/rest/utils"
+	"github.com/lonnblad/shipment-service-backend/trace"
+)
+
+// @Summary Create Quote
+// @Description Create a Quote of the price of a Shipment, which is honoured by a Shipment
+// @Description created with the quote ID before the Quote expires.
+// @Accept json
+// @Produce json
+// @Param tenant_id path string true "Tenant ID"
+// @Param body body CreateQuoteRequest true "Shipment Data"
+// @Success 201 {object} CreateQuoteResponse
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 422 {object} utils.ErrorResponse
+// @Failure 503 {object} utils.ErrorResponse
+// @Router /v1/tenants/{tenant_id}/quotes [post]
+func (api *API) withCreateQuoteHandler() *API {
+	api.router.
+		Path(pathQuotes).
+		Methods(http.MethodPost).
+		HandlerFunc(api.createQuoteHandler)
+
+	return api
+}
+
+func (api *API) createQuoteHandler(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+
+	ctx, span := trace.Tracer().Start(ctx, "v1.createQuoteHandler")
+	defer span.End()
+
+	reqData, err := parsedCreateQuoteRequest{}.parse(req)
+	if err != nil {
+		utils.WrapErrorAndWriteJSONResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	span.SetAttributes(
+		attribute.String("req.path.tenant_id", reqData.tenantID.String()),
+	)
+
+	internalQuote, err := api.logic.CreateQuote(ctx, reqData.body.toInternal(reqData.tenantID))
+	if err != nil {
+		writeErrorResponse(w, err)
+		return
+	}
+
+	output := CreateQuoteResponse{}.fromInternal(internalQuote)
+	output = output.decorateWithLinks(api.publicURL)
+
+	utils.MarshalAndWriteJSONResponse(w, http.StatusCreated, output)
+}
+
+type parsedCreateQuoteRequest struct {
+	tenantID uuid.UUID
+	body     CreateQuoteRequest
+}
+
+func (parsedCreateQuoteRequest) parse(req *http.Request) (_ parsedCreateQuoteRequest, err error) {
+	var out parsedCreateQuoteRequest
+
+	params := mux.Vars(req)
+
+	if out.tenantID, err = uuid.Parse(params[keyTenantID]); err != nil {
+		err = fmt.Errorf("could not parse tenant ID: %s, error: %w", params[keyTenantID], err)
+		return
+	}
+
+	if err = utils.UnmarshalRequest(req.Body, &out.body); err != nil {
+		err = fmt.Errorf("could not parse request body: %w", err)
+		return
+	}
+
+	if err = out.body.validate(); err != nil {
+		err = fmt.Errorf("could not parse request body: %w", err)
+		return
+	}
+
+	return out, nil
+}
@@ -17,14 +17,20 @@ import (
 // @Accept json
 // @Produce json
 // @Param tenant_id path string true "Tenant ID"
+// @Param Idempotency-Key header string false "Key of the request, a retry with the same key and body replays the first response"
 // @Param body body CreateShipmentRequest true "Shipment Data"
-// @Success 200 {object} CreateShipmentResponse
+// @Success 201 {object} CreateShipmentResponse
+// @Header 201 {string} ETag "The version of the Shipment"
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 409 {object} utils.ErrorResponse "The Idempotency-Key is used by a request that is in progress"
+// @Failure 422 {object} utils.ErrorResponse "The Shipment or its Quote is invalid, or the Idempotency-Key was used with another body"
+// @Failure 503 {object} utils.ErrorResponse
 // @Router /v1/tenants/{tenant_id}/shipments [post]
 func (api *API) withCreateShipmentHandler() *API {
 	api.router.
 		Path(pathShipments).
 		Methods(http.MethodPost).
-		HandlerFunc(api.createShipmentHandler)
+		Handler(api.idempotency.Middleware(http.HandlerFunc(api.createShipmentHandler)))
 
 	return api
 }
@@ -38,6 +44,7 @@ func (api *API) createShipmentHandler(w http.ResponseWriter, req *http.Request)
 	reqData, err := parsedCreateShipmentRequest{}.parse(req)
 	if err != nil {
 		utils.WrapErrorAndWriteJSONResponse(w, http.StatusBadRequest, err)
+		return
 	}
 
 	span.SetAttributes(
@@ -48,13 +55,14 @@ func (api *API) createShipmentHandler(w http.ResponseWriter, req *http.Request)
 
 	internalShipment, err = api.logic.CreateShipment(ctx, internalShipment)
 	if err != nil {
-		utils.WrapErrorAndWriteJSONResponse(w, http.StatusBadRequest, err)
+		writeErrorResponse(w, err)
 		return
 	}
 
 	output := CreateShipmentResponse{}.fromInternal(internalShipment)
 	output = output.decorateWithLinks(api.publicURL)
 
+	writeETag(w, internalShipment)
 	utils.MarshalAndWriteJSONResponse(w, http.StatusCreated, output)
 }
 
@@ -78,5 +86,10 @@ func (parsedCreateShipmentRequest) parse(req *http.Request) (_ parsedCreateShipm
 		return
 	}
 
+	if err = out.body.validate(); err != nil {
+		err = fmt.Errorf("could not parse request body: %w", err)
+		return
+	}
+
 	return out, nil
 }
@@ -0,0 +1,55 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/lonnblad/shipment-service-backend/boundaries/rest/utils"
+	"github.com/lonnblad/shipment-service-backend/businesslogic/models"
+	"github.com/lonnblad/shipment-service-backend/businesslogic/price"
+	"github.com/lonnblad/shipment-service-backend/storage"
+)
+
+// writeErrorResponse will write the error with the
+// status code that corresponds to the error.
+func writeErrorResponse(w http.ResponseWriter, err error) {
+	utils.WrapErrorAndWriteJSONResponse(w, statusCodeFromError(err), err)
+}
+
+func statusCodeFromError(err error) int {
+	var (
+		validationErr   models.ValidationError
+		weightClassErr  price.WeightClassError
+		countryCodeErr  price.CountryCodeError
+		rateCardErr     price.RateCardError
+		currencyErr     price.CurrencyError
+		exchangeRateErr price.ExchangeRateError
+		transitionErr   models.TransitionError
+		notEditableErr  models.NotEditableError
+		preconditionErr models.PreconditionFailedError
+	)
+
+	switch {
+	case errors.Is(err, storage.ErrInvalidCursor):
+		return http.StatusBadRequest
+	case errors.Is(err, storage.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, storage.ErrConflict),
+		errors.As(err, &transitionErr),
+		errors.As(err, &notEditableErr):
+		return http.StatusConflict
+	case errors.As(err, &preconditionErr):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, storage.ErrUnavailable):
+		return http.StatusServiceUnavailable
+	case errors.As(err, &validationErr),
+		errors.As(err, &weightClassErr),
+		errors.As(err, &countryCodeErr),
+		errors.As(err, &rateCardErr),
+		errors.As(err, &currencyErr),
+		errors.As(err, &exchangeRateErr):
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusInternalServerError
+	}
+}
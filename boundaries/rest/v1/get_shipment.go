@@ -13,11 +13,18 @@ import (
 )
 
 // @Summary Get Shipment
-// @Description Get Shipment
+// @Description Get Shipment, the ETag is the version of the Shipment, which is changed by every update
+// @Description and transition, but not by its tracking events.
 // @Produce json
 // @Param tenant_id path string true "Tenant ID"
 // @Param shipment_id path string true "Shipment ID"
+// @Param If-None-Match header string false "ETag of a cached version of the Shipment"
 // @Success 200 {object} getShipmentResponse
+// @Header 200 {string} ETag "The version of the Shipment"
+// @Success 304 "The Shipment still has the version of If-None-Match"
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 503 {object} utils.ErrorResponse
 // @Router /v1/tenants/{tenant_id}/shipments/{shipment_id} [get]
 func (api *API) withGetShipmentHandler() *API {
 	api.router.
@@ -37,6 +44,7 @@ func (api *API) getShipmentHandler(w http.ResponseWriter, req *http.Request) {
 	reqData, err := parsedGetShipmentRequest{}.parse(req)
 	if err != nil {
 		utils.WrapErrorAndWriteJSONResponse(w, http.StatusBadRequest, err)
+		return
 	}
 
 	span.SetAttributes(
@@ -46,19 +54,27 @@ func (api *API) getShipmentHandler(w http.ResponseWriter, req *http.Request) {
 
 	internalShipment, err := api.logic.GetShipment(ctx, reqData.tenantID, reqData.shipmentID)
 	if err != nil {
-		utils.WrapErrorAndWriteJSONResponse(w, http.StatusBadRequest, err)
+		writeErrorResponse(w, err)
+		return
+	}
+
+	writeETag(w, internalShipment)
+
+	if utils.MatchesIfNoneMatch(reqData.ifNoneMatch, utils.ETag(internalShipment.Version)) {
+		w.WriteHeader(http.StatusNotModified)
 		return
 	}
 
 	output := getShipmentResponse{}.fromInternal(internalShipment)
 	output = output.decorateWithLinks(api.publicURL)
 
-	utils.MarshalAndWriteJSONResponse(w, http.StatusCreated, output)
+	utils.MarshalAndWriteJSONResponse(w, http.StatusOK, output)
 }
 
 type parsedGetShipmentRequest struct {
-	tenantID   uuid.UUID
-	shipmentID uuid.UUID
+	tenantID    uuid.UUID
+	shipmentID  uuid.UUID
+	ifNoneMatch string
 }
 
 func (parsedGetShipmentRequest) parse(req *http.Request) (_ parsedGetShipmentRequest, err error) {
@@ -76,5 +92,7 @@ func (parsedGetShipmentRequest) parse(req *http.Request) (_ parsedGetShipmentReq
 		return
 	}
 
+	out.ifNoneMatch = req.Header.Get(utils.HeaderIfNoneMatch)
+
 	return out, nil
 }
@@ -2,13 +2,21 @@ package v1
 
 import (
 	"fmt"
+	"math"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 	"go.opentelemetry.io/otel/attribute"
 
 	"github.com/lonnblad/shipment-service-backend/boundaries/rest/utils"
+	"github.com/lonnblad/shipment-service-backend/businesslogic/models"
+	"github.com/lonnblad/shipment-service-backend/businesslogic/price"
+	"github.com/lonnblad/shipment-service-backend/storage"
 	"github.com/lonnblad/shipment-service-backend/trace"
 )
 
@@ -23,8 +31,22 @@ const (
 // @Produce json
 // @Param tenant_id path string true "Tenant ID"
 // @Param limit query int false "Limit" minimum(1) maximum(100) default(10)
-// @Param offset query int false "Offset" default(0)
+// @Param cursor query string false "Cursor, taken from the first, prev, next or last link of another page"
+// @Param offset query int false "Offset, deprecated in favour of the cursor" default(0)
+// @Param sort query string false "createdAt, package.price, package.weight, sender.countryCode or receiver.countryCode, - for descending"
+// @Param sender.countryCode query string false "Sender country code"
+// @Param receiver.countryCode query string false "Receiver country code"
+// @Param createdAt.from query string false "Created at or after" format(date-time)
+// @Param createdAt.to query string false "Created before" format(date-time)
+// @Param package.weightUnit query string false "Unit of the package weight filter" Enums(g, kg, lb) default(kg)
+// @Param package.weight.min query number false "Min package weight in the package.weightUnit, inclusive"
+// @Param package.weight.max query number false "Max package weight in the package.weightUnit, inclusive"
+// @Param package.currency query string false "Currency of the package price, ISO 4217, required by the price filter and sort"
+// @Param package.price.min query int false "Min package price in the minor units of the package.currency, e.g. öre for SEK, inclusive"
+// @Param package.price.max query int false "Max package price in the minor units of the package.currency, e.g. öre for SEK, inclusive"
 // @Success 200 {object} listShipmentsResponse
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 503 {object} utils.ErrorResponse
 // @Router /v1/tenants/{tenant_id}/shipments [get]
 func (api *API) withListShipmentsHandler() *API {
 	api.router.
@@ -44,30 +66,48 @@ func (api *API) listShipmentsHandler(w http.ResponseWriter, req *http.Request) {
 	reqData, err := parsedListShipmentsRequest{}.parse(req)
 	if err != nil {
 		utils.WrapErrorAndWriteJSONResponse(w, http.StatusBadRequest, err)
+		return
 	}
 
 	span.SetAttributes(
 		attribute.String("req.path.tenant_id", reqData.tenantID.String()),
 		attribute.Int("req.query.limit", reqData.limit),
 		attribute.Int("req.query.offset", reqData.offset),
+		attribute.Bool("req.query.cursor", reqData.cursor != nil),
 	)
 
-	internalShipments, err := api.logic.ListShipments(ctx, reqData.tenantID, reqData.limit, reqData.offset)
+	query := models.ListShipmentsQuery{
+		Limit:  reqData.limit,
+		Filter: reqData.filter,
+		Sort:   reqData.sort,
+		Cursor: reqData.cursor,
+		Offset: reqData.offset,
+	}
+
+	internalPage, err := api.logic.ListShipments(ctx, reqData.tenantID, query)
 	if err != nil {
-		utils.WrapErrorAndWriteJSONResponse(w, http.StatusBadRequest, err)
+		writeErrorResponse(w, err)
 		return
 	}
 
-	output := listShipmentsResponse{}.fromInternal(internalShipments)
+	output := listShipmentsResponse{}.fromInternal(internalPage)
 	output = output.decorateWithLinks(api.publicURL, reqData)
 
-	utils.MarshalAndWriteJSONResponse(w, http.StatusCreated, output)
+	utils.MarshalAndWriteJSONResponse(w, http.StatusOK, output)
 }
 
 type parsedListShipmentsRequest struct {
 	tenantID uuid.UUID
 	limit    int
+	filter   storage.ShipmentsFilter
+	sort     storage.Sort
+	cursor   *storage.Cursor
 	offset   int
+
+	// path and linkQuery are the path and the query params
+	// other than the pagination, to keep them in the links.
+	path      string
+	linkQuery url.Values
 }
 
 func (parsedListShipmentsRequest) parse(req *http.Request) (_ parsedListShipmentsRequest, err error) {
@@ -80,6 +120,8 @@ func (parsedListShipmentsRequest) parse(req *http.Request) (_ parsedListShipment
 		return
 	}
 
+	out.path = "/v1/tenants/" + out.tenantID.String() + "/shipments"
+
 	const (
 		defaultLimit = defaultLimitListShipments
 		maxLimit     = maxLimitListShipments
@@ -99,5 +141,289 @@ func (parsedListShipmentsRequest) parse(req *http.Request) (_ parsedListShipment
 		return
 	}
 
+	if out.sort, err = parseSortParam(req.URL.Query().Get("sort")); err != nil {
+		return
+	}
+
+	if out.cursor, err = parseCursorParam(req.URL.Query()); err != nil {
+		return
+	}
+
+	if out.filter, out.linkQuery, err = parseShipmentsFilter(req.URL.Query()); err != nil {
+		err = fmt.Errorf("could not parse filter: %w", err)
+		return
+	}
+
+	// Prices in different currencies can't be compared, so
+	// the price filter and sort are scoped to one currency.
+	if out.sort.Field == storage.SortByPackagePrice && out.filter.Currency == "" {
+		err = fmt.Errorf("%s is required to sort by %s", keyPackageCurrency, storage.SortByPackagePrice)
+		return
+	}
+
 	return out, nil
 }
+
+const (
+	sortDescendingPrefix = "-"
+	sortByCreatedAt      = "createdAt"
+)
+
+var listShipmentsSortFields = []storage.SortField{
+	storage.SortByPackagePrice,
+	storage.SortByPackageWeight,
+	storage.SortBySenderCountryCode,
+	storage.SortByReceiverCountryCode,
+}
+
+// parseSortParam will parse a sort field, which is sorted in descending order
+// when it is prefixed with a -, the default is to sort by creation time.
+func parseSortParam(sortStr string) (sort storage.Sort, err error) {
+	if sortStr == "" {
+		return
+	}
+
+	field := strings.TrimPrefix(sortStr, sortDescendingPrefix)
+	sort.Descending = field != sortStr
+
+	if field == sortByCreatedAt {
+		return sort, nil
+	}
+
+	for _, sortField := range listShipmentsSortFields {
+		if field == string(sortField) {
+			sort.Field = sortField
+			return sort, nil
+		}
+	}
+
+	supported := sortByCreatedAt + ", " + joinSortFields(listShipmentsSortFields)
+	err = fmt.Errorf("unknown sort field: %s, supported fields are: %s", field, supported)
+
+	return
+}
+
+func formatSortParam(sort storage.Sort) string {
+	field := string(sort.Field)
+	if sort.Field == storage.SortByCreatedAt {
+		field = sortByCreatedAt
+	}
+
+	if sort.Descending {
+		return sortDescendingPrefix + field
+	}
+
+	return field
+}
+
+func joinSortFields(fields []storage.SortField) string {
+	strs := make([]string, len(fields))
+	for idx, field := range fields {
+		strs[idx] = string(field)
+	}
+
+	return strings.Join(strs, ", ")
+}
+
+func parseCursorParam(query url.Values) (_ *storage.Cursor, err error) {
+	cursorStr := query.Get("cursor")
+	if cursorStr == "" {
+		return
+	}
+
+	if query.Get("offset") != "" {
+		err = fmt.Errorf("cursor and offset can't be combined")
+		return
+	}
+
+	cursor, err := storage.ParseCursor(cursorStr)
+	if err != nil {
+		err = fmt.Errorf("could not parse cursor: %w", err)
+		return
+	}
+
+	return &cursor, nil
+}
+
+const (
+	keySenderCountryCode   = "sender.countryCode"
+	keyReceiverCountryCode = "receiver.countryCode"
+	keyCreatedAtFrom       = "createdAt.from"
+	keyCreatedAtTo         = "createdAt.to"
+	keyPackageWeightUnit   = "package.weightUnit"
+	keyPackageWeightMin    = "package.weight.min"
+	keyPackageWeightMax    = "package.weight.max"
+	keyPackageCurrency     = "package.currency"
+	keyPackagePriceMin     = "package.price.min"
+	keyPackagePriceMax     = "package.price.max"
+)
+
+var listShipmentsPaginationKeys = map[string]bool{"limit": true, "cursor": true, "offset": true, "sort": true}
+
+var listShipmentsFilterKeys = []string{
+	keySenderCountryCode, keyReceiverCountryCode,
+	keyCreatedAtFrom, keyCreatedAtTo,
+	keyPackageWeightUnit, keyPackageWeightMin, keyPackageWeightMax,
+	keyPackageCurrency, keyPackagePriceMin, keyPackagePriceMax,
+}
+
+// parseShipmentsFilter will parse the filter from the query params,
+// where any query param that isn't a filter or used for pagination
+// is rejected.
+func parseShipmentsFilter(query url.Values) (filter storage.ShipmentsFilter, filterQuery url.Values, err error) {
+	filterQuery = url.Values{}
+
+	for key := range query {
+		if listShipmentsPaginationKeys[key] {
+			continue
+		}
+
+		if !isListShipmentsFilterKey(key) {
+			err = fmt.Errorf("unknown filter: %s, supported filters are: %s", key, strings.Join(listShipmentsFilterKeys, ", "))
+			return
+		}
+
+		filterQuery.Set(key, query.Get(key))
+	}
+
+	filter.SenderCountryCode = strings.ToUpper(filterQuery.Get(keySenderCountryCode))
+	filter.ReceiverCountryCode = strings.ToUpper(filterQuery.Get(keyReceiverCountryCode))
+
+	if filter.CreatedFrom, err = parseTimeParam(filterQuery, keyCreatedAtFrom); err != nil {
+		return
+	}
+
+	if filter.CreatedTo, err = parseTimeParam(filterQuery, keyCreatedAtTo); err != nil {
+		return
+	}
+
+	if !filter.CreatedFrom.IsZero() && !filter.CreatedTo.IsZero() && !filter.CreatedFrom.Before(filter.CreatedTo) {
+		err = fmt.Errorf("%s must be before %s", keyCreatedAtFrom, keyCreatedAtTo)
+		return
+	}
+
+	if filter.MinWeight, filter.MaxWeight, err = parseWeightRangeParams(filterQuery); err != nil {
+		return
+	}
+
+	if filter.Currency, err = parseCurrencyParam(filterQuery, keyPackageCurrency); err != nil {
+		return
+	}
+
+	if filter.MinPrice, filter.MaxPrice, err = parseRangeParams(filterQuery, keyPackagePriceMin, keyPackagePriceMax); err != nil {
+		return
+	}
+
+	if (filter.MinPrice != nil || filter.MaxPrice != nil) && filter.Currency == "" {
+		err = fmt.Errorf("%s is required by %s and %s", keyPackageCurrency, keyPackagePriceMin, keyPackagePriceMax)
+		return
+	}
+
+	return filter, filterQuery, nil
+}
+
+func isListShipmentsFilterKey(key string) bool {
+	for _, filterKey := range listShipmentsFilterKeys {
+		if key == filterKey {
+			return true
+		}
+	}
+
+	return false
+}
+
+func parseTimeParam(query url.Values, key string) (_ time.Time, err error) {
+	value := query.Get(key)
+	if value == "" {
+		return
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		err = fmt.Errorf("%s: %s is not an RFC 3339 date-time: %w", key, value, err)
+		return
+	}
+
+	return t.UTC(), nil
+}
+
+// parseCurrencyParam will parse an ISO 4217 currency
+// code, which has to be supported by the prices.
+func parseCurrencyParam(query url.Values, key string) (_ string, err error) {
+	value := strings.ToUpper(query.Get(key))
+	if value == "" {
+		return
+	}
+
+	if _, err = price.MinorUnits(value); err != nil {
+		err = fmt.Errorf("%s: %w", key, err)
+		return
+	}
+
+	return value, nil
+}
+
+// parseWeightRangeParams will parse the range of the weight, which can be
+// decimal and is in the weight unit, or kg when the weight unit isn't given.
+// The shipments are stored with their weight in grams, which the range is
+// returned in, rounded to the closest gram.
+func parseWeightRangeParams(query url.Values) (min, max *int, err error) {
+	unit := weightUnit(query.Get(keyPackageWeightUnit))
+	if _, ok := gramsPerWeightUnit[unit]; !ok {
+		err = fmt.Errorf("%s: %s is not one of: g, kg or lb", keyPackageWeightUnit, unit)
+		return
+	}
+
+	for _, param := range []struct {
+		key   string
+		value **int
+	}{{key: keyPackageWeightMin, value: &min}, {key: keyPackageWeightMax, value: &max}} {
+		valueStr := query.Get(param.key)
+		if valueStr == "" {
+			continue
+		}
+
+		weight, parseErr := strconv.ParseFloat(valueStr, 64)
+		if parseErr != nil || math.IsNaN(weight) || math.IsInf(weight, 0) {
+			err = fmt.Errorf("%s: %s is not a number", param.key, valueStr)
+			return
+		}
+
+		grams := unit.grams(weight)
+		*param.value = &grams
+	}
+
+	if min != nil && max != nil && *min > *max {
+		err = fmt.Errorf("%s must not be greater than %s", keyPackageWeightMin, keyPackageWeightMax)
+		return
+	}
+
+	return min, max, nil
+}
+
+func parseRangeParams(query url.Values, minKey, maxKey string) (min, max *int, err error) {
+	for _, param := range []struct {
+		key   string
+		value **int
+	}{{key: minKey, value: &min}, {key: maxKey, value: &max}} {
+		valueStr := query.Get(param.key)
+		if valueStr == "" {
+			continue
+		}
+
+		var value int
+		if value, err = strconv.Atoi(valueStr); err != nil {
+			err = fmt.Errorf("%s: %s is not an integer: %w", param.key, valueStr, err)
+			return
+		}
+
+		*param.value = &value
+	}
+
+	if min != nil && max != nil && *min > *max {
+		err = fmt.Errorf("%s must not be greater than %s", minKey, maxKey)
+		return
+	}
+
+	return min, max, nil
+}
@@ -1,6 +1,9 @@
 package v1
 
 import (
+	"encoding/json"
+	"fmt"
+	"math"
 	"net/url"
 	"strconv"
 	"time"
@@ -9,6 +12,8 @@ import (
 
 	"github.com/lonnblad/shipment-service-backend/boundaries/rest/utils"
 	"github.com/lonnblad/shipment-service-backend/businesslogic/models"
+	"github.com/lonnblad/shipment-service-backend/businesslogic/price"
+	"github.com/lonnblad/shipment-service-backend/storage"
 )
 
 const (
@@ -17,14 +22,28 @@ const (
 
 	pathShipments = "/tenants/{" + keyTenantID + ":" + utils.RegexpUUID + "}/shipments"
 	pathShipment  = pathShipments + "/{" + keyShipmentID + ":" + utils.RegexpUUID + "}"
+
+	pathSearchShipments = pathShipments + "/search"
+	pathTransitions     = pathShipment + "/transitions"
+	pathTrackingEvents  = pathShipment + "/tracking-events"
+	pathCancel          = pathShipment + "/cancel"
+
+	pathQuotes = "/tenants/{" + keyTenantID + ":" + utils.RegexpUUID + "}/quotes"
+
+	pathRateCards       = "/admin/tenants/{" + keyTenantID + ":" + utils.RegexpUUID + "}/rate-cards"
+	pathRateCardPreview = pathRateCards + "/preview"
 )
 
-type CreateShipmentRequest struct {
+// ShipmentDetails are the sender, receiver and package or parcels of a
+// shipment, which are the details that can be quoted and updated.
+type ShipmentDetails struct {
 	Sender struct {
 		Name        string `json:"name" example:"User Example A"`
 		Email       string `json:"email" format:"email"`
 		Address     string `json:"address" example:"Apt. Example 1A"`
 		CountryCode string `json:"countryCode" example:"SE"`
+		// VATNumber is only set when the sender is a business registered for VAT.
+		VATNumber string `json:"vatNumber,omitempty" example:"SE556677889901"`
 	} `json:"sender"`
 
 	Receiver struct {
@@ -34,19 +53,177 @@ type CreateShipmentRequest struct {
 		CountryCode string `json:"countryCode" example:"DE"`
 	} `json:"receiver"`
 
-	Package struct {
-		Weight int `json:"weight" example:"10"`
-	} `json:"package"`
+	// Package is the only parcel of a shipment, it is not given when the shipment has parcels.
+	Package packageDetails `json:"package"`
+
+	// Parcels are the packages of a shipment with several parcels.
+	Parcels []parcelDetails `json:"parcels,omitempty"`
 }
 
-func (s CreateShipmentRequest) toInternal(tenantID uuid.UUID) models.Shipment {
+// packageDetails has a weight in the weight unit, which defaults to kg, and optionally
+// a length, width and height in cm, either all of the dimensions are given or none
+// of them. The weight is rounded to the closest gram.
+type packageDetails struct {
+	Weight     float64    `json:"weight" example:"10.5"`
+	WeightUnit weightUnit `json:"weightUnit,omitempty" enums:"g,kg,lb" example:"kg"`
+	Length     int        `json:"length,omitempty" example:"40"`
+	Width      int        `json:"width,omitempty" example:"30"`
+	Height     int        `json:"height,omitempty" example:"20"`
+}
+
+// parcelDetails is one of the packages of a shipment, the ID is only used when a
+// shipment is updated, where a parcel without an ID is added to the shipment.
+type parcelDetails struct {
+	ID *uuid.UUID `json:"id,omitempty" format:"uuid"`
+	packageDetails
+}
+
+func (p packageDetails) toInternal() (internal models.Package) {
+	internal.Weight = p.WeightUnit.grams(p.Weight)
+	internal.Length = p.Length
+	internal.Width = p.Width
+	internal.Height = p.Height
+
+	return
+}
+
+func (p packageDetails) fromInternal(internal models.Package) packageDetails {
+	p.Weight = kilograms(internal.Weight)
+	p.WeightUnit = weightUnitKilogram
+	p.Length = internal.Length
+	p.Width = internal.Width
+	p.Height = internal.Height
+
+	return p
+}
+
+// weightUnit is the unit of the weight of a package, which is kg when it isn't given.
+type weightUnit string
+
+const (
+	weightUnitGram     weightUnit = "g"
+	weightUnitKilogram weightUnit = "kg"
+	weightUnitPound    weightUnit = "lb"
+)
+
+// gramsPerWeightUnit is the grams of one of every weight unit.
+var gramsPerWeightUnit = map[weightUnit]float64{
+	"":                 1000,
+	weightUnitGram:     1,
+	weightUnitKilogram: 1000,
+	weightUnitPound:    453.59237,
+}
+
+// UnmarshalJSON will return an error if the weight unit isn't supported.
+func (u *weightUnit) UnmarshalJSON(data []byte) error {
+	var unit string
+	if err := json.Unmarshal(data, &unit); err != nil {
+		return err
+	}
+
+	if _, ok := gramsPerWeightUnit[weightUnit(unit)]; !ok {
+		return fmt.Errorf("weight unit: %s is not one of: g, kg or lb", unit)
+	}
+
+	*u = weightUnit(unit)
+
+	return nil
+}
+
+// grams will return the weight in the weight unit in grams, rounded to the closest gram.
+func (u weightUnit) grams(weight float64) int {
+	return int(math.Round(weight * gramsPerWeightUnit[u]))
+}
+
+// kilograms will return the weight in grams in kg.
+func kilograms(grams int) float64 {
+	return float64(grams) / gramsPerWeightUnit[weightUnitKilogram]
+}
+
+// validate will return an error if both a package and parcels are given.
+func (s ShipmentDetails) validate() error {
+	if len(s.Parcels) > 0 && s.Package != (packageDetails{}) {
+		return fmt.Errorf("a shipment has either a package or parcels, not both")
+	}
+
+	return nil
+}
+
+// toInternal will return the shipment with the package as its only
+// parcel, unless the shipment has parcels.
+func (s ShipmentDetails) toInternal(tenantID uuid.UUID) models.Shipment {
 	var internal models.Shipment
 
 	internal.TenantID = tenantID
 
 	internal.Sender = models.Sender(s.Sender)
 	internal.Receiver = models.Receiver(s.Receiver)
-	internal.Package.Weight = s.Package.Weight
+
+	parcels := []models.Parcel{{Package: s.Package.toInternal()}}
+
+	if len(s.Parcels) > 0 {
+		parcels = make([]models.Parcel, len(s.Parcels))
+
+		for idx, parcel := range s.Parcels {
+			parcels[idx].Package = parcel.toInternal()
+
+			if parcel.ID != nil {
+				parcels[idx].ID = *parcel.ID
+			}
+		}
+	}
+
+	return internal.WithParcels(parcels)
+}
+
+func (s ShipmentDetails) fromInternal(internal models.Shipment) ShipmentDetails {
+	s.Sender.Name = internal.Sender.Name
+	s.Sender.Email = internal.Sender.Email
+	s.Sender.Address = internal.Sender.Address
+	s.Sender.CountryCode = internal.Sender.CountryCode
+	s.Sender.VATNumber = internal.Sender.VATNumber
+
+	s.Receiver.Name = internal.Receiver.Name
+	s.Receiver.Email = internal.Receiver.Email
+	s.Receiver.Address = internal.Receiver.Address
+	s.Receiver.CountryCode = internal.Receiver.CountryCode
+
+	s.Package, s.Parcels = packageDetails{}, nil
+
+	if len(internal.Parcels) <= 1 {
+		s.Package = packageDetails{}.fromInternal(internal.Package)
+		return s
+	}
+
+	s.Parcels = make([]parcelDetails, len(internal.Parcels))
+
+	for idx, parcel := range internal.Parcels {
+		parcelID := parcel.ID
+		s.Parcels[idx] = parcelDetails{ID: &parcelID, packageDetails: packageDetails{}.fromInternal(parcel.Package)}
+	}
+
+	return s
+}
+
+type CreateShipmentRequest struct {
+	ShipmentDetails
+
+	// QuoteID is a quote that hasn't expired, the quoted price and VAT are honoured
+	// if the weight, dimensions, countries and sender VAT number match the quote.
+	QuoteID *uuid.UUID `json:"quoteId,omitempty" format:"uuid"`
+
+	// Currency is the ISO 4217 code of the currency that the shipment is priced in,
+	// it defaults to the currency of the quote or the default currency of the tenant.
+	Currency string `json:"currency,omitempty" example:"EUR"`
+}
+
+func (s CreateShipmentRequest) toInternal(tenantID uuid.UUID) models.Shipment {
+	internal := s.ShipmentDetails.toInternal(tenantID)
+	internal.Package.Currency = s.Currency
+
+	if s.QuoteID != nil {
+		internal.QuoteID = *s.QuoteID
+	}
 
 	return internal
 }
@@ -59,12 +236,7 @@ func (s CreateShipmentResponse) fromInternal(internal models.Shipment) (out Crea
 }
 
 func (s CreateShipmentResponse) decorateWithLinks(url url.URL) CreateShipmentResponse {
-	s.Links = make([]link, 1)
-
-	url.Path = "/v1/tenants/" + s.Shipment.TenantID.String() + "/shipments/" + s.Shipment.ID.String()
-	s.Links[0] = link{Rel: "self", Href: url.String()}
-
-	return s
+	return CreateShipmentResponse(getShipmentResponse(s).decorateWithLinks(url))
 }
 
 type shipment struct {
@@ -72,78 +244,405 @@ type shipment struct {
 	ID        uuid.UUID `json:"id" format:"uuid"`
 	TenantID  uuid.UUID `json:"tenantId" format:"uuid"`
 	CreatedAt time.Time `json:"createdAt" format:"date-time"`
+	Status    string    `json:"status" example:"created"`
+
+	// RateCardVersion is the version of the price rules that priced the shipment,
+	// it is not included for shipments that were created before it was stored.
+	RateCardVersion string `json:"rateCardVersion,omitempty" example:"2021-01-01"`
 
-	Package struct {
-		Weight int      `json:"weight"`
-		Price  currency `json:"price"`
-	} `json:"package"`
+	// Package is the total weight and price of the parcels.
+	Package responsePackage  `json:"package"`
+	Parcels []responseParcel `json:"parcels"`
+
+	Cancellation *cancellation `json:"cancellation,omitempty"`
+}
+
+// responsePackage is the package of a shipment or quote with its price, the
+// weights are always in kg and the chargeable weight is the greater of the
+// weight and the volumetric weight.
+type responsePackage struct {
+	Weight           float64      `json:"weight" example:"10.5"`
+	WeightUnit       weightUnit   `json:"weightUnit" example:"kg"`
+	Length           int          `json:"length,omitempty"`
+	Width            int          `json:"width,omitempty"`
+	Height           int          `json:"height,omitempty"`
+	ChargeableWeight float64      `json:"chargeableWeight" example:"10.5"`
+	Price            packagePrice `json:"price"`
+}
+
+func (p responsePackage) fromInternal(
+	internal models.Package, breakdown *models.PriceBreakdown, rate *models.ExchangeRate, tax *models.Tax,
+) responsePackage {
+	p.Weight = kilograms(internal.Weight)
+	p.WeightUnit = weightUnitKilogram
+	p.Length = internal.Length
+	p.Width = internal.Width
+	p.Height = internal.Height
+
+	// Packages that were priced before the chargeable weight was
+	// stored didn't have any dimensions, so they were charged by weight.
+	p.ChargeableWeight = kilograms(internal.Weight)
+	if breakdown != nil && breakdown.ChargeableWeight != 0 {
+		p.ChargeableWeight = kilograms(breakdown.ChargeableWeight)
+	}
+
+	p.Price = packagePrice{}.fromInternal(internal.Price, internal.Currency, breakdown, rate)
+
+	if tax != nil {
+		vat := priceVAT{}.fromInternal(*tax, internal.Currency)
+		p.Price.VAT = &vat
+	}
+
+	return p
+}
+
+// responseParcel is a parcel of a shipment or quote with its price,
+// which is before the consignment discount of the shipment or quote.
+type responseParcel struct {
+	ID uuid.UUID `json:"id" format:"uuid"`
+	responsePackage
 }
 
+func (p responseParcel) fromInternal(internal models.Parcel, rate *models.ExchangeRate) responseParcel {
+	p.ID = internal.ID
+	p.responsePackage = responsePackage{}.fromInternal(internal.Package, internal.PriceBreakdown, rate, nil)
+
+	return p
+}
+
+func responseParcels(internal []models.Parcel, rate *models.ExchangeRate) []responseParcel {
+	parcels := make([]responseParcel, len(internal))
+
+	for idx, parcel := range internal {
+		parcels[idx] = responseParcel{}.fromInternal(parcel, rate)
+	}
+
+	return parcels
+}
+
+type cancellation struct {
+	CancelledBy string    `json:"cancelledBy" example:"user@example.com"`
+	CancelledAt time.Time `json:"cancelledAt" format:"date-time"`
+	Reason      string    `json:"reason" example:"Ordered twice"`
+	Fee         currency  `json:"fee"`
+	Refund      currency  `json:"refund"`
+}
+
+// currency is an amount in the minor units of the currency, the
+// amount divided by the decimal multiplier is the amount in units.
 type currency struct {
-	Amount            int    `json:"amount"`
-	DecimalMultiplier int    `json:"decimalMultiplier"`
-	Currency          string `json:"string"`
+	Amount            int    `json:"amount" example:"10000"`
+	DecimalMultiplier int    `json:"decimalMultiplier" example:"100"`
+	Currency          string `json:"string" example:"SEK"`
+}
+
+func (c currency) fromInternal(amount int, code string) currency {
+	c.Amount = amount
+	c.Currency = code
+	c.DecimalMultiplier = 1
+
+	if units, err := price.MinorUnits(code); err == nil {
+		c.DecimalMultiplier = units
+	}
+
+	return c
+}
+
+type packagePrice struct {
+	currency
+
+	// Breakdown is how the price was calculated, it is not included for
+	// shipments that were created before the breakdown was stored.
+	Breakdown *priceBreakdown `json:"breakdown,omitempty"`
+
+	// ExchangeRate is the rate that the price was converted with from the currency
+	// of the breakdown, it is not included when the price wasn't converted.
+	ExchangeRate *exchangeRate `json:"exchangeRate,omitempty"`
+
+	// VAT is the tax of the price, which is the net amount, it is not
+	// included for shipments that were created before the tax was stored.
+	VAT *priceVAT `json:"vat,omitempty"`
+}
+
+func (p packagePrice) fromInternal(
+	amount int, code string, breakdown *models.PriceBreakdown, rate *models.ExchangeRate,
+) packagePrice {
+	p.currency = currency{}.fromInternal(amount, code)
+
+	if breakdown != nil {
+		internal := priceBreakdown{}.fromInternal(*breakdown)
+		p.Breakdown = &internal
+	}
+
+	if rate != nil {
+		internal := exchangeRate{}.fromInternal(*rate)
+		p.ExchangeRate = &internal
+	}
+
+	return p
+}
+
+// exchangeRate is how many units of the To currency that a unit of the
+// From currency was worth, the converted price is rounded with the Rounding.
+type exchangeRate struct {
+	From     string  `json:"from" example:"SEK"`
+	To       string  `json:"to" example:"EUR"`
+	Rate     float64 `json:"rate" example:"0.0875"`
+	Rounding string  `json:"rounding" example:"half-up"`
+}
+
+func (r exchangeRate) fromInternal(internal models.ExchangeRate) exchangeRate {
+	r.From = internal.From
+	r.To = internal.To
+	r.Rate = float64(internal.Rate) / price.ExchangeRateScale
+	r.Rounding = internal.Rounding
+
+	return r
+}
+
+// priceVAT is the VAT of the price according to the tax rule that applies to where the
+// package is sent from and to, the country code is the country that the VAT is paid to.
+type priceVAT struct {
+	Rule        string   `json:"rule" example:"intra-eu-b2c"`
+	CountryCode string   `json:"countryCode,omitempty" example:"SE"`
+	Rate        float64  `json:"rate" example:"25"`
+	Net         currency `json:"net"`
+	Tax         currency `json:"tax"`
+	Gross       currency `json:"gross"`
+}
+
+// taxRateAdjustment is what the internal tax
+// rate is multiplied by to avoid floating points.
+const taxRateAdjustment = 10
+
+func (v priceVAT) fromInternal(internal models.Tax, code string) priceVAT {
+	v.Rule = internal.Rule
+	v.CountryCode = internal.CountryCode
+	v.Rate = float64(internal.Rate) / taxRateAdjustment
+	v.Net = currency{}.fromInternal(internal.Net, code)
+	v.Tax = currency{}.fromInternal(internal.Amount, code)
+	v.Gross = currency{}.fromInternal(internal.Gross, code)
+
+	return v
+}
+
+// priceBreakdown is how the price was calculated, the price is the base price
+// of the weight class of the chargeable weight multiplied by the region
+// multiplier plus the adjustments, the amounts are in the minor units of the
+// currency and the weights are in kg. The breakdown of a shipment with several
+// parcels is the sum of its parcels, which only has a weight class when all
+// parcels have the same weight class.
+type priceBreakdown struct {
+	RateCardVersion  string            `json:"rateCardVersion" example:"2021-01-01"`
+	Currency         string            `json:"currency" example:"SEK"`
+	VolumetricWeight float64           `json:"volumetricWeight,omitempty" example:"5.2"`
+	ChargeableWeight float64           `json:"chargeableWeight,omitempty" example:"10.5"`
+	WeightClass      string            `json:"weightClass,omitempty" example:"small"`
+	BasePrice        int               `json:"basePrice" example:"10000"`
+	Region           string            `json:"region" example:"nordic"`
+	RegionMultiplier float64           `json:"regionMultiplier" example:"1"`
+	Adjustments      []priceAdjustment `json:"adjustments"`
+}
+
+// priceAdjustment is a surcharge, or a discount when the amount is negative.
+type priceAdjustment struct {
+	Name   string `json:"name"`
+	Amount int    `json:"amount"`
+}
+
+// regionMultiplierAdjustment is what the internal region
+// multiplier is multiplied by to avoid floating points.
+const regionMultiplierAdjustment = 10
+
+func (b priceBreakdown) fromInternal(internal models.PriceBreakdown) priceBreakdown {
+	b.RateCardVersion = internal.RateCardVersion
+	b.Currency = internal.Currency
+	b.VolumetricWeight = kilograms(internal.VolumetricWeight)
+	b.ChargeableWeight = kilograms(internal.ChargeableWeight)
+	b.WeightClass = internal.WeightClass
+	b.BasePrice = internal.BasePrice
+	b.Region = internal.Region
+	b.RegionMultiplier = float64(internal.RegionMultiplier) / regionMultiplierAdjustment
+	b.Adjustments = make([]priceAdjustment, len(internal.Adjustments))
+
+	for idx, adjustment := range internal.Adjustments {
+		b.Adjustments[idx] = priceAdjustment(adjustment)
+	}
+
+	return b
 }
 
 func (s shipment) fromInternal(internal models.Shipment) shipment {
 	s.ID = internal.ID
 	s.TenantID = internal.TenantID
 	s.CreatedAt = internal.CreatedAt
+	s.Status = string(internal.Status)
+	s.RateCardVersion = internal.RateCardVersion
+	s.Currency = internal.Package.Currency
+
+	if internal.QuoteID != uuid.Nil {
+		quoteID := internal.QuoteID
+		s.QuoteID = &quoteID
+	}
 
 	s.Sender.Name = internal.Sender.Name
 	s.Sender.Email = internal.Sender.Email
 	s.Sender.Address = internal.Sender.Address
 	s.Sender.CountryCode = internal.Sender.CountryCode
+	s.Sender.VATNumber = internal.Sender.VATNumber
 
 	s.Receiver.Name = internal.Receiver.Name
 	s.Receiver.Email = internal.Receiver.Email
 	s.Receiver.Address = internal.Receiver.Address
 	s.Receiver.CountryCode = internal.Receiver.CountryCode
 
-	s.Package.Weight = internal.Package.Weight
-	s.Package.Price.Amount = internal.Package.Price
-	s.Package.Price.DecimalMultiplier = 1
-	s.Package.Price.Currency = "SEK"
+	s.Package = responsePackage{}.fromInternal(internal.Package, internal.PriceBreakdown, internal.ExchangeRate, internal.Tax)
+	s.Parcels = responseParcels(internal.Parcels, internal.ExchangeRate)
+
+	if internal.Cancellation != nil {
+		s.Cancellation = &cancellation{
+			CancelledBy: internal.Cancellation.CancelledBy,
+			CancelledAt: internal.Cancellation.CancelledAt,
+			Reason:      internal.Cancellation.Reason,
+			Fee:         currency{}.fromInternal(internal.Cancellation.Fee, internal.Package.Currency),
+			Refund:      currency{}.fromInternal(internal.Cancellation.Refund, internal.Package.Currency),
+		}
+	}
 
 	return s
 }
 
+type CreateQuoteRequest struct {
+	ShipmentDetails
+
+	// Currency is the ISO 4217 code of the currency that the package is quoted in,
+	// it defaults to the default currency of the tenant.
+	Currency string `json:"currency,omitempty" example:"EUR"`
+}
+
+func (r CreateQuoteRequest) toInternal(tenantID uuid.UUID) models.Quote {
+	internal := r.ShipmentDetails.toInternal(tenantID)
+	internal.Package.Currency = r.Currency
+
+	return models.Quote{
+		TenantID: internal.TenantID,
+		Sender:   internal.Sender,
+		Receiver: internal.Receiver,
+		Package:  internal.Package,
+		Parcels:  internal.Parcels,
+	}
+}
+
+type CreateQuoteResponse struct {
+	Quote quote  `json:"quote"`
+	Links []link `json:"links"`
+}
+
+type quote struct {
+	ShipmentDetails
+	ID        uuid.UUID `json:"id" format:"uuid"`
+	TenantID  uuid.UUID `json:"tenantId" format:"uuid"`
+	CreatedAt time.Time `json:"createdAt" format:"date-time"`
+	ExpiresAt time.Time `json:"expiresAt" format:"date-time"`
+
+	// Package is the total weight and price of the parcels.
+	Package responsePackage  `json:"package"`
+	Parcels []responseParcel `json:"parcels"`
+}
+
+func (r CreateQuoteResponse) fromInternal(internal models.Quote) CreateQuoteResponse {
+	r.Quote.ID = internal.ID
+	r.Quote.TenantID = internal.TenantID
+	r.Quote.CreatedAt = internal.CreatedAt
+	r.Quote.ExpiresAt = internal.ExpiresAt
+	r.Quote.ShipmentDetails = ShipmentDetails{}.fromInternal(internal.Shipment())
+
+	r.Quote.Package = responsePackage{}.fromInternal(internal.Package, internal.PriceBreakdown, internal.ExchangeRate, internal.Tax)
+	r.Quote.Parcels = responseParcels(internal.Parcels, internal.ExchangeRate)
+
+	return r
+}
+
+func (r CreateQuoteResponse) decorateWithLinks(url url.URL) CreateQuoteResponse {
+	url.Path = "/v1/tenants/" + r.Quote.TenantID.String() + "/shipments"
+	r.Links = []link{{Rel: "shipments", Href: url.String()}}
+
+	return r
+}
+
+type CancelShipmentRequest struct {
+	CancelledBy string `json:"cancelledBy" example:"user@example.com"`
+	Reason      string `json:"reason" example:"Ordered twice"`
+}
+
+func (r CancelShipmentRequest) toInternal() models.Cancellation {
+	return models.Cancellation{CancelledBy: r.CancelledBy, Reason: r.Reason}
+}
+
 type listShipmentsResponse struct {
 	Shipments []getShipmentResponse `json:"shipments"`
 	Metadata  struct {
 		Total int `json:"total"`
 	} `json:"metadata"`
 	Links []link `json:"links"`
+
+	prev *storage.Cursor
+	next *storage.Cursor
 }
 
-func (r listShipmentsResponse) fromInternal(shipments models.Shipments) listShipmentsResponse {
-	r.Shipments = make([]getShipmentResponse, len(shipments))
+func (r listShipmentsResponse) fromInternal(page models.ShipmentsPage) listShipmentsResponse {
+	r.Shipments = make([]getShipmentResponse, len(page.Shipments))
 
-	for idx, internal := range shipments {
+	for idx, internal := range page.Shipments {
 		r.Shipments[idx] = getShipmentResponse{}.fromInternal(internal)
 	}
 
+	r.Metadata.Total = page.Total
+	r.prev = page.Prev
+	r.next = page.Next
+
 	return r
 }
 
+// decorateWithLinks will add a self link and the first, prev, next
+// and last links, which are only added when those pages exist.
 func (r listShipmentsResponse) decorateWithLinks(url url.URL, req parsedListShipmentsRequest) listShipmentsResponse {
-	r.Links = make([]link, 2)
-
-	self := url
-	self.Path = "/v1/tenants/" + req.tenantID.String() + "/shipments"
-	selfQuery := self.Query()
-	selfQuery.Add("limit", strconv.Itoa(req.limit))
-	selfQuery.Add("offset", strconv.Itoa(req.offset))
-	self.RawQuery = selfQuery.Encode()
-	r.Links[0] = link{Rel: "self", Href: self.String()}
-
-	next := url
-	next.Path = "/v1/tenants/" + req.tenantID.String() + "/shipments"
-	nextQuery := next.Query()
-	nextQuery.Add("limit", strconv.Itoa(req.limit))
-	nextQuery.Add("offset", strconv.Itoa(req.offset+len(r.Shipments)))
-	next.RawQuery = nextQuery.Encode()
-	r.Links[1] = link{Rel: "next", Href: next.String()}
+	pageLink := func(rel string, cursor *storage.Cursor, offset int) link {
+		page := url
+		page.Path = req.path
+		pageQuery := page.Query()
+
+		for key := range req.linkQuery {
+			pageQuery.Set(key, req.linkQuery.Get(key))
+		}
+
+		pageQuery.Add("limit", strconv.Itoa(req.limit))
+
+		if req.sort != (storage.Sort{}) {
+			pageQuery.Add("sort", formatSortParam(req.sort))
+		}
+
+		if cursor != nil {
+			pageQuery.Add("cursor", cursor.String())
+		} else if offset > 0 {
+			pageQuery.Add("offset", strconv.Itoa(offset))
+		}
+
+		page.RawQuery = pageQuery.Encode()
+
+		return link{Rel: rel, Href: page.String()}
+	}
+
+	r.Links = []link{pageLink("self", req.cursor, req.offset)}
+
+	if r.prev != nil {
+		r.Links = append(r.Links, pageLink("first", nil, 0), pageLink("prev", r.prev, 0))
+	}
+
+	if r.next != nil {
+		last := storage.LastPageCursor(req.sort)
+		r.Links = append(r.Links, pageLink("next", r.next, 0), pageLink("last", &last, 0))
+	}
 
 	for idx := range r.Shipments {
 		r.Shipments[idx] = r.Shipments[idx].decorateWithLinks(url)
@@ -154,23 +653,215 @@ func (r listShipmentsResponse) decorateWithLinks(url url.URL, req parsedListShip
 
 type getShipmentResponse struct {
 	Shipment shipment `json:"shipment"`
-	Links    []link   `json:"links"`
+
+	// LatestTrackingEvent is only included when a single shipment is requested.
+	LatestTrackingEvent *trackingEvent `json:"latestTrackingEvent,omitempty"`
+
+	Links []link `json:"links"`
 }
 
 func (s getShipmentResponse) fromInternal(internal models.Shipment) (out getShipmentResponse) {
 	out.Shipment = shipment{}.fromInternal(internal)
+
+	if internal.LatestTrackingEvent != nil {
+		latest := trackingEvent{}.fromInternal(*internal.LatestTrackingEvent)
+		out.LatestTrackingEvent = &latest
+	}
+
 	return
 }
 
 func (s getShipmentResponse) decorateWithLinks(url url.URL) getShipmentResponse {
-	s.Links = make([]link, 1)
+	s.Links = make([]link, 2)
 
 	url.Path = "/v1/tenants/" + s.Shipment.TenantID.String() + "/shipments/" + s.Shipment.ID.String()
 	s.Links[0] = link{Rel: "self", Href: url.String()}
 
+	url.Path += "/tracking-events"
+	s.Links[1] = link{Rel: "trackingEvents", Href: url.String()}
+
 	return s
 }
 
+type AppendTrackingEventRequest struct {
+	// OccurredAt defaults to when the tracking event is appended.
+	OccurredAt  *time.Time `json:"occurredAt,omitempty" format:"date-time"`
+	Location    string     `json:"location" example:"Stockholm, SE"`
+	Description string     `json:"description" example:"Arrived at the sorting facility"`
+	Source      string     `json:"source" example:"carrier"`
+}
+
+func (r AppendTrackingEventRequest) toInternal(tenantID, shipmentID uuid.UUID) models.TrackingEvent {
+	var internal models.TrackingEvent
+
+	internal.TenantID = tenantID
+	internal.ShipmentID = shipmentID
+
+	if r.OccurredAt != nil {
+		internal.OccurredAt = *r.OccurredAt
+	}
+
+	internal.Location = r.Location
+	internal.Description = r.Description
+	internal.Source = r.Source
+
+	return internal
+}
+
+type trackingEvent struct {
+	ID          uuid.UUID `json:"id" format:"uuid"`
+	OccurredAt  time.Time `json:"occurredAt" format:"date-time"`
+	Location    string    `json:"location" example:"Stockholm, SE"`
+	Description string    `json:"description" example:"Arrived at the sorting facility"`
+	Source      string    `json:"source" example:"carrier"`
+}
+
+func (e trackingEvent) fromInternal(internal models.TrackingEvent) trackingEvent {
+	e.ID = internal.ID
+	e.OccurredAt = internal.OccurredAt
+	e.Location = internal.Location
+	e.Description = internal.Description
+	e.Source = internal.Source
+
+	return e
+}
+
+type AppendTrackingEventResponse struct {
+	TrackingEvent trackingEvent `json:"trackingEvent"`
+	Links         []link        `json:"links"`
+}
+
+type listTrackingEventsResponse struct {
+	TrackingEvents []trackingEvent `json:"trackingEvents"`
+	Links          []link          `json:"links"`
+}
+
+func (r listTrackingEventsResponse) fromInternal(internal models.TrackingEvents) listTrackingEventsResponse {
+	r.TrackingEvents = make([]trackingEvent, len(internal))
+
+	for idx := range internal {
+		r.TrackingEvents[idx] = trackingEvent{}.fromInternal(internal[idx])
+	}
+
+	return r
+}
+
+// trackingEventsLinks will return a link with the rel to the
+// tracking events of the shipment and a link to the shipment.
+func trackingEventsLinks(url url.URL, rel string, tenantID, shipmentID uuid.UUID) []link {
+	url.Path = "/v1/tenants/" + tenantID.String() + "/shipments/" + shipmentID.String()
+	shipmentLink := link{Rel: "shipment", Href: url.String()}
+
+	url.Path += "/tracking-events"
+
+	return []link{{Rel: rel, Href: url.String()}, shipmentLink}
+}
+
+type rateCard struct {
+	Version       string    `json:"version" example:"contract-1"`
+	EffectiveFrom time.Time `json:"effectiveFrom" format:"date-time"`
+	UploadedAt    time.Time `json:"uploadedAt" format:"date-time"`
+	Document      string    `json:"document" example:"version: contract-1"`
+}
+
+func (rc rateCard) fromInternal(internal models.RateCard) rateCard {
+	rc.Version = internal.Version
+	rc.EffectiveFrom = internal.EffectiveFrom
+	rc.UploadedAt = internal.UploadedAt
+	rc.Document = string(internal.Document)
+
+	return rc
+}
+
+type uploadRateCardResponse struct {
+	RateCard rateCard `json:"rateCard"`
+	Links    []link   `json:"links"`
+}
+
+type listRateCardsResponse struct {
+	RateCards []rateCard `json:"rateCards"`
+	// ActiveVersion is the version of the rate card that new Shipments of the Tenant are
+	// priced with, it is not included when the default rate cards are used.
+	ActiveVersion string `json:"activeVersion,omitempty" example:"contract-1"`
+	Links         []link `json:"links"`
+}
+
+func (r listRateCardsResponse) fromInternal(internal models.RateCards, now time.Time) listRateCardsResponse {
+	r.RateCards = make([]rateCard, len(internal))
+
+	for idx := range internal {
+		r.RateCards[idx] = rateCard{}.fromInternal(internal[idx])
+	}
+
+	if active, ok := internal.Effective(now); ok {
+		r.ActiveVersion = active.Version
+	}
+
+	return r
+}
+
+type previewRateCardResponse struct {
+	Version       string         `json:"version" example:"contract-1"`
+	EffectiveFrom time.Time      `json:"effectiveFrom" format:"date-time"`
+	Prices        []pricePreview `json:"prices"`
+	// Differences are the sums of the differences of the Shipments that the rate card
+	// can price by the currency that they are priced in, in the minor units of the currency.
+	Differences map[string]int `json:"differences" example:"SEK:-5000"`
+	Links       []link         `json:"links"`
+}
+
+// pricePreview is the price that a Shipment would have with the
+// previewed rate card, Error is set if the rate card can't price it.
+type pricePreview struct {
+	ShipmentID      uuid.UUID     `json:"shipmentId" format:"uuid"`
+	CreatedAt       time.Time     `json:"createdAt" format:"date-time"`
+	RateCardVersion string        `json:"rateCardVersion,omitempty" example:"2021-01-01"`
+	Price           currency      `json:"price"`
+	PreviewPrice    *packagePrice `json:"previewPrice,omitempty"`
+	Difference      int           `json:"difference"`
+	Error           string        `json:"error,omitempty"`
+}
+
+func (r previewRateCardResponse) fromInternal(internal models.RateCardPreview) previewRateCardResponse {
+	r.Version = internal.Version
+	r.EffectiveFrom = internal.EffectiveFrom
+	r.Prices = make([]pricePreview, len(internal.Prices))
+	r.Differences = map[string]int{}
+
+	for idx, internalPrice := range internal.Prices {
+		shipment := internalPrice.Shipment
+
+		preview := pricePreview{
+			ShipmentID:      shipment.ID,
+			CreatedAt:       shipment.CreatedAt,
+			RateCardVersion: shipment.RateCardVersion,
+			Price:           currency{}.fromInternal(shipment.Package.Price, shipment.Package.Currency),
+		}
+
+		if internalPrice.Err != nil {
+			preview.Error = internalPrice.Err.Error()
+		} else {
+			previewPrice := packagePrice{}.fromInternal(
+				internalPrice.Price, shipment.Package.Currency, internalPrice.PriceBreakdown, internalPrice.ExchangeRate,
+			)
+			preview.PreviewPrice = &previewPrice
+			preview.Difference = internalPrice.Difference()
+			r.Differences[shipment.Package.Currency] += preview.Difference
+		}
+
+		r.Prices[idx] = preview
+	}
+
+	return r
+}
+
+// rateCardsLinks will return a link to the rate cards of the tenant.
+func rateCardsLinks(url url.URL, tenantID uuid.UUID) []link {
+	url.Path = "/v1/admin/tenants/" + tenantID.String() + "/rate-cards"
+
+	return []link{{Rel: "rateCards", Href: url.String()}}
+}
+
 type link struct {
 	Rel  string `json:"rel"`
 	Href string `json:"href"`
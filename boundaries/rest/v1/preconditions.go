@@ -0,0 +1,27 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/lonnblad/shipment-service-backend/boundaries/rest/utils"
+	"github.com/lonnblad/shipment-service-backend/businesslogic/models"
+)
+
+// parsePrecondition will return the precondition of the If-Match header of
+// the request, which matches the ETag of the version of a shipment.
+// It returns nil when there is no If-Match header.
+func parsePrecondition(req *http.Request) models.Precondition {
+	header := req.Header.Get(utils.HeaderIfMatch)
+	if header == "" {
+		return nil
+	}
+
+	return func(version int) bool {
+		return utils.MatchesIfMatch(header, utils.ETag(version))
+	}
+}
+
+// writeETag will set the ETag header to the version of the shipment.
+func writeETag(w http.ResponseWriter, internal models.Shipment) {
+	w.Header().Set(utils.HeaderETag, utils.ETag(internal.Version))
+}
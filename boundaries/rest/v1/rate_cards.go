@@ -0,0 +1,224 @@
+package v1
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/lonnblad/shipment-service-backend/boundaries/rest/utils"
+	"github.com/lonnblad/shipment-service-backend/trace"
+)
+
+const (
+	// maxRateCardSize is the max size of an uploaded rate card in bytes.
+	maxRateCardSize = 1 << 20
+
+	defaultLimitPreviewRateCard = 20
+	maxLimitPreviewRateCard     = 100
+)
+
+// @Summary Upload Rate Card
+// @Description Upload a YAML or JSON rate card of a Tenant, which overrides the default rate cards
+// @Description for the Shipments of the Tenant that are created after its effectiveFrom date. The weight bands
+// @Description and regions of the default rate card are used when the rate card doesn't have any, which makes
+// @Description it possible to upload a rate card that only has a discountPercent. A rate card can't be effective
+// @Description from before it is uploaded, as that would change the price rules of Shipments that are already created.
+// @Accept application/yaml,json
+// @Produce json
+// @Param tenant_id path string true "Tenant ID"
+// @Param body body string true "YAML or JSON Rate Card"
+// @Success 201 {object} uploadRateCardResponse
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 401 {object} utils.ErrorResponse "The admin token is missing or invalid"
+// @Failure 403 {object} utils.ErrorResponse "The admin endpoints are disabled"
+// @Failure 409 {object} utils.ErrorResponse "The Tenant already has a Rate Card with the version"
+// @Failure 422 {object} utils.ErrorResponse
+// @Failure 503 {object} utils.ErrorResponse
+// @Security AdminToken
+// @Router /v1/admin/tenants/{tenant_id}/rate-cards [post]
+func (api *API) withUploadRateCardHandler() *API {
+	api.router.
+		Path(pathRateCards).
+		Methods(http.MethodPost).
+		Handler(api.adminGuard.Middleware(http.HandlerFunc(api.uploadRateCardHandler)))
+
+	return api
+}
+
+func (api *API) uploadRateCardHandler(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+
+	ctx, span := trace.Tracer().Start(ctx, "v1.uploadRateCardHandler")
+	defer span.End()
+
+	reqData, err := parsedUploadRateCardRequest{}.parse(w, req)
+	if err != nil {
+		utils.WrapErrorAndWriteJSONResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	span.SetAttributes(
+		attribute.String("req.path.tenant_id", reqData.tenantID.String()),
+	)
+
+	internalRateCard, err := api.logic.UploadRateCard(ctx, reqData.tenantID, reqData.document)
+	if err != nil {
+		writeErrorResponse(w, err)
+		return
+	}
+
+	output := uploadRateCardResponse{
+		RateCard: rateCard{}.fromInternal(internalRateCard),
+		Links:    rateCardsLinks(api.publicURL, reqData.tenantID),
+	}
+
+	utils.MarshalAndWriteJSONResponse(w, http.StatusCreated, output)
+}
+
+type parsedUploadRateCardRequest struct {
+	tenantID uuid.UUID
+	document []byte
+}
+
+func (parsedUploadRateCardRequest) parse(w http.ResponseWriter, req *http.Request) (_ parsedUploadRateCardRequest, err error) {
+	var out parsedUploadRateCardRequest
+
+	params := mux.Vars(req)
+
+	if out.tenantID, err = uuid.Parse(params[keyTenantID]); err != nil {
+		err = fmt.Errorf("could not parse tenant ID: %s, error: %w", params[keyTenantID], err)
+		return
+	}
+
+	body := http.MaxBytesReader(w, req.Body, maxRateCardSize)
+	defer body.Close()
+
+	if out.document, err = io.ReadAll(body); err != nil {
+		err = fmt.Errorf("could not read request body: %w", err)
+		return
+	}
+
+	return out, nil
+}
+
+// @Summary List Rate Cards
+// @Description List the rate cards of a Tenant, ordered by when they were uploaded. A Shipment
+// @Description is priced with the rate card that was effective when the Shipment was created,
+// @Description new Shipments are priced with the active rate card.
+// @Produce json
+// @Param tenant_id path string true "Tenant ID"
+// @Success 200 {object} listRateCardsResponse
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 401 {object} utils.ErrorResponse "The admin token is missing or invalid"
+// @Failure 403 {object} utils.ErrorResponse "The admin endpoints are disabled"
+// @Failure 503 {object} utils.ErrorResponse
+// @Security AdminToken
+// @Router /v1/admin/tenants/{tenant_id}/rate-cards [get]
+func (api *API) withListRateCardsHandler() *API {
+	api.router.
+		Path(pathRateCards).
+		Methods(http.MethodGet).
+		Handler(api.adminGuard.Middleware(http.HandlerFunc(api.listRateCardsHandler)))
+
+	return api
+}
+
+func (api *API) listRateCardsHandler(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+
+	ctx, span := trace.Tracer().Start(ctx, "v1.listRateCardsHandler")
+	defer span.End()
+
+	params := mux.Vars(req)
+
+	tenantID, err := uuid.Parse(params[keyTenantID])
+	if err != nil {
+		err = fmt.Errorf("could not parse tenant ID: %s, error: %w", params[keyTenantID], err)
+		utils.WrapErrorAndWriteJSONResponse(w, http.StatusBadRequest, err)
+
+		return
+	}
+
+	span.SetAttributes(
+		attribute.String("req.path.tenant_id", tenantID.String()),
+	)
+
+	internalRateCards, err := api.logic.ListRateCards(ctx, tenantID)
+	if err != nil {
+		writeErrorResponse(w, err)
+		return
+	}
+
+	output := listRateCardsResponse{}.fromInternal(internalRateCards, time.Now())
+	output.Links = rateCardsLinks(api.publicURL, tenantID)
+
+	utils.MarshalAndWriteJSONResponse(w, http.StatusOK, output)
+}
+
+// @Summary Preview Rate Card
+// @Description Preview how the prices of the latest Shipments of a Tenant would change if they were priced
+// @Description with a YAML or JSON rate card, regardless of when they were created. The rate card is validated
+// @Description in the same way as when it is uploaded, but it isn't stored.
+// @Accept application/yaml,json
+// @Produce json
+// @Param tenant_id path string true "Tenant ID"
+// @Param limit query int false "The number of Shipments to preview" minimum(1) maximum(100) default(20)
+// @Param body body string true "YAML or JSON Rate Card"
+// @Success 200 {object} previewRateCardResponse
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 401 {object} utils.ErrorResponse "The admin token is missing or invalid"
+// @Failure 403 {object} utils.ErrorResponse "The admin endpoints are disabled"
+// @Failure 422 {object} utils.ErrorResponse
+// @Failure 503 {object} utils.ErrorResponse
+// @Security AdminToken
+// @Router /v1/admin/tenants/{tenant_id}/rate-cards/preview [post]
+func (api *API) withPreviewRateCardHandler() *API {
+	api.router.
+		Path(pathRateCardPreview).
+		Methods(http.MethodPost).
+		Handler(api.adminGuard.Middleware(http.HandlerFunc(api.previewRateCardHandler)))
+
+	return api
+}
+
+func (api *API) previewRateCardHandler(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+
+	ctx, span := trace.Tracer().Start(ctx, "v1.previewRateCardHandler")
+	defer span.End()
+
+	reqData, err := parsedUploadRateCardRequest{}.parse(w, req)
+	if err != nil {
+		utils.WrapErrorAndWriteJSONResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	limit, err := utils.ParseLimit(req.URL.Query().Get("limit"), defaultLimitPreviewRateCard, maxLimitPreviewRateCard)
+	if err != nil {
+		err = fmt.Errorf("could not parse limit: %w", err)
+		utils.WrapErrorAndWriteJSONResponse(w, http.StatusBadRequest, err)
+
+		return
+	}
+
+	span.SetAttributes(
+		attribute.String("req.path.tenant_id", reqData.tenantID.String()),
+		attribute.Int("req.query.limit", limit),
+	)
+
+	internalPreview, err := api.logic.PreviewRateCard(ctx, reqData.tenantID, reqData.document, limit)
+	if err != nil {
+		writeErrorResponse(w, err)
+		return
+	}
+
+	output := previewRateCardResponse{}.fromInternal(internalPreview)
+	output.Links = rateCardsLinks(api.publicURL, reqData.tenantID)
+
+	utils.MarshalAndWriteJSONResponse(w, http.StatusOK, output)
+}
@@ -0,0 +1,137 @@
+package v1
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/lonnblad/shipment-service-backend/boundaries/rest/utils"
+	"github.com/lonnblad/shipment-service-backend/businesslogic/models"
+	"github.com/lonnblad/shipment-service-backend/storage"
+	"github.com/lonnblad/shipment-service-backend/storage/search"
+	"github.com/lonnblad/shipment-service-backend/trace"
+)
+
+const keySearchText = "q"
+
+var searchShipmentsKeys = map[string]bool{keySearchText: true, "limit": true, "cursor": true}
+
+// @Summary Search Shipments
+// @Description Search Shipments by the name, email and address of the sender and the receiver,
+// @Description every word of the query has to be the start of a word in one of those fields, ignoring case.
+// @Produce json
+// @Param tenant_id path string true "Tenant ID"
+// @Param q query string true "Search query"
+// @Param limit query int false "Limit" minimum(1) maximum(100) default(10)
+// @Param cursor query string false "Cursor, taken from the first, prev, next or last link of another page"
+// @Success 200 {object} listShipmentsResponse
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 503 {object} utils.ErrorResponse
+// @Router /v1/tenants/{tenant_id}/shipments/search [get]
+func (api *API) withSearchShipmentsHandler() *API {
+	api.router.
+		Path(pathSearchShipments).
+		Methods(http.MethodGet).
+		HandlerFunc(api.searchShipmentsHandler)
+
+	return api
+}
+
+func (api *API) searchShipmentsHandler(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+
+	ctx, span := trace.Tracer().Start(ctx, "v1.searchShipmentsHandler")
+	defer span.End()
+
+	reqData, err := parsedSearchShipmentsRequest{}.parse(req)
+	if err != nil {
+		utils.WrapErrorAndWriteJSONResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	span.SetAttributes(
+		attribute.String("req.path.tenant_id", reqData.tenantID.String()),
+		attribute.Int("req.query.limit", reqData.limit),
+		attribute.Bool("req.query.cursor", reqData.cursor != nil),
+	)
+
+	query := models.SearchShipmentsQuery{
+		Text:   reqData.text,
+		Limit:  reqData.limit,
+		Cursor: reqData.cursor,
+	}
+
+	internalPage, err := api.logic.SearchShipments(ctx, reqData.tenantID, query)
+	if err != nil {
+		writeErrorResponse(w, err)
+		return
+	}
+
+	// The links of a search are built like the links of a listing
+	// sorted by creation time, with the search query kept in them.
+	linkReq := parsedListShipmentsRequest{
+		tenantID:  reqData.tenantID,
+		limit:     reqData.limit,
+		cursor:    reqData.cursor,
+		path:      "/v1/tenants/" + reqData.tenantID.String() + "/shipments/search",
+		linkQuery: url.Values{keySearchText: {reqData.text}},
+	}
+
+	output := listShipmentsResponse{}.fromInternal(internalPage)
+	output = output.decorateWithLinks(api.publicURL, linkReq)
+
+	utils.MarshalAndWriteJSONResponse(w, http.StatusOK, output)
+}
+
+type parsedSearchShipmentsRequest struct {
+	tenantID uuid.UUID
+	text     string
+	limit    int
+	cursor   *storage.Cursor
+}
+
+func (parsedSearchShipmentsRequest) parse(req *http.Request) (_ parsedSearchShipmentsRequest, err error) {
+	var out parsedSearchShipmentsRequest
+
+	params := mux.Vars(req)
+
+	if out.tenantID, err = uuid.Parse(params[keyTenantID]); err != nil {
+		err = fmt.Errorf("could not parse tenant ID: %s, error: %w", params[keyTenantID], err)
+		return
+	}
+
+	query := req.URL.Query()
+
+	for key := range query {
+		if !searchShipmentsKeys[key] {
+			err = fmt.Errorf("unknown query param: %s", key)
+			return
+		}
+	}
+
+	out.text = query.Get(keySearchText)
+	if len(search.Tokenize(out.text)) == 0 {
+		err = fmt.Errorf("the search query must contain at least one letter or digit")
+		return
+	}
+
+	const (
+		defaultLimit = defaultLimitListShipments
+		maxLimit     = maxLimitListShipments
+	)
+
+	if out.limit, err = utils.ParseLimit(query.Get("limit"), defaultLimit, maxLimit); err != nil {
+		err = fmt.Errorf("could not parse limit: %w", err)
+		return
+	}
+
+	if out.cursor, err = parseCursorParam(query); err != nil {
+		return
+	}
+
+	return out, nil
+}
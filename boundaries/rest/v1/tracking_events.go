@@ -0,0 +1,159 @@
+package v1
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/lonnblad/shipment-service-backend/boundaries/rest/utils"
+	"github.com/lonnblad/shipment-service-backend/trace"
+)
+
+// @Summary Append Tracking Event
+// @Description Append an event to the tracking timeline of a Shipment
+// @Accept json
+// @Produce json
+// @Param tenant_id path string true "Tenant ID"
+// @Param shipment_id path string true "Shipment ID"
+// @Param body body AppendTrackingEventRequest true "Tracking Event Data"
+// @Success 201 {object} AppendTrackingEventResponse
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 422 {object} utils.ErrorResponse
+// @Failure 503 {object} utils.ErrorResponse
+// @Router /v1/tenants/{tenant_id}/shipments/{shipment_id}/tracking-events [post]
+func (api *API) withAppendTrackingEventHandler() *API {
+	api.router.
+		Path(pathTrackingEvents).
+		Methods(http.MethodPost).
+		HandlerFunc(api.appendTrackingEventHandler)
+
+	return api
+}
+
+func (api *API) appendTrackingEventHandler(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+
+	ctx, span := trace.Tracer().Start(ctx, "v1.appendTrackingEventHandler")
+	defer span.End()
+
+	reqData, err := parsedAppendTrackingEventRequest{}.parse(req)
+	if err != nil {
+		utils.WrapErrorAndWriteJSONResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	span.SetAttributes(
+		attribute.String("req.path.tenant_id", reqData.tenantID.String()),
+		attribute.String("req.path.shipment_id", reqData.shipmentID.String()),
+	)
+
+	internalEvent := reqData.body.toInternal(reqData.tenantID, reqData.shipmentID)
+
+	internalEvent, err = api.logic.AppendTrackingEvent(ctx, internalEvent)
+	if err != nil {
+		writeErrorResponse(w, err)
+		return
+	}
+
+	output := AppendTrackingEventResponse{
+		TrackingEvent: trackingEvent{}.fromInternal(internalEvent),
+		Links:         trackingEventsLinks(api.publicURL, "trackingEvents", reqData.tenantID, reqData.shipmentID),
+	}
+
+	utils.MarshalAndWriteJSONResponse(w, http.StatusCreated, output)
+}
+
+// @Summary List Tracking Events
+// @Description List the tracking timeline of a Shipment, ordered by when the events occurred
+// @Produce json
+// @Param tenant_id path string true "Tenant ID"
+// @Param shipment_id path string true "Shipment ID"
+// @Success 200 {object} listTrackingEventsResponse
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 503 {object} utils.ErrorResponse
+// @Router /v1/tenants/{tenant_id}/shipments/{shipment_id}/tracking-events [get]
+func (api *API) withListTrackingEventsHandler() *API {
+	api.router.
+		Path(pathTrackingEvents).
+		Methods(http.MethodGet).
+		HandlerFunc(api.listTrackingEventsHandler)
+
+	return api
+}
+
+func (api *API) listTrackingEventsHandler(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+
+	ctx, span := trace.Tracer().Start(ctx, "v1.listTrackingEventsHandler")
+	defer span.End()
+
+	reqData, err := parsedTrackingEventsRequest{}.parse(req)
+	if err != nil {
+		utils.WrapErrorAndWriteJSONResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	span.SetAttributes(
+		attribute.String("req.path.tenant_id", reqData.tenantID.String()),
+		attribute.String("req.path.shipment_id", reqData.shipmentID.String()),
+	)
+
+	internalEvents, err := api.logic.ListTrackingEvents(ctx, reqData.tenantID, reqData.shipmentID)
+	if err != nil {
+		writeErrorResponse(w, err)
+		return
+	}
+
+	output := listTrackingEventsResponse{}.fromInternal(internalEvents)
+	output.Links = trackingEventsLinks(api.publicURL, "self", reqData.tenantID, reqData.shipmentID)
+
+	utils.MarshalAndWriteJSONResponse(w, http.StatusOK, output)
+}
+
+type parsedTrackingEventsRequest struct {
+	tenantID   uuid.UUID
+	shipmentID uuid.UUID
+}
+
+func (parsedTrackingEventsRequest) parse(req *http.Request) (_ parsedTrackingEventsRequest, err error) {
+	var out parsedTrackingEventsRequest
+
+	params := mux.Vars(req)
+
+	if out.tenantID, err = uuid.Parse(params[keyTenantID]); err != nil {
+		err = fmt.Errorf("could not parse tenant ID: %s, error: %w", params[keyTenantID], err)
+		return
+	}
+
+	if out.shipmentID, err = uuid.Parse(params[keyShipmentID]); err != nil {
+		err = fmt.Errorf("could not parse shipment ID: %s, error: %w", params[keyShipmentID], err)
+		return
+	}
+
+	return out, nil
+}
+
+type parsedAppendTrackingEventRequest struct {
+	parsedTrackingEventsRequest
+	body AppendTrackingEventRequest
+}
+
+func (parsedAppendTrackingEventRequest) parse(req *http.Request) (_ parsedAppendTrackingEventRequest, err error) {
+	var out parsedAppendTrackingEventRequest
+
+	if out.parsedTrackingEventsRequest, err = (parsedTrackingEventsRequest{}).parse(req); err != nil {
+		return
+	}
+
+	if err = utils.UnmarshalRequest(req.Body, &out.body); err != nil {
+		err = fmt.Errorf("could not parse request body: %w", err)
+		return
+	}
+
+	return out, nil
+}
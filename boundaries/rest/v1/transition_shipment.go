@@ -0,0 +1,112 @@
+package v1
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/lonnblad/shipment-service-backend/boundaries/rest/utils"
+	"github.com/lonnblad/shipment-service-backend/businesslogic/models"
+	"github.com/lonnblad/shipment-service-backend/trace"
+)
+
+type TransitionShipmentRequest struct {
+	Status string `json:"status" example:"booked" enums:"booked,picked_up,in_transit,delivered,returned"`
+}
+
+// @Summary Transition Shipment
+// @Description Transition a Shipment to another status of its lifecycle, the allowed transitions are:
+// @Description created to booked, booked to picked_up,
+// @Description picked_up to in_transit or returned and in_transit to delivered or returned.
+// @Description A Shipment is cancelled with the cancel endpoint, which also refunds the price minus a fee.
+// @Accept json
+// @Produce json
+// @Param tenant_id path string true "Tenant ID"
+// @Param shipment_id path string true "Shipment ID"
+// @Param If-Match header string false "ETag of the version of the Shipment that the request is based on"
+// @Param body body TransitionShipmentRequest true "Transition Data"
+// @Success 200 {object} getShipmentResponse
+// @Header 200 {string} ETag "The version of the Shipment"
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 409 {object} utils.ErrorResponse
+// @Failure 412 {object} utils.ErrorResponse
+// @Failure 422 {object} utils.ErrorResponse
+// @Failure 503 {object} utils.ErrorResponse
+// @Router /v1/tenants/{tenant_id}/shipments/{shipment_id}/transitions [post]
+func (api *API) withTransitionShipmentHandler() *API {
+	api.router.
+		Path(pathTransitions).
+		Methods(http.MethodPost).
+		HandlerFunc(api.transitionShipmentHandler)
+
+	return api
+}
+
+func (api *API) transitionShipmentHandler(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+
+	ctx, span := trace.Tracer().Start(ctx, "v1.transitionShipmentHandler")
+	defer span.End()
+
+	reqData, err := parsedTransitionShipmentRequest{}.parse(req)
+	if err != nil {
+		utils.WrapErrorAndWriteJSONResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	span.SetAttributes(
+		attribute.String("req.path.tenant_id", reqData.tenantID.String()),
+		attribute.String("req.path.shipment_id", reqData.shipmentID.String()),
+		attribute.String("req.body.status", reqData.body.Status),
+	)
+
+	status := models.Status(reqData.body.Status)
+
+	internalShipment, err := api.logic.TransitionShipment(ctx, reqData.tenantID, reqData.shipmentID, reqData.precondition, status)
+	if err != nil {
+		writeErrorResponse(w, err)
+		return
+	}
+
+	output := getShipmentResponse{}.fromInternal(internalShipment)
+	output = output.decorateWithLinks(api.publicURL)
+
+	writeETag(w, internalShipment)
+	utils.MarshalAndWriteJSONResponse(w, http.StatusOK, output)
+}
+
+type parsedTransitionShipmentRequest struct {
+	tenantID     uuid.UUID
+	shipmentID   uuid.UUID
+	precondition models.Precondition
+	body         TransitionShipmentRequest
+}
+
+func (parsedTransitionShipmentRequest) parse(req *http.Request) (_ parsedTransitionShipmentRequest, err error) {
+	var out parsedTransitionShipmentRequest
+
+	params := mux.Vars(req)
+
+	if out.tenantID, err = uuid.Parse(params[keyTenantID]); err != nil {
+		err = fmt.Errorf("could not parse tenant ID: %s, error: %w", params[keyTenantID], err)
+		return
+	}
+
+	if out.shipmentID, err = uuid.Parse(params[keyShipmentID]); err != nil {
+		err = fmt.Errorf("could not parse shipment ID: %s, error: %w", params[keyShipmentID], err)
+		return
+	}
+
+	out.precondition = parsePrecondition(req)
+
+	if err = utils.UnmarshalRequest(req.Body, &out.body); err != nil {
+		err = fmt.Errorf("could not parse request body: %w", err)
+		return
+	}
+
+	return out, nil
+}
@@ -0,0 +1,161 @@
+package v1
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/lonnblad/shipment-service-backend/boundaries/rest/utils"
+	"github.com/lonnblad/shipment-service-backend/businesslogic/models"
+	"github.com/lonnblad/shipment-service-backend/trace"
+)
+
+// @Summary Update Shipment
+// @Description Update the sender, receiver or package of a Shipment with a JSON Merge Patch (RFC 7396),
+// @Description where a member with the value null is removed and objects are merged.
+// @Description The price is calculated again when a parcel is added, removed or has its weight or dimensions changed,
+// @Description or when the country of the sender is changed, which also removes the quote of the Shipment.
+// @Description A Shipment with several parcels has parcels instead of a package, where a parcel without an id is added,
+// @Description the package or parcels are replaced by setting the other one to null.
+// @Description A Shipment can only be updated before it has been picked up.
+// @Accept application/merge-patch+json
+// @Produce json
+// @Param tenant_id path string true "Tenant ID"
+// @Param shipment_id path string true "Shipment ID"
+// @Param If-Match header string false "ETag of the version of the Shipment that the request is based on"
+// @Param body body ShipmentDetails true "Merge Patch of the Shipment Data"
+// @Success 200 {object} getShipmentResponse
+// @Header 200 {string} ETag "The version of the Shipment"
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 409 {object} utils.ErrorResponse
+// @Failure 412 {object} utils.ErrorResponse
+// @Failure 422 {object} utils.ErrorResponse
+// @Failure 503 {object} utils.ErrorResponse
+// @Router /v1/tenants/{tenant_id}/shipments/{shipment_id} [patch]
+func (api *API) withUpdateShipmentHandler() *API {
+	api.router.
+		Path(pathShipment).
+		Methods(http.MethodPatch).
+		HandlerFunc(api.updateShipmentHandler)
+
+	return api
+}
+
+func (api *API) updateShipmentHandler(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+
+	ctx, span := trace.Tracer().Start(ctx, "v1.updateShipmentHandler")
+	defer span.End()
+
+	reqData, err := parsedUpdateShipmentRequest{}.parse(req)
+	if err != nil {
+		utils.WrapErrorAndWriteJSONResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	span.SetAttributes(
+		attribute.String("req.path.tenant_id", reqData.tenantID.String()),
+		attribute.String("req.path.shipment_id", reqData.shipmentID.String()),
+	)
+
+	internalShipment, err := api.logic.UpdateShipment(ctx, reqData.tenantID, reqData.shipmentID, reqData.precondition, reqData.patch)
+	if err != nil {
+		writeErrorResponse(w, err)
+		return
+	}
+
+	output := getShipmentResponse{}.fromInternal(internalShipment)
+	output = output.decorateWithLinks(api.publicURL)
+
+	writeETag(w, internalShipment)
+	utils.MarshalAndWriteJSONResponse(w, http.StatusOK, output)
+}
+
+type parsedUpdateShipmentRequest struct {
+	tenantID     uuid.UUID
+	shipmentID   uuid.UUID
+	precondition models.Precondition
+	patch        shipmentMergePatch
+}
+
+func (parsedUpdateShipmentRequest) parse(req *http.Request) (_ parsedUpdateShipmentRequest, err error) {
+	var out parsedUpdateShipmentRequest
+
+	params := mux.Vars(req)
+
+	if out.tenantID, err = uuid.Parse(params[keyTenantID]); err != nil {
+		err = fmt.Errorf("could not parse tenant ID: %s, error: %w", params[keyTenantID], err)
+		return
+	}
+
+	if out.shipmentID, err = uuid.Parse(params[keyShipmentID]); err != nil {
+		err = fmt.Errorf("could not parse shipment ID: %s, error: %w", params[keyShipmentID], err)
+		return
+	}
+
+	out.precondition = parsePrecondition(req)
+
+	defer req.Body.Close()
+
+	if out.patch, err = io.ReadAll(req.Body); err != nil {
+		err = fmt.Errorf("could not read request body: %w", err)
+		return
+	}
+
+	if !json.Valid(out.patch) {
+		err = fmt.Errorf("could not parse request body: merge patch is not valid JSON")
+		return
+	}
+
+	return out, nil
+}
+
+// shipmentMergePatch is a JSON Merge Patch of the ShipmentDetails
+// of a shipment, it is applied to the shipment as it is stored.
+type shipmentMergePatch []byte
+
+// Apply will return a ValidationError if the patched
+// document isn't a valid ShipmentDetails.
+func (p shipmentMergePatch) Apply(internal models.Shipment) (_ models.Shipment, err error) {
+	document, err := json.Marshal(ShipmentDetails{}.fromInternal(internal))
+	if err != nil {
+		err = fmt.Errorf("failed to marshal shipment: %w", err)
+		return
+	}
+
+	if document, err = utils.MergePatch(document, p); err != nil {
+		err = models.ValidationError{Err: err}
+		return
+	}
+
+	var patched ShipmentDetails
+
+	decoder := json.NewDecoder(bytes.NewReader(document))
+	decoder.DisallowUnknownFields()
+
+	if err = decoder.Decode(&patched); err != nil {
+		err = models.ValidationError{Err: fmt.Errorf("patched shipment is invalid: %w", err)}
+		return
+	}
+
+	if err = patched.validate(); err != nil {
+		err = models.ValidationError{Err: fmt.Errorf("patched shipment is invalid: %w", err)}
+		return
+	}
+
+	shipment := patched.toInternal(internal.TenantID)
+
+	// The package of a shipment with a single parcel is the same parcel.
+	if len(patched.Parcels) == 0 && len(internal.Parcels) == 1 {
+		shipment.Parcels[0].ID = internal.Parcels[0].ID
+	}
+
+	return shipment, nil
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/gorilla/mux"
 	swagger_http "github.com/swaggo/http-swagger"
 
+	"github.com/lonnblad/shipment-service-backend/boundaries/rest/utils"
 	swagger_docs "github.com/lonnblad/shipment-service-backend/boundaries/rest/v1/generated/swagger"
 	"github.com/lonnblad/shipment-service-backend/businesslogic"
 )
@@ -17,19 +18,40 @@ import (
 // @description The API requires a Tenant ID.
 // @description For the purpose of testing, use this ID:
 // @description **fe131811-7fcd-4942-84a2-4ce8af359da5**
+// @securityDefinitions.apikey AdminToken
+// @in header
+// @name Authorization
 type API struct {
-	router    *mux.Router
-	logic     *businesslogic.BusinessLogic
-	publicURL url.URL
+	router      *mux.Router
+	logic       *businesslogic.BusinessLogic
+	publicURL   url.URL
+	idempotency *utils.Idempotency
+	adminGuard  *utils.AdminGuard
 }
 
-func New(router *mux.Router, publicURL url.URL) *API {
-	api := &API{router: router, publicURL: publicURL}
+// New will register the endpoints on the router, the idempotency keys
+// are scoped by tenant, which allows tenants to use the same keys, and
+// the admin endpoints are guarded by the admin guard.
+func New(router *mux.Router, publicURL url.URL, idempotency *utils.Idempotency, adminGuard *utils.AdminGuard) *API {
+	api := &API{router: router, publicURL: publicURL, adminGuard: adminGuard}
+	api.idempotency = idempotency.WithScope(func(req *http.Request) string {
+		return mux.Vars(req)[keyTenantID]
+	})
 
 	api.
 		withCreateShipmentHandler().
 		withListShipmentsHandler().
+		withSearchShipmentsHandler().
 		withGetShipmentHandler().
+		withUpdateShipmentHandler().
+		withTransitionShipmentHandler().
+		withCancelShipmentHandler().
+		withAppendTrackingEventHandler().
+		withListTrackingEventsHandler().
+		withCreateQuoteHandler().
+		withUploadRateCardHandler().
+		withListRateCardsHandler().
+		withPreviewRateCardHandler().
 		withSwagger(publicURL)
 
 	return api
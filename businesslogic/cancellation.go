@@ -0,0 +1,82 @@
+package businesslogic
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/lonnblad/shipment-service-backend/businesslogic/models"
+	"github.com/lonnblad/shipment-service-backend/storage"
+	"github.com/lonnblad/shipment-service-backend/trace"
+)
+
+// CancelShipment will cancel the shipment and refund its price minus the
+// cancellation fee, which is only allowed before the shipment is picked up
+// and if the precondition allows a change of the current version.
+//
+// The cancellation only needs who cancelled the shipment and why, the
+// time, the fee and the refund are set when it is cancelled.
+func (bl *BusinessLogic) CancelShipment(
+	ctx context.Context, tenantID, shipmentID uuid.UUID, precondition models.Precondition, cancellation models.Cancellation,
+) (_ models.Shipment, err error) {
+	ctx, span := trace.Tracer().Start(ctx, "businesslogic.CancelShipment")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant_id", tenantID.String()),
+		attribute.String("shipment_id", shipmentID.String()),
+	)
+
+	if err = cancellation.Validate(); err != nil {
+		err = fmt.Errorf("cancellation was invalid: %w", err)
+		return
+	}
+
+	dlShipment, err := bl.storage.GetShipment(ctx, tenantID.String(), shipmentID.String())
+	if err != nil {
+		err = fmt.Errorf("could not get shipment: %w", err)
+		return
+	}
+
+	shipment := models.Shipment{}.FromDatalayer(dlShipment)
+
+	if err = precondition.Check(shipment); err != nil {
+		err = fmt.Errorf("could not cancel shipment: %w", err)
+		return
+	}
+
+	if err = shipment.Status.Transition(models.StatusCancelled); err != nil {
+		err = fmt.Errorf("could not cancel shipment: %w", err)
+		return
+	}
+
+	cancellation.Fee, cancellation.Refund, err = bl.cancellationFees.CalculateRefund(shipment)
+	if err != nil {
+		err = fmt.Errorf("could not calculate the refund of the shipment: %w", err)
+		return
+	}
+
+	cancellation.CancelledAt = time.Now()
+
+	span.SetAttributes(
+		attribute.String("status.from", string(shipment.Status)),
+		attribute.Int("cancellation.fee", cancellation.Fee),
+		attribute.Int("cancellation.refund", cancellation.Refund),
+	)
+
+	dlCancellation := storage.Cancellation(cancellation)
+	update := storage.StatusUpdate{Status: string(models.StatusCancelled), Cancellation: &dlCancellation}
+
+	// The shipment is only cancelled if no one else has changed it since
+	// it was read, the refund could otherwise be for another status or price.
+	dlShipment, err = bl.storage.UpdateShipmentStatus(ctx, tenantID.String(), shipmentID.String(), dlShipment.Version, update)
+	if err != nil {
+		err = fmt.Errorf("could not cancel the shipment in storage: %w", err)
+		return
+	}
+
+	return models.Shipment{}.FromDatalayer(dlShipment), nil
+}
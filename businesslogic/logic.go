@@ -2,6 +2,7 @@ package businesslogic
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -14,15 +15,87 @@ import (
 	"github.com/lonnblad/shipment-service-backend/trace"
 )
 
+// defaultQuoteTTL is for how long a quote is valid by default.
+const defaultQuoteTTL = 30 * time.Minute
+
 type BusinessLogic struct {
-	storage storage.ShipmentStorage
+	storage          storage.ShipmentStorage
+	trackingEvents   storage.TrackingEventStorage
+	quotes           storage.QuoteStorage
+	rateCards        storage.RateCardStorage
+	rateCardCache    *rateCardCache
+	priceRules       price.RateCards
+	converter        price.Converter
+	taxRules         price.TaxRules
+	cancellationFees price.CancellationFeeRules
+	quoteTTL         time.Duration
+}
+
+// New will take a pointer the ShipmentStorage, the TrackingEventStorage, the
+// QuoteStorage and the RateCardStorage and return a new BusinessLogic
+// instance, which uses the default rate cards, currency converter, tax
+// rules, cancellation fee rules and quote time to live.
+func New(
+	storage storage.ShipmentStorage,
+	trackingEvents storage.TrackingEventStorage,
+	quotes storage.QuoteStorage,
+	rateCards storage.RateCardStorage,
+) *BusinessLogic {
+	return &BusinessLogic{
+		storage:          storage,
+		trackingEvents:   trackingEvents,
+		quotes:           quotes,
+		rateCards:        rateCards,
+		rateCardCache:    newRateCardCache(),
+		priceRules:       price.DefaultRateCards(),
+		converter:        price.DefaultConverter(),
+		taxRules:         price.DefaultTaxRules(),
+		cancellationFees: price.DefaultCancellationFeeRules(),
+		quoteTTL:         defaultQuoteTTL,
+	}
+}
+
+// WithRateCards will replace the history of the default rate cards, which
+// prices are calculated with unless the tenant has uploaded rate cards that
+// override them.
+func (bl *BusinessLogic) WithRateCards(rateCards price.RateCards) *BusinessLogic {
+	bl.priceRules = rateCards
+	bl.rateCardCache = newRateCardCache()
+
+	return bl
+}
+
+// WithConverter will replace the default currency converter, which
+// converts the prices to the currency that a shipment is priced in.
+func (bl *BusinessLogic) WithConverter(converter price.Converter) *BusinessLogic {
+	bl.converter = converter
+	return bl
+}
+
+// WithTaxRules will replace the default tax rules, which
+// the VAT of the price of a shipment is calculated with.
+func (bl *BusinessLogic) WithTaxRules(rules price.TaxRules) *BusinessLogic {
+	bl.taxRules = rules
+	return bl
+}
+
+// WithCancellationFeeRules will replace the cancellation fee rules.
+func (bl *BusinessLogic) WithCancellationFeeRules(rules price.CancellationFeeRules) *BusinessLogic {
+	bl.cancellationFees = rules
+	return bl
 }
 
-// New will take a pointer the ShipmentStorage and return a new BusinessLogic instance.
-func New(storage storage.ShipmentStorage) *BusinessLogic {
-	return &BusinessLogic{storage: storage}
+// WithQuoteTTL will replace for how long a quote is valid.
+func (bl *BusinessLogic) WithQuoteTTL(ttl time.Duration) *BusinessLogic {
+	bl.quoteTTL = ttl
+	return bl
 }
 
+// CreateShipment will validate and store the shipment, the price is
+// calculated unless the shipment has a QuoteID, then the price of the
+// quote is honoured as long as the quote hasn't expired. The VAT of the
+// price is calculated for the sender of the shipment. Each parcel is given
+// an ID, a shipment without parcels has its package as its only parcel.
 func (bl *BusinessLogic) CreateShipment(ctx context.Context, shipment models.Shipment) (_ models.Shipment, err error) {
 	ctx, span := trace.Tracer().Start(ctx, "businesslogic.CreateShipment")
 	defer span.End()
@@ -30,6 +103,8 @@ func (bl *BusinessLogic) CreateShipment(ctx context.Context, shipment models.Shi
 	span.SetAttributes(
 		attribute.String("shipment.tenant_id", shipment.TenantID.String()),
 		attribute.String("shipment.sender.country_code", shipment.Sender.CountryCode),
+		attribute.String("shipment.quote_id", shipment.QuoteID.String()),
+		attribute.String("shipment.package.currency", shipment.Package.Currency),
 	)
 
 	if err = shipment.Validate(); err != nil {
@@ -37,23 +112,36 @@ func (bl *BusinessLogic) CreateShipment(ctx context.Context, shipment models.Shi
 		return
 	}
 
+	shipment = withNewParcelIDs(shipment)
 	shipment.ID = uuid.New()
 	shipment.CreatedAt = time.Now()
+	shipment.Status = models.StatusCreated
+	shipment.Version = 1
 
 	span.SetAttributes(
 		attribute.String("shipment.id", shipment.ID.String()),
 		attribute.String("shipment.created_at", shipment.CreatedAt.Format(time.RFC3339)),
 		attribute.Int("shipment.package.weight", shipment.Package.Weight),
+		attribute.Int("shipment.parcels", len(shipment.Parcels)),
 	)
 
-	shipment.Package.Price, err = price.Calculate(shipment)
-	if err != nil {
+	if shipment, err = bl.priceShipment(ctx, shipment); err != nil {
 		err = fmt.Errorf("could not calculate the price of the shipment: %w", err)
 		return
 	}
 
+	// A shipment with a quote keeps the quoted tax.
+	if shipment.Tax == nil {
+		if shipment, err = bl.applyTax(shipment); err != nil {
+			return
+		}
+	}
+
 	span.SetAttributes(
 		attribute.Int("shipment.package.price", shipment.Package.Price),
+		attribute.String("shipment.package.currency", shipment.Package.Currency),
+		attribute.String("shipment.rate_card_version", shipment.RateCardVersion),
+		attribute.String("shipment.tax.rule", shipment.Tax.Rule),
 	)
 
 	dlShipment := shipment.ToDatalayer()
@@ -65,23 +153,114 @@ func (bl *BusinessLogic) CreateShipment(ctx context.Context, shipment models.Shi
 	return shipment, nil
 }
 
-func (bl *BusinessLogic) ListShipments(ctx context.Context, tenantID uuid.UUID, limit, offset int) (_ models.Shipments, err error) {
+// priceShipment will price the shipment with the rate card of the tenant that
+// was effective when the shipment was created, unless the shipment has a
+// quote, then the quoted prices, currency, breakdowns, exchange rate and tax are used.
+func (bl *BusinessLogic) priceShipment(ctx context.Context, shipment models.Shipment) (_ models.Shipment, err error) {
+	if shipment.QuoteID != uuid.Nil {
+		var quote models.Quote
+
+		if quote, err = bl.validQuote(ctx, shipment); err != nil {
+			return
+		}
+
+		shipment.Package.Price = quote.Package.Price
+		shipment.Package.Currency = quote.Package.Currency
+		shipment.PriceBreakdown = quote.PriceBreakdown
+		shipment.ExchangeRate = quote.ExchangeRate
+		shipment.Tax = quote.Tax
+
+		// The parcels of the shipment match the parcels of the quote in order.
+		for idx := range shipment.Parcels {
+			shipment.Parcels[idx].Price = quote.Parcels[idx].Price
+			shipment.Parcels[idx].Currency = quote.Parcels[idx].Currency
+			shipment.Parcels[idx].PriceBreakdown = quote.Parcels[idx].PriceBreakdown
+		}
+
+		if quote.PriceBreakdown != nil {
+			shipment.RateCardVersion = quote.PriceBreakdown.RateCardVersion
+		}
+
+		return shipment, nil
+	}
+
+	rateCards, err := bl.rateCardsOf(ctx, shipment.TenantID)
+	if err != nil {
+		return
+	}
+
+	return price.Apply(ctx, rateCards, bl.converter, shipment)
+}
+
+// withNewParcelIDs will return the shipment with a new ID for each parcel,
+// a shipment without parcels is given its package as its only parcel.
+func withNewParcelIDs(shipment models.Shipment) models.Shipment {
+	parcels := []models.Parcel{{Package: shipment.Package}}
+	if len(shipment.Parcels) > 0 {
+		parcels = append([]models.Parcel(nil), shipment.Parcels...)
+	}
+
+	for idx := range parcels {
+		parcels[idx].ID = uuid.New()
+	}
+
+	return shipment.WithParcels(parcels)
+}
+
+// applyTax will calculate the VAT of the price of the shipment.
+func (bl *BusinessLogic) applyTax(shipment models.Shipment) (_ models.Shipment, err error) {
+	tax, err := bl.taxRules.Calculate(shipment)
+	if err != nil {
+		err = fmt.Errorf("could not calculate the tax of the shipment: %w", err)
+		return
+	}
+
+	shipment.Tax = &tax
+
+	return shipment, nil
+}
+
+func (bl *BusinessLogic) ListShipments(
+	ctx context.Context, tenantID uuid.UUID, query models.ListShipmentsQuery,
+) (_ models.ShipmentsPage, err error) {
 	ctx, span := trace.Tracer().Start(ctx, "businesslogic.ListShipments")
 	defer span.End()
 
 	span.SetAttributes(
 		attribute.String("tenant_id", tenantID.String()),
-		attribute.Int("limit", limit),
-		attribute.Int("offset", offset),
+		attribute.Int("limit", query.Limit),
+		attribute.Int("offset", query.Offset),
+		attribute.Bool("cursor", query.Cursor != nil),
 	)
 
-	dlShipments, err := bl.storage.ListShipments(ctx, tenantID.String(), limit, offset)
+	dlPage, err := bl.storage.ListShipments(ctx, tenantID.String(), query.ToDatalayer())
 	if err != nil {
 		err = fmt.Errorf("could not list shipments: %w", err)
 		return
 	}
 
-	return models.Shipments{}.FromDatalayer(dlShipments), nil
+	return models.ShipmentsPage{}.FromDatalayer(dlPage), nil
+}
+
+func (bl *BusinessLogic) SearchShipments(
+	ctx context.Context, tenantID uuid.UUID, query models.SearchShipmentsQuery,
+) (_ models.ShipmentsPage, err error) {
+	ctx, span := trace.Tracer().Start(ctx, "businesslogic.SearchShipments")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant_id", tenantID.String()),
+		attribute.Int("limit", query.Limit),
+		attribute.Bool("cursor", query.Cursor != nil),
+	)
+
+	dlPage, err := bl.storage.SearchShipments(ctx, tenantID.String(), query.ToDatalayer())
+	if err != nil {
+		err = fmt.Errorf("could not search shipments: %w", err)
+		return
+	}
+
+	return models.ShipmentsPage{}.FromDatalayer(dlPage), nil
 }
 
 func (bl *BusinessLogic) GetShipment(ctx context.Context, tenantID, shipmentID uuid.UUID) (_ models.Shipment, err error) {
@@ -99,5 +278,74 @@ func (bl *BusinessLogic) GetShipment(ctx context.Context, tenantID, shipmentID u
 		return
 	}
 
+	shipment := models.Shipment{}.FromDatalayer(dlShipment)
+
+	dlEvent, err := bl.trackingEvents.LatestTrackingEvent(ctx, tenantID.String(), shipmentID.String())
+	if errors.Is(err, storage.ErrNotFound) {
+		return shipment, nil
+	}
+
+	if err != nil {
+		err = fmt.Errorf("could not get the latest tracking event: %w", err)
+		return
+	}
+
+	latest := models.TrackingEvent{}.FromDatalayer(dlEvent)
+	shipment.LatestTrackingEvent = &latest
+
+	return shipment, nil
+}
+
+// TransitionShipment will transition the shipment to the status, if the
+// state machine of the lifecycle allows it from the current status and
+// the precondition allows a change of the current version.
+//
+// A shipment can't be transitioned to cancelled, it has to be cancelled
+// with CancelShipment, which also records the cancellation.
+func (bl *BusinessLogic) TransitionShipment(
+	ctx context.Context, tenantID, shipmentID uuid.UUID, precondition models.Precondition, status models.Status,
+) (_ models.Shipment, err error) {
+	ctx, span := trace.Tracer().Start(ctx, "businesslogic.TransitionShipment")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant_id", tenantID.String()),
+		attribute.String("shipment_id", shipmentID.String()),
+		attribute.String("status", string(status)),
+	)
+
+	if status == models.StatusCancelled {
+		err = models.ValidationError{Err: fmt.Errorf("a shipment can't be transitioned to: %s, it has to be cancelled", status)}
+		return
+	}
+
+	dlShipment, err := bl.storage.GetShipment(ctx, tenantID.String(), shipmentID.String())
+	if err != nil {
+		err = fmt.Errorf("could not get shipment: %w", err)
+		return
+	}
+
+	shipment := models.Shipment{}.FromDatalayer(dlShipment)
+
+	if err = precondition.Check(shipment); err != nil {
+		err = fmt.Errorf("could not transition shipment: %w", err)
+		return
+	}
+
+	if err = shipment.Status.Transition(status); err != nil {
+		err = fmt.Errorf("could not transition shipment: %w", err)
+		return
+	}
+
+	// The status is only updated if no one else has changed the
+	// shipment since it was read, otherwise storage.ErrConflict is returned.
+	dlShipment, err = bl.storage.UpdateShipmentStatus(
+		ctx, tenantID.String(), shipmentID.String(), dlShipment.Version, storage.StatusUpdate{Status: string(status)},
+	)
+	if err != nil {
+		err = fmt.Errorf("could not update the status of the shipment in storage: %w", err)
+		return
+	}
+
 	return models.Shipment{}.FromDatalayer(dlShipment), nil
 }
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	maxLengthCancelledBy        = 100
+	maxLengthCancellationReason = 200
+)
+
+// Cancellation is who cancelled a shipment, when and why, and the
+// Fee that was kept and the amount refunded of the price.
+type Cancellation struct {
+	CancelledBy string
+	CancelledAt time.Time
+	Reason      string
+	Fee         int
+	Refund      int
+}
+
+// Validate will return a ValidationError if the cancellation is invalid.
+func (c Cancellation) Validate() error {
+	if c.CancelledBy == "" {
+		return ValidationError{Err: fmt.Errorf("who cancelled the shipment is required")}
+	}
+
+	if len(c.CancelledBy) > maxLengthCancelledBy {
+		return ValidationError{Err: fmt.Errorf(
+			"cancelled by: %s is longer than max length: %d", c.CancelledBy, maxLengthCancelledBy,
+		)}
+	}
+
+	if len(c.Reason) > maxLengthCancellationReason {
+		return ValidationError{Err: fmt.Errorf(
+			"cancellation reason is longer than max length: %d", maxLengthCancellationReason,
+		)}
+	}
+
+	return nil
+}
@@ -10,21 +10,91 @@ import (
 
 type Shipments []Shipment
 
+// ListShipmentsQuery selects a page of shipments, ordered by the sort.
+type ListShipmentsQuery struct {
+	Limit  int
+	Filter storage.ShipmentsFilter
+	Sort   storage.Sort
+	Cursor *storage.Cursor
+	// Deprecated: Offset is only used when there is no Cursor.
+	Offset int
+}
+
+// SearchShipmentsQuery selects a page of the shipments matching
+// the text, ordered by creation time.
+type SearchShipmentsQuery struct {
+	Text   string
+	Limit  int
+	Cursor *storage.Cursor
+}
+
+// ShipmentsPage is a page of shipments, Prev is nil on the
+// first page and Next is nil on the last page.
+type ShipmentsPage struct {
+	Shipments Shipments
+	Total     int
+	Prev      *storage.Cursor
+	Next      *storage.Cursor
+}
+
 type Shipment struct {
 	ID        uuid.UUID
 	TenantID  uuid.UUID
 	CreatedAt time.Time
+	Status    Status
+	Version   int
 
 	Sender   Sender
 	Receiver Receiver
-	Package  Package
+
+	// Package is the total weight and price of the parcels, it has the
+	// dimensions of the parcel when the shipment only has one parcel.
+	Package Package
+	Parcels []Parcel
+
+	// Cancellation is nil unless the shipment is cancelled.
+	Cancellation *Cancellation
+
+	// QuoteID is the quote that the price is taken from,
+	// it is uuid.Nil if the shipment wasn't quoted.
+	QuoteID uuid.UUID
+
+	// PriceBreakdown is how the price was calculated, it is nil for
+	// shipments that were created before the breakdown was stored.
+	PriceBreakdown *PriceBreakdown
+
+	// RateCardVersion is the version of the price rules that priced the
+	// shipment, it is empty for shipments that were created before it was
+	// stored or with a quote that was created before it was stored.
+	RateCardVersion string
+
+	// ExchangeRate is the rate that the price was converted with from the
+	// currency of the rate card, it is nil if the price wasn't converted.
+	ExchangeRate *ExchangeRate
+
+	// Tax is the VAT of the price, it is nil for shipments
+	// that were created before the tax was stored.
+	Tax *Tax
+
+	// LatestTrackingEvent is nil when there are no tracking events,
+	// it is only set when a single shipment is requested.
+	LatestTrackingEvent *TrackingEvent
+}
+
+// ShipmentPatch is a change of the details of a shipment, Apply will
+// return the changed shipment or an error if the patch is invalid.
+type ShipmentPatch interface {
+	Apply(Shipment) (Shipment, error)
 }
 
+// Sender is who sends a shipment, the VATNumber is empty
+// when the sender isn't a business registered for VAT.
 type Sender struct {
 	Name        string
 	Email       string
 	Address     string
 	CountryCode string
+	VATNumber   string
 }
 
 type Receiver struct {
@@ -34,20 +104,290 @@ type Receiver struct {
 	CountryCode string
 }
 
+// Package is the weight of a package in grams, its length, width and height
+// in cm, which are zero when they aren't known, and its price, which is in the
+// minor units of the currency, e.g. 100.05 EUR is 10005.
 type Package struct {
-	Weight int
-	Price  int
+	Weight   int
+	Length   int
+	Width    int
+	Height   int
+	Price    int
+	Currency string
+}
+
+// HasDimensions will return true if the dimensions of the package are known.
+func (p Package) HasDimensions() bool {
+	return p.Length != 0 || p.Width != 0 || p.Height != 0
+}
+
+// Parcel is one of the packages of a shipment, the price of the parcel is in
+// the currency of the shipment and before the consignment discount.
+type Parcel struct {
+	ID uuid.UUID
+	Package
+
+	// PriceBreakdown is how the price of the parcel was calculated.
+	PriceBreakdown *PriceBreakdown
+}
+
+// WithParcels will return the shipment with the parcels, the weight of its
+// package is the total weight of the parcels and it has the dimensions of
+// the parcel when there is only one.
+func (s Shipment) WithParcels(parcels []Parcel) Shipment {
+	s.Parcels = parcels
+	s.Package.Weight = 0
+	s.Package.Length, s.Package.Width, s.Package.Height = 0, 0, 0
+
+	for _, parcel := range parcels {
+		s.Package.Weight += parcel.Weight
+	}
+
+	if len(parcels) == 1 {
+		s.Package.Length, s.Package.Width, s.Package.Height = parcels[0].Length, parcels[0].Width, parcels[0].Height
+	}
+
+	return s
+}
+
+// ExchangeRate is the rate that an amount was converted with from a currency
+// to another, the Rate is multiplied by 1000000 to avoid floating points,
+// which means that 87500 is a rate of 0.0875. The converted amount is rounded
+// to the closest minor unit of the currency according to the Rounding.
+type ExchangeRate struct {
+	From     string
+	To       string
+	Rate     int64
+	Rounding string
+}
+
+// Tax is the VAT of the net price of a package, according to the Rule that
+// applies to where the package is sent from and to. The Rate is the percent
+// multiplied by 10 to avoid floating points, which means that 255 is 25.5%,
+// and the CountryCode is the country that the VAT is paid to, it is empty
+// when no VAT is charged.
+type Tax struct {
+	Rule        string
+	CountryCode string
+	Rate        int
+	Net         int
+	Amount      int
+	Gross       int
+}
+
+// PriceBreakdown is how the price of a package is calculated, which is
+// the base price of its weight class multiplied by the multiplier of
+// the region of the sender, plus the adjustments.
+type PriceBreakdown struct {
+	// RateCardVersion is the version of the rate card that priced the package.
+	RateCardVersion string
+	// Currency is the currency of the rate card, the amounts of
+	// the breakdown are in the minor units of the currency.
+	Currency string
+
+	// VolumetricWeight is the weight in grams that the dimensions of the
+	// package correspond to, it is zero when they aren't known. The
+	// ChargeableWeight is the greater of it and the weight of the package,
+	// which is the weight that the weight class is selected by.
+	VolumetricWeight int
+	ChargeableWeight int
+
+	WeightClass string
+	BasePrice   int
+	Region      string
+	// RegionMultiplier is multiplied by 10 to avoid floating points,
+	// which means that 15 is a multiplier of 1.5.
+	RegionMultiplier int
+	// Adjustments are the surcharges and discounts that
+	// are added to the price after the multiplier.
+	Adjustments []PriceAdjustment
+	Price       int
+}
+
+// PriceAdjustment is a surcharge, or a discount when the amount is negative.
+type PriceAdjustment struct {
+	Name   string
+	Amount int
+}
+
+func (b PriceBreakdown) ToDatalayer() (dlBreakdown storage.PriceBreakdown) {
+	dlBreakdown.RateCardVersion = b.RateCardVersion
+	dlBreakdown.Currency = b.Currency
+	dlBreakdown.VolumetricWeight = b.VolumetricWeight
+	dlBreakdown.ChargeableWeight = b.ChargeableWeight
+	dlBreakdown.WeightClass = b.WeightClass
+	dlBreakdown.BasePrice = b.BasePrice
+	dlBreakdown.Region = b.Region
+	dlBreakdown.RegionMultiplier = b.RegionMultiplier
+	dlBreakdown.Price = b.Price
+
+	for _, adjustment := range b.Adjustments {
+		dlBreakdown.Adjustments = append(dlBreakdown.Adjustments, storage.PriceAdjustment(adjustment))
+	}
+
+	return
+}
+
+func (b PriceBreakdown) FromDatalayer(dlBreakdown storage.PriceBreakdown) PriceBreakdown {
+	b.RateCardVersion = dlBreakdown.RateCardVersion
+	b.Currency = dlBreakdown.Currency
+	b.VolumetricWeight = dlBreakdown.VolumetricWeight
+	b.ChargeableWeight = dlBreakdown.ChargeableWeight
+	b.WeightClass = dlBreakdown.WeightClass
+	b.BasePrice = dlBreakdown.BasePrice
+	b.Region = dlBreakdown.Region
+	b.RegionMultiplier = dlBreakdown.RegionMultiplier
+	b.Price = dlBreakdown.Price
+
+	for _, adjustment := range dlBreakdown.Adjustments {
+		b.Adjustments = append(b.Adjustments, PriceAdjustment(adjustment))
+	}
+
+	return b
+}
+
+func (r ExchangeRate) ToDatalayer() storage.ExchangeRate {
+	return storage.ExchangeRate(r)
+}
+
+func (r ExchangeRate) FromDatalayer(dlRate storage.ExchangeRate) ExchangeRate {
+	return ExchangeRate(dlRate)
+}
+
+// exchangeRateToDatalayer will return nil if the rate is nil.
+func exchangeRateToDatalayer(rate *ExchangeRate) *storage.ExchangeRate {
+	if rate == nil {
+		return nil
+	}
+
+	dlRate := rate.ToDatalayer()
+
+	return &dlRate
+}
+
+// exchangeRateFromDatalayer will return nil if the rate is nil.
+func exchangeRateFromDatalayer(dlRate *storage.ExchangeRate) *ExchangeRate {
+	if dlRate == nil {
+		return nil
+	}
+
+	rate := ExchangeRate{}.FromDatalayer(*dlRate)
+
+	return &rate
+}
+
+// taxToDatalayer will return nil if the tax is nil.
+func taxToDatalayer(tax *Tax) *storage.Tax {
+	if tax == nil {
+		return nil
+	}
+
+	dlTax := storage.Tax(*tax)
+
+	return &dlTax
+}
+
+// taxFromDatalayer will return nil if the tax is nil.
+func taxFromDatalayer(dlTax *storage.Tax) *Tax {
+	if dlTax == nil {
+		return nil
+	}
+
+	tax := Tax(*dlTax)
+
+	return &tax
+}
+
+func (p Parcel) ToDatalayer() (dlParcel storage.Parcel) {
+	dlParcel.ID = p.ID.String()
+	dlParcel.Weight = p.Weight
+	dlParcel.Length = p.Length
+	dlParcel.Width = p.Width
+	dlParcel.Height = p.Height
+	dlParcel.Price = p.Price
+
+	if p.PriceBreakdown != nil {
+		dlBreakdown := p.PriceBreakdown.ToDatalayer()
+		dlParcel.PriceBreakdown = &dlBreakdown
+	}
+
+	return
+}
+
+// FromDatalayer will return the parcel in the currency of the shipment.
+func (p Parcel) FromDatalayer(dlParcel storage.Parcel, currency string) Parcel {
+	p.ID = uuid.MustParse(dlParcel.ID)
+	p.Weight = dlParcel.Weight
+	p.Length = dlParcel.Length
+	p.Width = dlParcel.Width
+	p.Height = dlParcel.Height
+	p.Price = dlParcel.Price
+	p.Currency = currency
+
+	if dlParcel.PriceBreakdown != nil {
+		breakdown := PriceBreakdown{}.FromDatalayer(*dlParcel.PriceBreakdown)
+		p.PriceBreakdown = &breakdown
+	}
+
+	return p
+}
+
+// parcelsToDatalayer will return nil if there are no parcels.
+func parcelsToDatalayer(parcels []Parcel) (dlParcels []storage.Parcel) {
+	for _, parcel := range parcels {
+		dlParcels = append(dlParcels, parcel.ToDatalayer())
+	}
+
+	return
+}
+
+// parcelsFromDatalayer will return the parcels of the shipment or quote with
+// the ID. A legacy shipment or quote has a single package, which is returned
+// as a parcel with an ID that is derived from the ID of the shipment or quote.
+func parcelsFromDatalayer(id uuid.UUID, dlParcels []storage.Parcel, p Package, breakdown *PriceBreakdown) []Parcel {
+	if len(dlParcels) == 0 {
+		return []Parcel{{ID: uuid.NewSHA1(id, []byte("parcel")), Package: p, PriceBreakdown: breakdown}}
+	}
+
+	parcels := make([]Parcel, len(dlParcels))
+
+	for idx := range dlParcels {
+		parcels[idx] = Parcel{}.FromDatalayer(dlParcels[idx], p.Currency)
+	}
+
+	return parcels
 }
 
 func (s Shipment) ToDatalayer() (dlShipment storage.Shipment) {
 	dlShipment.ID = s.ID.String()
 	dlShipment.TenantID = s.TenantID.String()
 	dlShipment.CreatedAt = s.CreatedAt
+	dlShipment.Status = string(s.Status)
+	dlShipment.Version = s.Version
 
 	dlShipment.Sender = storage.Sender(s.Sender)
 	dlShipment.Receiver = storage.Receiver(s.Receiver)
 	dlShipment.Package = storage.Package(s.Package)
 
+	if s.Cancellation != nil {
+		dlCancellation := storage.Cancellation(*s.Cancellation)
+		dlShipment.Cancellation = &dlCancellation
+	}
+
+	if s.QuoteID != uuid.Nil {
+		dlShipment.QuoteID = s.QuoteID.String()
+	}
+
+	if s.PriceBreakdown != nil {
+		dlBreakdown := s.PriceBreakdown.ToDatalayer()
+		dlShipment.PriceBreakdown = &dlBreakdown
+	}
+
+	dlShipment.RateCardVersion = s.RateCardVersion
+	dlShipment.ExchangeRate = exchangeRateToDatalayer(s.ExchangeRate)
+	dlShipment.Tax = taxToDatalayer(s.Tax)
+	dlShipment.Parcels = parcelsToDatalayer(s.Parcels)
+
 	return
 }
 
@@ -55,11 +395,37 @@ func (s Shipment) FromDatalayer(dlShipment storage.Shipment) Shipment {
 	s.ID = uuid.MustParse(dlShipment.ID)
 	s.TenantID = uuid.MustParse(dlShipment.TenantID)
 	s.CreatedAt = dlShipment.CreatedAt
+	s.Status = Status(dlShipment.Status)
+	s.Version = dlShipment.Version
+
+	// Shipments stored before the lifecycle was introduced have no status.
+	if s.Status == "" {
+		s.Status = StatusCreated
+	}
 
 	s.Sender = Sender(dlShipment.Sender)
 	s.Receiver = Receiver(dlShipment.Receiver)
 	s.Package = Package(dlShipment.Package)
 
+	if dlShipment.Cancellation != nil {
+		cancellation := Cancellation(*dlShipment.Cancellation)
+		s.Cancellation = &cancellation
+	}
+
+	if dlShipment.QuoteID != "" {
+		s.QuoteID = uuid.MustParse(dlShipment.QuoteID)
+	}
+
+	if dlShipment.PriceBreakdown != nil {
+		breakdown := PriceBreakdown{}.FromDatalayer(*dlShipment.PriceBreakdown)
+		s.PriceBreakdown = &breakdown
+	}
+
+	s.RateCardVersion = dlShipment.RateCardVersion
+	s.ExchangeRate = exchangeRateFromDatalayer(dlShipment.ExchangeRate)
+	s.Tax = taxFromDatalayer(dlShipment.Tax)
+	s.Parcels = parcelsFromDatalayer(s.ID, dlShipment.Parcels, s.Package, s.PriceBreakdown)
+
 	return s
 }
 
@@ -72,3 +438,20 @@ func (s Shipments) FromDatalayer(dlShipments []storage.Shipment) Shipments {
 
 	return s
 }
+
+func (q ListShipmentsQuery) ToDatalayer() storage.ListShipmentsQuery {
+	return storage.ListShipmentsQuery(q)
+}
+
+func (q SearchShipmentsQuery) ToDatalayer() storage.SearchShipmentsQuery {
+	return storage.SearchShipmentsQuery(q)
+}
+
+func (p ShipmentsPage) FromDatalayer(dlPage storage.ShipmentsPage) ShipmentsPage {
+	p.Shipments = Shipments{}.FromDatalayer(dlPage.Shipments)
+	p.Total = dlPage.Total
+	p.Prev = dlPage.Prev
+	p.Next = dlPage.Next
+
+	return p
+}
@@ -0,0 +1,127 @@
+package models_test
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lonnblad/shipment-service-backend/businesslogic/models"
+	"github.com/lonnblad/shipment-service-backend/storage"
+)
+
+func Test_Shipment_FromDatalayer_Currency(t *testing.T) {
+	dlShipment := storage.Shipment{
+		ID:             uuid.New().String(),
+		TenantID:       uuid.New().String(),
+		Status:         "booked",
+		Package:        storage.Package{Weight: 10000, Price: 135, Currency: "EUR"},
+		Cancellation:   &storage.Cancellation{Fee: 20, Refund: 115},
+		PriceBreakdown: &storage.PriceBreakdown{Currency: "SEK", BasePrice: 150, Price: 150},
+		ExchangeRate:   &storage.ExchangeRate{From: "SEK", To: "EUR", Rate: 87500, Rounding: "half-up"},
+	}
+
+	// The breakdown is in the currency of the rate card and the price is converted to the currency of the package.
+	shipment := models.Shipment{}.FromDatalayer(dlShipment)
+	assert.Equal(t, models.Package{Weight: 10000, Price: 135, Currency: "EUR"}, shipment.Package)
+	assert.Equal(t, 20, shipment.Cancellation.Fee)
+	assert.Equal(t, "SEK", shipment.PriceBreakdown.Currency)
+	assert.Equal(t, 150, shipment.PriceBreakdown.Price)
+	assert.Equal(t, &models.ExchangeRate{From: "SEK", To: "EUR", Rate: 87500, Rounding: "half-up"}, shipment.ExchangeRate)
+
+	// The package of a legacy shipment is stored as its only parcel.
+	dlShipment.Parcels = []storage.Parcel{
+		{ID: shipment.Parcels[0].ID.String(), Weight: 10000, Price: 135, PriceBreakdown: dlShipment.PriceBreakdown},
+	}
+	assert.Equal(t, dlShipment, shipment.ToDatalayer())
+}
+
+func Test_Shipment_FromDatalayer_Parcels(t *testing.T) {
+	dlShipment := storage.Shipment{
+		ID:             uuid.New().String(),
+		TenantID:       uuid.New().String(),
+		Status:         "created",
+		Package:        storage.Package{Weight: 10000, Length: 40, Width: 30, Height: 20, Price: 15000, Currency: "SEK"},
+		PriceBreakdown: &storage.PriceBreakdown{Currency: "SEK", BasePrice: 15000, Price: 15000},
+	}
+
+	// A legacy shipment has its package as its only parcel, which always gets the same ID.
+	shipment := models.Shipment{}.FromDatalayer(dlShipment)
+	assert.Len(t, shipment.Parcels, 1)
+	assert.Equal(t, shipment.Package, shipment.Parcels[0].Package)
+	assert.Equal(t, shipment.PriceBreakdown, shipment.Parcels[0].PriceBreakdown)
+	assert.Equal(t, models.Shipment{}.FromDatalayer(dlShipment).Parcels[0].ID, shipment.Parcels[0].ID)
+
+	dlShipment.Package = storage.Package{Weight: 10000, Price: 27000, Currency: "SEK"}
+	dlShipment.Parcels = []storage.Parcel{
+		{ID: uuid.New().String(), Weight: 6000, Length: 40, Width: 30, Height: 20, Price: 15000},
+		{ID: uuid.New().String(), Weight: 4000, Price: 15000},
+	}
+
+	shipment = models.Shipment{}.FromDatalayer(dlShipment)
+	assert.Equal(t, 6000, shipment.Parcels[0].Weight)
+	assert.Equal(t, 15000, shipment.Parcels[0].Price)
+	assert.Equal(t, "SEK", shipment.Parcels[1].Currency)
+	assert.Equal(t, dlShipment, shipment.ToDatalayer())
+}
+
+func Test_Shipment_WithParcels(t *testing.T) {
+	shipment := models.Shipment{Package: models.Package{Weight: 1000, Price: 15000, Currency: "SEK"}}
+
+	shipment = shipment.WithParcels([]models.Parcel{{Package: models.Package{Weight: 6000, Length: 40, Width: 30, Height: 20}}})
+	assert.Equal(t, models.Package{Weight: 6000, Length: 40, Width: 30, Height: 20, Price: 15000, Currency: "SEK"}, shipment.Package)
+
+	shipment = shipment.WithParcels([]models.Parcel{
+		{Package: models.Package{Weight: 6000, Length: 40, Width: 30, Height: 20}},
+		{Package: models.Package{Weight: 4000}},
+	})
+	assert.Equal(t, models.Package{Weight: 10000, Price: 15000, Currency: "SEK"}, shipment.Package)
+}
+
+func Test_Package_Validate(t *testing.T) {
+	shipment := models.Shipment{
+		Sender:   models.Sender{Name: "User Example A", Email: "a@example.com", Address: "Apt. Example 1A", CountryCode: "SE"},
+		Receiver: models.Receiver{Name: "User Example B", Email: "b@example.com", Address: "Apt. Example 1B", CountryCode: "DE"},
+		Package:  models.Package{Weight: 10000},
+	}
+	assert.NoError(t, shipment.Validate())
+
+	shipment.Package = models.Package{Weight: 10000, Length: 40, Width: 30, Height: 20}
+	assert.NoError(t, shipment.Validate())
+
+	for name, pkg := range map[string]models.Package{
+		"NoHeight":       {Weight: 10000, Length: 40, Width: 30},
+		"NegativeWidth":  {Weight: 10000, Length: 40, Width: -30, Height: 20},
+		"TooLong":        {Weight: 10000, Length: 301, Width: 30, Height: 20},
+		"OnlyWidth":      {Weight: 10000, Width: 30},
+		"TooHeavy":       {Weight: 1000001},
+		"NegativeWeight": {Weight: -1},
+	} {
+		shipment.Package = pkg
+		assert.Error(t, shipment.Validate(), name)
+	}
+}
+
+func Test_Parcels_Validate(t *testing.T) {
+	shipment := models.Shipment{
+		Sender:   models.Sender{Name: "User Example A", Email: "a@example.com", Address: "Apt. Example 1A", CountryCode: "SE"},
+		Receiver: models.Receiver{Name: "User Example B", Email: "b@example.com", Address: "Apt. Example 1B", CountryCode: "DE"},
+	}
+
+	// The total weight of the parcels can be above the maximum weight of a parcel.
+	parcel := models.Parcel{Package: models.Package{Weight: 1000000, Length: 40, Width: 30, Height: 20}}
+	shipment = shipment.WithParcels([]models.Parcel{parcel, parcel})
+	assert.NoError(t, shipment.Validate())
+
+	invalid := models.Parcel{Package: models.Package{Weight: 10000, Length: 40}}
+	shipment = shipment.WithParcels([]models.Parcel{parcel, invalid})
+	assert.Error(t, shipment.Validate())
+
+	parcels := make([]models.Parcel, 100)
+	for idx := range parcels {
+		parcels[idx] = parcel
+	}
+
+	shipment = shipment.WithParcels(parcels)
+	assert.Error(t, shipment.Validate())
+}
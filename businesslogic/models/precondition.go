@@ -0,0 +1,27 @@
+package models
+
+import "fmt"
+
+// Precondition is checked against the version of a shipment before the
+// shipment is changed, it returns false if the change isn't allowed.
+//
+// A nil Precondition allows a change of any version.
+type Precondition func(version int) bool
+
+// PreconditionFailedError is returned by Check when the
+// precondition doesn't allow a change of the version.
+type PreconditionFailedError struct{ Version int }
+
+func (pfe PreconditionFailedError) Error() string {
+	return fmt.Sprintf("the precondition doesn't match the version: %d of the shipment", pfe.Version)
+}
+
+// Check will return a PreconditionFailedError if the
+// precondition doesn't allow a change of the shipment.
+func (p Precondition) Check(s Shipment) error {
+	if p == nil || p(s.Version) {
+		return nil
+	}
+
+	return PreconditionFailedError{Version: s.Version}
+}
@@ -0,0 +1,22 @@
+package models_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lonnblad/shipment-service-backend/businesslogic/models"
+)
+
+func Test_PreconditionCheck(t *testing.T) {
+	shipment := models.Shipment{Version: 2}
+
+	var anyVersion models.Precondition
+	assert.NoError(t, anyVersion.Check(shipment))
+
+	matches := models.Precondition(func(version int) bool { return version == 2 })
+	assert.NoError(t, matches.Check(shipment))
+
+	mismatches := models.Precondition(func(version int) bool { return version == 1 })
+	assert.Equal(t, models.PreconditionFailedError{Version: 2}, mismatches.Check(shipment))
+}
@@ -0,0 +1,110 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/lonnblad/shipment-service-backend/storage"
+)
+
+// Quote is the price of the parcels sent from the sender to the receiver,
+// which is honoured by a shipment created with the quote before it expires.
+type Quote struct {
+	ID        uuid.UUID
+	TenantID  uuid.UUID
+	CreatedAt time.Time
+	ExpiresAt time.Time
+
+	Sender   Sender
+	Receiver Receiver
+
+	// Package is the total weight and price of the parcels, it has the
+	// dimensions of the parcel when the quote only has one parcel.
+	Package Package
+	Parcels []Parcel
+
+	// PriceBreakdown is how the price was calculated, it is nil for
+	// quotes that were created before the breakdown was stored.
+	PriceBreakdown *PriceBreakdown
+
+	// ExchangeRate is the rate that the price was converted with from the
+	// currency of the rate card, it is nil if the price wasn't converted.
+	ExchangeRate *ExchangeRate
+
+	// Tax is the VAT of the price, it is nil for quotes
+	// that were created before the tax was stored.
+	Tax *Tax
+}
+
+// Shipment will return a shipment with the details of the quote.
+func (q Quote) Shipment() Shipment {
+	shipment := Shipment{
+		TenantID:  q.TenantID,
+		CreatedAt: q.CreatedAt,
+		Sender:    q.Sender,
+		Receiver:  q.Receiver,
+		Package:   q.Package,
+		Parcels:   q.Parcels,
+		QuoteID:   q.ID,
+
+		PriceBreakdown: q.PriceBreakdown,
+		ExchangeRate:   q.ExchangeRate,
+		Tax:            q.Tax,
+	}
+
+	if q.PriceBreakdown != nil {
+		shipment.RateCardVersion = q.PriceBreakdown.RateCardVersion
+	}
+
+	return shipment
+}
+
+// Expired will return true if the quote has expired at the time.
+func (q Quote) Expired(at time.Time) bool {
+	return !at.Before(q.ExpiresAt)
+}
+
+func (q Quote) ToDatalayer() (dlQuote storage.Quote) {
+	dlQuote.ID = q.ID.String()
+	dlQuote.TenantID = q.TenantID.String()
+	dlQuote.CreatedAt = q.CreatedAt
+	dlQuote.ExpiresAt = q.ExpiresAt
+
+	dlQuote.Sender = storage.Sender(q.Sender)
+	dlQuote.Receiver = storage.Receiver(q.Receiver)
+	dlQuote.Package = storage.Package(q.Package)
+
+	if q.PriceBreakdown != nil {
+		dlBreakdown := q.PriceBreakdown.ToDatalayer()
+		dlQuote.PriceBreakdown = &dlBreakdown
+	}
+
+	dlQuote.ExchangeRate = exchangeRateToDatalayer(q.ExchangeRate)
+	dlQuote.Tax = taxToDatalayer(q.Tax)
+	dlQuote.Parcels = parcelsToDatalayer(q.Parcels)
+
+	return
+}
+
+func (q Quote) FromDatalayer(dlQuote storage.Quote) Quote {
+	q.ID = uuid.MustParse(dlQuote.ID)
+	q.TenantID = uuid.MustParse(dlQuote.TenantID)
+	q.CreatedAt = dlQuote.CreatedAt
+	q.ExpiresAt = dlQuote.ExpiresAt
+
+	q.Sender = Sender(dlQuote.Sender)
+	q.Receiver = Receiver(dlQuote.Receiver)
+	q.Package = Package(dlQuote.Package)
+
+	if dlQuote.PriceBreakdown != nil {
+		breakdown := PriceBreakdown{}.FromDatalayer(*dlQuote.PriceBreakdown)
+		q.PriceBreakdown = &breakdown
+	}
+
+	q.ExchangeRate = exchangeRateFromDatalayer(dlQuote.ExchangeRate)
+	q.Tax = taxFromDatalayer(dlQuote.Tax)
+	q.Parcels = parcelsFromDatalayer(q.ID, dlQuote.Parcels, q.Package, q.PriceBreakdown)
+
+	return q
+}
@@ -0,0 +1,99 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/lonnblad/shipment-service-backend/storage"
+)
+
+type RateCards []RateCard
+
+// RateCard is a rate card of a tenant, the Document is the
+// YAML or JSON rate card file as it was uploaded.
+type RateCard struct {
+	TenantID      uuid.UUID
+	Version       string
+	EffectiveFrom time.Time
+	UploadedAt    time.Time
+	Document      []byte
+}
+
+// RateCardPreview is how the prices of a sample of shipments
+// would change if they were priced with a rate card.
+type RateCardPreview struct {
+	Version       string
+	EffectiveFrom time.Time
+	Prices        []PricePreview
+}
+
+// PricePreview is the price that the shipment would have with the
+// previewed rate card, in the currency of the shipment, Err is set if
+// the rate card can't price it.
+type PricePreview struct {
+	Shipment       Shipment
+	Price          int
+	PriceBreakdown *PriceBreakdown
+	ExchangeRate   *ExchangeRate
+	Err            error
+}
+
+// Difference will return how much the price of the shipment would change.
+func (p PricePreview) Difference() int {
+	return p.Price - p.Shipment.Package.Price
+}
+
+// Effective will return the rate card that is effective at the time,
+// which is the rate card with the latest effective from date before the
+// time, or the one uploaded last of them. The rate cards have to be
+// ordered by when they were uploaded, ok is false if none is effective.
+func (rcs RateCards) Effective(at time.Time) (_ RateCard, ok bool) {
+	effective := -1
+
+	for idx := range rcs {
+		if rcs[idx].EffectiveFrom.After(at) {
+			continue
+		}
+
+		if effective == -1 || !rcs[idx].EffectiveFrom.Before(rcs[effective].EffectiveFrom) {
+			effective = idx
+		}
+	}
+
+	if effective == -1 {
+		return
+	}
+
+	return rcs[effective], true
+}
+
+func (rc RateCard) ToDatalayer() (dlRateCard storage.RateCard) {
+	dlRateCard.TenantID = rc.TenantID.String()
+	dlRateCard.Version = rc.Version
+	dlRateCard.EffectiveFrom = rc.EffectiveFrom
+	dlRateCard.UploadedAt = rc.UploadedAt
+	dlRateCard.Document = rc.Document
+
+	return
+}
+
+func (rc RateCard) FromDatalayer(dlRateCard storage.RateCard) RateCard {
+	rc.TenantID = uuid.MustParse(dlRateCard.TenantID)
+	rc.Version = dlRateCard.Version
+	rc.EffectiveFrom = dlRateCard.EffectiveFrom
+	rc.UploadedAt = dlRateCard.UploadedAt
+	rc.Document = dlRateCard.Document
+
+	return rc
+}
+
+func (rcs RateCards) FromDatalayer(dlRateCards []storage.RateCard) RateCards {
+	rcs = make(RateCards, len(dlRateCards))
+
+	for idx := range dlRateCards {
+		rcs[idx] = RateCard{}.FromDatalayer(dlRateCards[idx])
+	}
+
+	return rcs
+}
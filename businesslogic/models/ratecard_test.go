@@ -0,0 +1,33 @@
+package models_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lonnblad/shipment-service-backend/businesslogic/models"
+)
+
+func Test_RateCards_Effective(t *testing.T) {
+	now := time.Now()
+
+	rateCards := models.RateCards{
+		{Version: "1", EffectiveFrom: now.Add(-2 * time.Hour)},
+		{Version: "3", EffectiveFrom: now.Add(time.Hour)},
+		{Version: "2", EffectiveFrom: now.Add(-time.Hour)},
+		{Version: "2-fixed", EffectiveFrom: now.Add(-time.Hour)},
+	}
+
+	// The last uploaded of the latest effective rate cards.
+	effective, ok := rateCards.Effective(now)
+	assert.True(t, ok)
+	assert.Equal(t, "2-fixed", effective.Version)
+
+	effective, ok = rateCards.Effective(now.Add(-90 * time.Minute))
+	assert.True(t, ok)
+	assert.Equal(t, "1", effective.Version)
+
+	_, ok = rateCards.Effective(now.Add(-3 * time.Hour))
+	assert.False(t, ok)
+}
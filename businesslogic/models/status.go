@@ -0,0 +1,107 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Status is the step of the lifecycle that a shipment is in.
+type Status string
+
+const (
+	StatusCreated   Status = "created"
+	StatusBooked    Status = "booked"
+	StatusPickedUp  Status = "picked_up"
+	StatusInTransit Status = "in_transit"
+	StatusDelivered Status = "delivered"
+	StatusReturned  Status = "returned"
+	StatusCancelled Status = "cancelled"
+)
+
+// transitions is the state machine of the lifecycle, it maps every
+// status to the statuses that a shipment can transition to from it.
+// Delivered, returned and cancelled are final.
+var transitions = map[Status][]Status{
+	StatusCreated:   {StatusBooked, StatusCancelled},
+	StatusBooked:    {StatusPickedUp, StatusCancelled},
+	StatusPickedUp:  {StatusInTransit, StatusReturned},
+	StatusInTransit: {StatusDelivered, StatusReturned},
+	StatusDelivered: {},
+	StatusReturned:  {},
+	StatusCancelled: {},
+}
+
+// TransitionError is returned by Transition when the shipment
+// can't transition from its status to the requested status.
+type TransitionError struct {
+	From Status
+	To   Status
+}
+
+func (te TransitionError) Error() string {
+	return fmt.Sprintf("a shipment can't transition from: %s to: %s", te.From, te.To)
+}
+
+// Validate will return a ValidationError if the status is unknown.
+func (s Status) Validate() error {
+	if _, ok := transitions[s]; !ok {
+		return ValidationError{Err: fmt.Errorf("unknown status: %s, supported statuses are: %s", s, joinStatuses(statuses))}
+	}
+
+	return nil
+}
+
+// Transition will return a TransitionError if the
+// state machine doesn't allow the transition.
+func (s Status) Transition(to Status) error {
+	if err := to.Validate(); err != nil {
+		return err
+	}
+
+	for _, next := range transitions[s] {
+		if next == to {
+			return nil
+		}
+	}
+
+	return TransitionError{From: s, To: to}
+}
+
+// editableStatuses are the statuses where the shipment is still in the
+// warehouse, the details can't be edited once it has been picked up.
+var editableStatuses = []Status{StatusCreated, StatusBooked}
+
+// NotEditableError is returned by Editable when the
+// details of a shipment in the status can't be edited.
+type NotEditableError struct{ Status Status }
+
+func (nee NotEditableError) Error() string {
+	return fmt.Sprintf("a shipment with status: %s can't be edited, it has left the warehouse", nee.Status)
+}
+
+// Editable will return a NotEditableError if the details
+// of a shipment in the status can't be edited.
+func (s Status) Editable() error {
+	for _, editable := range editableStatuses {
+		if editable == s {
+			return nil
+		}
+	}
+
+	return NotEditableError{Status: s}
+}
+
+// statuses is every status in the order of the lifecycle.
+var statuses = []Status{
+	StatusCreated, StatusBooked, StatusPickedUp, StatusInTransit,
+	StatusDelivered, StatusReturned, StatusCancelled,
+}
+
+func joinStatuses(statuses []Status) string {
+	strs := make([]string, len(statuses))
+	for idx, status := range statuses {
+		strs[idx] = string(status)
+	}
+
+	return strings.Join(strs, ", ")
+}
@@ -0,0 +1,57 @@
+package models_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lonnblad/shipment-service-backend/businesslogic/models"
+)
+
+func Test_StatusTransition(t *testing.T) {
+	tcs := []struct {
+		from, to models.Status
+		allowed  bool
+	}{
+		{from: models.StatusCreated, to: models.StatusBooked, allowed: true},
+		{from: models.StatusCreated, to: models.StatusCancelled, allowed: true},
+		{from: models.StatusCreated, to: models.StatusPickedUp, allowed: false},
+		{from: models.StatusBooked, to: models.StatusPickedUp, allowed: true},
+		{from: models.StatusBooked, to: models.StatusCancelled, allowed: true},
+		{from: models.StatusPickedUp, to: models.StatusInTransit, allowed: true},
+		{from: models.StatusPickedUp, to: models.StatusCancelled, allowed: false},
+		{from: models.StatusInTransit, to: models.StatusDelivered, allowed: true},
+		{from: models.StatusInTransit, to: models.StatusReturned, allowed: true},
+		{from: models.StatusDelivered, to: models.StatusReturned, allowed: false},
+		{from: models.StatusCancelled, to: models.StatusBooked, allowed: false},
+		{from: models.StatusBooked, to: models.StatusBooked, allowed: false},
+	}
+
+	for _, tc := range tcs {
+		err := tc.from.Transition(tc.to)
+
+		if tc.allowed {
+			assert.NoError(t, err, "%s to %s", tc.from, tc.to)
+			continue
+		}
+
+		assert.Equal(t, models.TransitionError{From: tc.from, To: tc.to}, err)
+	}
+}
+
+func Test_StatusTransition_UnknownStatus(t *testing.T) {
+	err := models.StatusCreated.Transition("lost")
+	assert.ErrorAs(t, err, &models.ValidationError{})
+}
+
+func Test_StatusEditable(t *testing.T) {
+	for _, status := range []models.Status{models.StatusCreated, models.StatusBooked} {
+		assert.NoError(t, status.Editable(), "%s", status)
+	}
+
+	for _, status := range []models.Status{
+		models.StatusPickedUp, models.StatusInTransit, models.StatusDelivered, models.StatusReturned, models.StatusCancelled,
+	} {
+		assert.Equal(t, models.NotEditableError{Status: status}, status.Editable())
+	}
+}
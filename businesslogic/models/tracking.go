@@ -0,0 +1,95 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/lonnblad/shipment-service-backend/storage"
+)
+
+const (
+	maxLengthTrackingEventLocation    = 100
+	maxLengthTrackingEventDescription = 200
+	maxLengthTrackingEventSource      = 50
+)
+
+type TrackingEvents []TrackingEvent
+
+// TrackingEvent is an event in the tracking timeline of a shipment,
+// the Source is who reported the event, like a carrier or a scanner.
+type TrackingEvent struct {
+	ID          uuid.UUID
+	TenantID    uuid.UUID
+	ShipmentID  uuid.UUID
+	OccurredAt  time.Time
+	Location    string
+	Description string
+	Source      string
+}
+
+// Validate will return a ValidationError if the tracking event is invalid.
+func (e TrackingEvent) Validate() error {
+	if e.Description == "" {
+		return ValidationError{Err: fmt.Errorf("tracking event description is required")}
+	}
+
+	if len(e.Description) > maxLengthTrackingEventDescription {
+		return ValidationError{Err: fmt.Errorf(
+			"tracking event description is longer than max length: %d", maxLengthTrackingEventDescription,
+		)}
+	}
+
+	if len(e.Location) > maxLengthTrackingEventLocation {
+		return ValidationError{Err: fmt.Errorf(
+			"tracking event location: %s is longer than max length: %d", e.Location, maxLengthTrackingEventLocation,
+		)}
+	}
+
+	if e.Source == "" {
+		return ValidationError{Err: fmt.Errorf("tracking event source is required")}
+	}
+
+	if len(e.Source) > maxLengthTrackingEventSource {
+		return ValidationError{Err: fmt.Errorf(
+			"tracking event source: %s is longer than max length: %d", e.Source, maxLengthTrackingEventSource,
+		)}
+	}
+
+	return nil
+}
+
+func (e TrackingEvent) ToDatalayer() (dlEvent storage.TrackingEvent) {
+	dlEvent.ID = e.ID.String()
+	dlEvent.TenantID = e.TenantID.String()
+	dlEvent.ShipmentID = e.ShipmentID.String()
+	dlEvent.OccurredAt = e.OccurredAt
+	dlEvent.Location = e.Location
+	dlEvent.Description = e.Description
+	dlEvent.Source = e.Source
+
+	return
+}
+
+func (e TrackingEvent) FromDatalayer(dlEvent storage.TrackingEvent) TrackingEvent {
+	e.ID = uuid.MustParse(dlEvent.ID)
+	e.TenantID = uuid.MustParse(dlEvent.TenantID)
+	e.ShipmentID = uuid.MustParse(dlEvent.ShipmentID)
+	e.OccurredAt = dlEvent.OccurredAt
+	e.Location = dlEvent.Location
+	e.Description = dlEvent.Description
+	e.Source = dlEvent.Source
+
+	return e
+}
+
+func (e TrackingEvents) FromDatalayer(dlEvents []storage.TrackingEvent) TrackingEvents {
+	e = make(TrackingEvents, len(dlEvents))
+
+	for idx := range dlEvents {
+		e[idx] = TrackingEvent{}.FromDatalayer(dlEvents[idx])
+	}
+
+	return e
+}
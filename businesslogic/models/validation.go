@@ -13,26 +13,59 @@ const (
 	maxLengthName           = 30
 	maxLengthAddress        = 100
 	minPackageWeight        = 0
-	maxPackageWeight        = 1000
+	maxPackageWeight        = 1000000
+	minPackageDimension     = 1
+	maxPackageDimension     = 300
+	maxParcels              = 99
 )
 
 var (
 	regexpNameValidatorNumbers = regexp.MustCompile("[0-9]+")
-	countries                  = gountries.New()
+	// regexpVATNumber is the format of an EU VAT identification number,
+	// which is the country prefix followed by 2 to 13 digits or letters.
+	regexpVATNumber = regexp.MustCompile("^[A-Z]{2}[0-9A-Z+*]{2,13}$")
+	countries       = gountries.New()
 )
 
-// Validate ...
+// ValidationError is returned by Validate when the shipment is invalid.
+type ValidationError struct{ Err error }
+
+func (ve ValidationError) Error() string {
+	return ve.Err.Error()
+}
+
+func (ve ValidationError) Unwrap() error {
+	return ve.Err
+}
+
+// Validate will return a ValidationError if the shipment is invalid.
 func (s Shipment) Validate() error {
 	if err := s.Sender.validate(); err != nil {
-		return fmt.Errorf("failed to validate sender: %w", err)
+		return ValidationError{Err: fmt.Errorf("failed to validate sender: %w", err)}
 	}
 
 	if err := s.Receiver.validate(); err != nil {
-		return fmt.Errorf("failed to validate receiver: %w", err)
+		return ValidationError{Err: fmt.Errorf("failed to validate receiver: %w", err)}
 	}
 
-	if err := s.Package.validate(); err != nil {
-		return fmt.Errorf("failed to validate package: %w", err)
+	// The package is the only parcel, or the total weight of the
+	// parcels, which can be above the maximum weight of a parcel.
+	if len(s.Parcels) <= 1 {
+		if err := s.Package.validate(); err != nil {
+			return ValidationError{Err: fmt.Errorf("failed to validate package: %w", err)}
+		}
+
+		return nil
+	}
+
+	if len(s.Parcels) > maxParcels {
+		return ValidationError{Err: fmt.Errorf("a shipment can't have more than: %d parcels", maxParcels)}
+	}
+
+	for idx, parcel := range s.Parcels {
+		if err := parcel.validate(); err != nil {
+			return ValidationError{Err: fmt.Errorf("failed to validate parcel: %d: %w", idx+1, err)}
+		}
 	}
 
 	return nil
@@ -55,6 +88,10 @@ func (s Sender) validate() error {
 		return fmt.Errorf("sender country code is invalid: %w", err)
 	}
 
+	if s.VATNumber != "" && !regexpVATNumber.MatchString(s.VATNumber) {
+		return fmt.Errorf("sender VAT number: %s is not valid", s.VATNumber)
+	}
+
 	return nil
 }
 
@@ -80,11 +117,30 @@ func (r Receiver) validate() error {
 
 func (p Package) validate() error {
 	if p.Weight < minPackageWeight {
-		return fmt.Errorf("package weight: %d can't be below minimum: %d", p.Weight, minPackageWeight)
+		return fmt.Errorf("package weight: %d g can't be below minimum: %d g", p.Weight, minPackageWeight)
 	}
 
 	if p.Weight > maxPackageWeight {
-		return fmt.Errorf("package weight: %d can't be above maximum: %d", p.Weight, maxPackageWeight)
+		return fmt.Errorf("package weight: %d g can't be above maximum: %d g", p.Weight, maxPackageWeight)
+	}
+
+	// The dimensions are optional, but all of them are required when one is given.
+	if !p.HasDimensions() {
+		return nil
+	}
+
+	dimensions := []struct {
+		name  string
+		value int
+	}{{"length", p.Length}, {"width", p.Width}, {"height", p.Height}}
+
+	for _, dimension := range dimensions {
+		if dimension.value < minPackageDimension || dimension.value > maxPackageDimension {
+			return fmt.Errorf(
+				"package %s: %d cm needs to be between: %d and: %d cm",
+				dimension.name, dimension.value, minPackageDimension, maxPackageDimension,
+			)
+		}
 	}
 
 	return nil
@@ -0,0 +1,127 @@
+package price
+
+import (
+	"fmt"
+
+	"github.com/lonnblad/shipment-service-backend/businesslogic/models"
+)
+
+const (
+	// - Cancelled before it is booked: no fee
+	cancellationFeePercentCreated = 0
+	cancellationFeeMinimumCreated = 0
+
+	// - Cancelled after it is booked: 10% of the price, at least 20 of the currency of the rate card, e.g. 20 SEK
+	cancellationFeePercentBooked = 10
+	cancellationFeeMinimumBooked = 20
+
+	percentDivisor = 100
+)
+
+// CancellationFeeRule is the fee for cancelling a shipment in the Status,
+// which is the Percent of the price, but at least the Minimum, which is in
+// whole units of the currency of the rate card that priced the shipment.
+// The Minimum is converted to the currency of the shipment with the
+// exchange rate that the price of the shipment was converted with.
+type CancellationFeeRule struct {
+	Status  models.Status
+	Percent int
+	Minimum int
+}
+
+// CancellationFeeRules are the fees for cancelling a shipment, there
+// has to be a rule for every status that a shipment can be cancelled in.
+type CancellationFeeRules []CancellationFeeRule
+
+// DefaultCancellationFeeRules will return the cancellation fee rules
+// used when no other rules are configured.
+func DefaultCancellationFeeRules() CancellationFeeRules {
+	return CancellationFeeRules{
+		{Status: models.StatusCreated, Percent: cancellationFeePercentCreated, Minimum: cancellationFeeMinimumCreated},
+		{Status: models.StatusBooked, Percent: cancellationFeePercentBooked, Minimum: cancellationFeeMinimumBooked},
+	}
+}
+
+// Validate will return an error if a rule has a percent outside
+// of 0 to 100 or a negative minimum, or if a status has two rules.
+func (r CancellationFeeRules) Validate() error {
+	statuses := map[models.Status]bool{}
+
+	for _, rule := range r {
+		if rule.Percent < 0 || rule.Percent > percentDivisor {
+			return fmt.Errorf("cancellation fee percent: %d of status: %s is not between 0 and 100", rule.Percent, rule.Status)
+		}
+
+		if rule.Minimum < 0 {
+			return fmt.Errorf("cancellation fee minimum: %d of status: %s is negative", rule.Minimum, rule.Status)
+		}
+
+		if statuses[rule.Status] {
+			return fmt.Errorf("status: %s has more than one cancellation fee rule", rule.Status)
+		}
+
+		statuses[rule.Status] = true
+	}
+
+	return nil
+}
+
+// CancellationFeeRuleError will be returned by CalculateRefund when
+// there isn't a cancellation fee rule for the status of the shipment.
+type CancellationFeeRuleError struct{ Status models.Status }
+
+func (cfre CancellationFeeRuleError) Error() string {
+	return fmt.Sprintf("status: %s doesn't have a defined cancellation fee", cfre.Status)
+}
+
+// CalculateRefund will return the fee that is kept and the amount of the
+// price that is refunded when the shipment is cancelled in its status.
+//
+// The fee is rounded down to the closest minor unit and is never
+// more than the price, which makes the refund never negative.
+func (r CancellationFeeRules) CalculateRefund(s models.Shipment) (fee, refund int, err error) {
+	for _, rule := range r {
+		if rule.Status != s.Status {
+			continue
+		}
+
+		var minimum int
+
+		if minimum, err = rule.minimum(s); err != nil {
+			return
+		}
+
+		fee = s.Package.Price * rule.Percent / percentDivisor
+
+		if fee < minimum {
+			fee = minimum
+		}
+
+		if fee > s.Package.Price {
+			fee = s.Package.Price
+		}
+
+		return fee, s.Package.Price - fee, nil
+	}
+
+	err = CancellationFeeRuleError{Status: s.Status}
+
+	return
+}
+
+// minimum will return the minimum fee in the minor units of the currency of
+// the shipment. A shipment without an exchange rate is priced in the
+// currency of the rate card.
+func (rule CancellationFeeRule) minimum(s models.Shipment) (_ int, err error) {
+	if s.ExchangeRate == nil {
+		units, err := MinorUnits(s.Package.Currency)
+		return rule.Minimum * units, err
+	}
+
+	units, err := MinorUnits(s.ExchangeRate.From)
+	if err != nil {
+		return
+	}
+
+	return ConvertWith(rule.Minimum*units, *s.ExchangeRate)
+}
@@ -0,0 +1,81 @@
+package price_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lonnblad/shipment-service-backend/businesslogic/models"
+	"github.com/lonnblad/shipment-service-backend/businesslogic/price"
+)
+
+func Test_CalculateRefund(t *testing.T) {
+	rules := price.DefaultCancellationFeeRules()
+	sekToEUR := models.ExchangeRate{From: "SEK", To: "EUR", Rate: 87500, Rounding: string(price.RoundHalfUp)}
+	sekToJPY := models.ExchangeRate{From: "SEK", To: "JPY", Rate: 14200000, Rounding: string(price.RoundHalfUp)}
+
+	tcs := []struct {
+		name           string
+		status         models.Status
+		price          int
+		currency       string
+		exchangeRate   *models.ExchangeRate
+		expectedFee    int
+		expectedRefund int
+		expectedError  error
+	}{
+		{name: "Created", status: models.StatusCreated, price: 50000, currency: "SEK", expectedFee: 0, expectedRefund: 50000},
+		{name: "Booked/Percent", status: models.StatusBooked, price: 50000, currency: "SEK", expectedFee: 5000, expectedRefund: 45000},
+		{name: "Booked/Minimum", status: models.StatusBooked, price: 15000, currency: "SEK", expectedFee: 2000, expectedRefund: 13000},
+		{name: "Booked/MinimumInJPY", status: models.StatusBooked, price: 150, currency: "JPY", expectedFee: 20, expectedRefund: 130},
+		{
+			name: "Booked/MinimumConvertedToEUR", status: models.StatusBooked, price: 1500, currency: "EUR", exchangeRate: &sekToEUR,
+			expectedFee: 175, expectedRefund: 1325,
+		},
+		{
+			name: "Booked/MinimumConvertedToJPY", status: models.StatusBooked, price: 1500, currency: "JPY", exchangeRate: &sekToJPY,
+			expectedFee: 284, expectedRefund: 1216,
+		},
+		{
+			name: "Booked/PercentInEUR", status: models.StatusBooked, price: 10000, currency: "EUR", exchangeRate: &sekToEUR,
+			expectedFee: 1000, expectedRefund: 9000,
+		},
+		{name: "Booked/RoundedDown", status: models.StatusBooked, price: 22555, currency: "SEK", expectedFee: 2255, expectedRefund: 20300},
+		{name: "Booked/FeeAbovePrice", status: models.StatusBooked, price: 1000, currency: "SEK", expectedFee: 1000, expectedRefund: 0},
+		{
+			name: "PickedUp", status: models.StatusPickedUp, price: 10000, currency: "SEK",
+			expectedError: price.CancellationFeeRuleError{Status: models.StatusPickedUp},
+		},
+		{
+			name: "UnsupportedCurrency", status: models.StatusBooked, price: 10000, currency: "XXX",
+			expectedError: price.CurrencyError{Currency: "XXX"},
+		},
+	}
+
+	for _, tc := range tcs {
+		shipment := models.Shipment{
+			Status:       tc.status,
+			Package:      models.Package{Price: tc.price, Currency: tc.currency},
+			ExchangeRate: tc.exchangeRate,
+		}
+
+		fee, refund, err := rules.CalculateRefund(shipment)
+
+		assert.Equal(t, tc.expectedError, err, tc.name)
+		assert.Equal(t, tc.expectedFee, fee, tc.name)
+		assert.Equal(t, tc.expectedRefund, refund, tc.name)
+	}
+}
+
+func Test_CancellationFeeRules_Validate(t *testing.T) {
+	assert.NoError(t, price.DefaultCancellationFeeRules().Validate())
+
+	for _, rules := range []price.CancellationFeeRules{
+		{{Status: models.StatusBooked, Percent: 101}},
+		{{Status: models.StatusBooked, Percent: -1}},
+		{{Status: models.StatusBooked, Minimum: -1}},
+		{{Status: models.StatusBooked}, {Status: models.StatusBooked}},
+	} {
+		assert.Error(t, rules.Validate())
+	}
+}
@@ -0,0 +1,186 @@
+package price
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lonnblad/shipment-service-backend/businesslogic/models"
+)
+
+// ExchangeRateScale is what exchange rates are multiplied by to remove
+// the need of using a floating point, e.g. a rate of 0.0875 is 87500.
+const ExchangeRateScale = 1000000
+
+// minorUnits is the number of minor units in a unit of the supported
+// ISO 4217 currencies, e.g. there are 100 öre in a krona.
+var minorUnits = map[string]int{
+	"AUD": 100, "CAD": 100, "CHF": 100, "CNY": 100, "CZK": 100, "DKK": 100, "EUR": 100, "GBP": 100,
+	"HUF": 100, "ISK": 1, "JPY": 1, "NOK": 100, "PLN": 100, "SEK": 100, "USD": 100,
+}
+
+// MinorUnits will return the number of minor units in a unit of the
+// currency, a CurrencyError is returned if it isn't supported.
+func MinorUnits(currency string) (_ int, err error) {
+	units, ok := minorUnits[currency]
+	if !ok {
+		err = CurrencyError{Currency: currency}
+		return
+	}
+
+	return units, nil
+}
+
+// Rounding is how a converted amount is rounded to a minor unit.
+type Rounding string
+
+const (
+	// RoundHalfUp rounds to the closest minor unit and halves away from zero.
+	RoundHalfUp Rounding = "half-up"
+	// RoundHalfEven rounds to the closest minor unit and halves to the even one.
+	RoundHalfEven Rounding = "half-even"
+	// RoundDown rounds towards zero.
+	RoundDown Rounding = "down"
+	// RoundUp rounds away from zero.
+	RoundUp Rounding = "up"
+)
+
+// Validate will return an error if the rounding isn't supported.
+func (r Rounding) Validate() error {
+	switch r {
+	case RoundHalfUp, RoundHalfEven, RoundDown, RoundUp:
+		return nil
+	default:
+		return fmt.Errorf("rounding: %s needs to be one of %s, %s, %s, %s", r, RoundHalfUp, RoundHalfEven, RoundDown, RoundUp)
+	}
+}
+
+// divide will return the numerator divided by the positive denominator,
+// rounded to an integer.
+func (r Rounding) divide(numerator, denominator int64) int64 {
+	quotient, remainder := numerator/denominator, numerator%denominator
+	if remainder == 0 {
+		return quotient
+	}
+
+	sign := int64(1)
+
+	if numerator < 0 {
+		sign, remainder = -1, -remainder
+	}
+
+	switch r {
+	case RoundDown:
+		return quotient
+	case RoundUp:
+		return quotient + sign
+	case RoundHalfEven:
+		if 2*remainder > denominator || (2*remainder == denominator && quotient%2 != 0) {
+			return quotient + sign
+		}
+
+		return quotient
+	default:
+		if 2*remainder >= denominator {
+			return quotient + sign
+		}
+
+		return quotient
+	}
+}
+
+// ExchangeRateProvider provides the exchange rates that prices are converted
+// with. The rate is the amount of the to currency that a unit of the from
+// currency was worth at the time, multiplied by ExchangeRateScale.
+type ExchangeRateProvider interface {
+	ExchangeRate(ctx context.Context, from, to string, at time.Time) (rate int64, err error)
+}
+
+// Converter converts amounts between currencies with the exchange rates
+// of the Provider, a converted amount is rounded with the Rounding.
+type Converter struct {
+	Provider ExchangeRateProvider
+	Rounding Rounding
+}
+
+// DefaultConverter will return the converter which is used when no exchange
+// rates are configured, it can only price in the currency of the rate card.
+func DefaultConverter() Converter {
+	return Converter{Rounding: RoundHalfUp}
+}
+
+// Convert will convert the amount in minor units of the from currency to
+// minor units of the to currency, with the exchange rate at the time. The
+// exchange rate is nil when the currencies are the same.
+func (c Converter) Convert(
+	ctx context.Context, amount int, from, to string, at time.Time,
+) (_ int, _ *models.ExchangeRate, err error) {
+	fromUnits, err := MinorUnits(from)
+	if err != nil {
+		return
+	}
+
+	toUnits, err := MinorUnits(to)
+	if err != nil {
+		return
+	}
+
+	if from == to {
+		return amount, nil, nil
+	}
+
+	if c.Provider == nil {
+		err = ExchangeRateError{From: from, To: to}
+		return
+	}
+
+	rate, err := c.Provider.ExchangeRate(ctx, from, to, at)
+	if err != nil {
+		return
+	}
+
+	exchangeRate := models.ExchangeRate{From: from, To: to, Rate: rate, Rounding: string(c.Rounding)}
+
+	return convertWith(amount, exchangeRate, fromUnits, toUnits), &exchangeRate, nil
+}
+
+// ConvertWith will convert the amount in minor units of the from currency of
+// the exchange rate to minor units of its to currency, which converts an
+// amount in the same way as the price that was converted with the rate.
+func ConvertWith(amount int, exchangeRate models.ExchangeRate) (_ int, err error) {
+	fromUnits, err := MinorUnits(exchangeRate.From)
+	if err != nil {
+		return
+	}
+
+	toUnits, err := MinorUnits(exchangeRate.To)
+	if err != nil {
+		return
+	}
+
+	return convertWith(amount, exchangeRate, fromUnits, toUnits), nil
+}
+
+func convertWith(amount int, exchangeRate models.ExchangeRate, fromUnits, toUnits int) int {
+	rounding := Rounding(exchangeRate.Rounding)
+	converted := rounding.divide(int64(amount)*exchangeRate.Rate*int64(toUnits), ExchangeRateScale*int64(fromUnits))
+
+	return int(converted)
+}
+
+type (
+	// CurrencyError will be returned when the currency isn't supported.
+	CurrencyError struct{ Currency string }
+
+	// ExchangeRateError will be returned when there isn't an
+	// exchange rate from the one currency to the other.
+	ExchangeRateError struct{ From, To string }
+)
+
+func (ce CurrencyError) Error() string {
+	return fmt.Sprintf("currency: %s is not supported", ce.Currency)
+}
+
+func (ere ExchangeRateError) Error() string {
+	return fmt.Sprintf("there is no exchange rate from: %s to: %s", ere.From, ere.To)
+}
@@ -0,0 +1,113 @@
+package price_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lonnblad/shipment-service-backend/businesslogic/models"
+	"github.com/lonnblad/shipment-service-backend/businesslogic/price"
+)
+
+const validExchangeRates = `
+base: SEK
+rates:
+  EUR: 0.0875
+  USD: 0.095
+  JPY: 10.5
+`
+
+func Test_Converter_Convert(t *testing.T) {
+	exchangeRates, err := price.ParseStaticExchangeRates([]byte(validExchangeRates))
+	require.NoError(t, err)
+
+	tcs := []struct {
+		name           string
+		amount         int
+		from, to       string
+		rounding       price.Rounding
+		expectedAmount int
+		expectedRate   int64
+	}{
+		{name: "SEK/EUR", amount: 10000, from: "SEK", to: "EUR", rounding: price.RoundHalfUp, expectedAmount: 875, expectedRate: 87500},
+		{name: "EUR/SEK", amount: 875, from: "EUR", to: "SEK", rounding: price.RoundHalfUp, expectedAmount: 10000, expectedRate: 11428571},
+		{name: "SEK/JPY", amount: 10000, from: "SEK", to: "JPY", rounding: price.RoundHalfUp, expectedAmount: 1050, expectedRate: 10500000},
+		{name: "EUR/USD", amount: 10000, from: "EUR", to: "USD", rounding: price.RoundHalfUp, expectedAmount: 10857, expectedRate: 1085714},
+		{name: "HalfUp", amount: 10, from: "SEK", to: "USD", rounding: price.RoundHalfUp, expectedAmount: 1, expectedRate: 95000},
+		{name: "HalfEven", amount: 10, from: "SEK", to: "USD", rounding: price.RoundHalfEven, expectedAmount: 1, expectedRate: 95000},
+		{name: "HalfEven/Half", amount: 100, from: "SEK", to: "JPY", rounding: price.RoundHalfEven, expectedAmount: 10, expectedRate: 10500000},
+		{name: "HalfUp/Half", amount: 100, from: "SEK", to: "JPY", rounding: price.RoundHalfUp, expectedAmount: 11, expectedRate: 10500000},
+		{name: "Down", amount: 10, from: "SEK", to: "USD", rounding: price.RoundDown, expectedAmount: 0, expectedRate: 95000},
+		{name: "Up", amount: 1, from: "SEK", to: "EUR", rounding: price.RoundUp, expectedAmount: 1, expectedRate: 87500},
+		{name: "Negative", amount: -100, from: "SEK", to: "JPY", rounding: price.RoundHalfUp, expectedAmount: -11, expectedRate: 10500000},
+	}
+
+	for _, tc := range tcs {
+		converter := price.Converter{Provider: exchangeRates, Rounding: tc.rounding}
+
+		amount, rate, err := converter.Convert(context.Background(), tc.amount, tc.from, tc.to, createdAt)
+		require.NoError(t, err, tc.name)
+		assert.Equal(t, tc.expectedAmount, amount, tc.name)
+		assert.Equal(t, &models.ExchangeRate{From: tc.from, To: tc.to, Rate: tc.expectedRate, Rounding: string(tc.rounding)}, rate, tc.name)
+	}
+}
+
+func Test_Converter_Convert_Errors(t *testing.T) {
+	ctx := context.Background()
+
+	// The same currency is never converted.
+	amount, rate, err := price.DefaultConverter().Convert(ctx, 10000, "SEK", "SEK", createdAt)
+	require.NoError(t, err)
+	assert.Equal(t, 10000, amount)
+	assert.Nil(t, rate)
+
+	_, _, err = price.DefaultConverter().Convert(ctx, 10000, "SEK", "EUR", createdAt)
+	assert.Equal(t, price.ExchangeRateError{From: "SEK", To: "EUR"}, err)
+
+	_, _, err = price.DefaultConverter().Convert(ctx, 10000, "SEK", "XXX", createdAt)
+	assert.Equal(t, price.CurrencyError{Currency: "XXX"}, err)
+
+	exchangeRates, err := price.ParseStaticExchangeRates([]byte(validExchangeRates))
+	require.NoError(t, err)
+
+	converter := price.Converter{Provider: exchangeRates, Rounding: price.RoundHalfUp}
+
+	_, _, err = converter.Convert(ctx, 10000, "SEK", "GBP", createdAt)
+	assert.Equal(t, price.ExchangeRateError{From: "SEK", To: "GBP"}, err)
+}
+
+func Test_Apply(t *testing.T) {
+	exchangeRates, err := price.ParseStaticExchangeRates([]byte(validExchangeRates))
+	require.NoError(t, err)
+
+	converter := price.Converter{Provider: exchangeRates, Rounding: price.RoundHalfUp}
+
+	shipment := models.Shipment{CreatedAt: createdAt}
+	shipment.Sender.CountryCode = "DE"
+	shipment.Package.Weight = 10
+
+	// The default currency of the rate card is used.
+	priced, err := price.Apply(context.Background(), price.DefaultRateCards(), converter, shipment)
+	require.NoError(t, err)
+	assert.Equal(t, models.Package{Weight: 10, Price: 15000, Currency: "SEK"}, priced.Package)
+	assert.Equal(t, "2021-01-01", priced.RateCardVersion)
+	assert.Nil(t, priced.ExchangeRate)
+
+	shipment.Package.Currency = "EUR"
+
+	priced, err = price.Apply(context.Background(), price.DefaultRateCards(), converter, shipment)
+	require.NoError(t, err)
+	assert.Equal(t, models.Package{Weight: 10, Price: 1313, Currency: "EUR"}, priced.Package)
+	assert.Equal(t, 15000, priced.PriceBreakdown.Price)
+	assert.Equal(t, &models.ExchangeRate{From: "SEK", To: "EUR", Rate: 87500, Rounding: "half-up"}, priced.ExchangeRate)
+}
+
+func Test_Rounding_Validate(t *testing.T) {
+	for _, rounding := range []price.Rounding{price.RoundHalfUp, price.RoundHalfEven, price.RoundDown, price.RoundUp} {
+		assert.NoError(t, rounding.Validate())
+	}
+
+	assert.Error(t, price.Rounding("ceiling").Validate())
+}
@@ -0,0 +1,130 @@
+package price
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// rateDecimals is the max number of decimals of an exchange rate.
+const rateDecimals = 6
+
+var _ ExchangeRateProvider = &StaticExchangeRates{}
+
+// StaticExchangeRates are exchange rates that don't change over time, which
+// are loaded from a YAML or JSON file with what a unit of the base currency
+// is worth in the other currencies:
+//
+//	base: SEK
+//	rates:
+//	  EUR: 0.0875
+//	  USD: 0.0952
+//
+// The rate between two currencies that are not the base
+// currency is crossed through the base currency.
+type StaticExchangeRates struct {
+	Base  string          `yaml:"base"`
+	Rates map[string]Rate `yaml:"rates"`
+}
+
+// Rate is an exchange rate multiplied by ExchangeRateScale, which
+// is parsed from a decimal without the use of a floating point.
+type Rate int64
+
+// LoadStaticExchangeRates will read and validate the
+// exchange rates in the YAML or JSON file.
+func LoadStaticExchangeRates(path string) (_ *StaticExchangeRates, err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		err = fmt.Errorf("failed to read exchange rates: %w", err)
+		return
+	}
+
+	return ParseStaticExchangeRates(data)
+}
+
+// ParseStaticExchangeRates will parse and validate YAML or JSON exchange rates.
+func ParseStaticExchangeRates(data []byte) (_ *StaticExchangeRates, err error) {
+	var rates StaticExchangeRates
+
+	if err = yaml.Unmarshal(data, &rates); err != nil {
+		err = fmt.Errorf("failed to parse exchange rates: %w", err)
+		return
+	}
+
+	if err = rates.Validate(); err != nil {
+		err = fmt.Errorf("exchange rates are invalid: %w", err)
+		return
+	}
+
+	return &rates, nil
+}
+
+// Validate will return an error if a currency isn't supported.
+func (s StaticExchangeRates) Validate() error {
+	if _, err := MinorUnits(s.Base); err != nil {
+		return fmt.Errorf("base: %w", err)
+	}
+
+	for currency := range s.Rates {
+		if _, err := MinorUnits(currency); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ExchangeRate will return the rate from the one currency to the other,
+// a rate that is crossed through the base currency is rounded half up.
+func (s *StaticExchangeRates) ExchangeRate(_ context.Context, from, to string, _ time.Time) (_ int64, err error) {
+	fromRate, fromOK := s.rate(from)
+	toRate, toOK := s.rate(to)
+
+	if !fromOK || !toOK {
+		err = ExchangeRateError{From: from, To: to}
+		return
+	}
+
+	return RoundHalfUp.divide(toRate*ExchangeRateScale, fromRate), nil
+}
+
+// rate will return the rate from the base currency to the currency.
+func (s *StaticExchangeRates) rate(currency string) (int64, bool) {
+	if currency == s.Base {
+		return ExchangeRateScale, true
+	}
+
+	rate, ok := s.Rates[currency]
+
+	return int64(rate), ok
+}
+
+// UnmarshalYAML will parse a positive decimal with at most six decimals.
+func (r *Rate) UnmarshalYAML(node *yaml.Node) error {
+	integer, fraction := node.Value, ""
+
+	if idx := strings.Index(node.Value, "."); idx >= 0 {
+		integer, fraction = node.Value[:idx], node.Value[idx+1:]
+	}
+
+	if len(fraction) > rateDecimals {
+		return fmt.Errorf("exchange rate: %s has more than %d decimals", node.Value, rateDecimals)
+	}
+
+	fraction += strings.Repeat("0", rateDecimals-len(fraction))
+
+	rate, err := strconv.ParseUint(integer+fraction, 10, 63)
+	if err != nil || rate == 0 {
+		return fmt.Errorf("exchange rate: %s is not a positive decimal", node.Value)
+	}
+
+	*r = Rate(rate)
+
+	return nil
+}
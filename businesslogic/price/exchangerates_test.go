@@ -0,0 +1,66 @@
+package price_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lonnblad/shipment-service-backend/businesslogic/price"
+)
+
+func Test_StaticExchangeRates(t *testing.T) {
+	exchangeRates, err := price.ParseStaticExchangeRates([]byte(validExchangeRates))
+	require.NoError(t, err)
+
+	for pair, expected := range map[string]int64{
+		"SEK/SEK": 1000000,
+		"SEK/EUR": 87500,
+		"EUR/SEK": 11428571,
+		"USD/JPY": 110526316,
+	} {
+		currencies := strings.Split(pair, "/")
+
+		rate, rateErr := exchangeRates.ExchangeRate(context.Background(), currencies[0], currencies[1], createdAt)
+		require.NoError(t, rateErr, pair)
+		assert.Equal(t, expected, rate, pair)
+	}
+
+	_, err = exchangeRates.ExchangeRate(context.Background(), "SEK", "GBP", createdAt)
+	assert.Equal(t, price.ExchangeRateError{From: "SEK", To: "GBP"}, err)
+}
+
+func Test_LoadStaticExchangeRates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "exchange-rates.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"base": "EUR", "rates": {"SEK": 11.43}}`), 0o600))
+
+	exchangeRates, err := price.LoadStaticExchangeRates(path)
+	require.NoError(t, err)
+	assert.Equal(t, price.Rate(11430000), exchangeRates.Rates["SEK"])
+
+	_, err = price.LoadStaticExchangeRates(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.Error(t, err)
+}
+
+func Test_ParseStaticExchangeRates_Invalid(t *testing.T) {
+	for name, exchangeRates := range map[string]string{
+		"UnknownBase":     strings.Replace(validExchangeRates, `base: SEK`, `base: XXX`, 1),
+		"UnknownCurrency": strings.Replace(validExchangeRates, `EUR:`, `XXX:`, 1),
+		"SevenDecimals":   strings.Replace(validExchangeRates, `0.0875`, `0.0875001`, 1),
+		"Zero":            strings.Replace(validExchangeRates, `0.0875`, `0`, 1),
+		"Negative":        strings.Replace(validExchangeRates, `0.0875`, `-0.0875`, 1),
+		"NotANumber":      strings.Replace(validExchangeRates, `0.0875`, `cheap`, 1),
+		"NotYAML":         "{",
+	} {
+		exchangeRates := exchangeRates
+
+		t.Run(name, func(t *testing.T) {
+			_, err := price.ParseStaticExchangeRates([]byte(exchangeRates))
+			assert.Error(t, err)
+		})
+	}
+}
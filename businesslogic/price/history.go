@@ -0,0 +1,173 @@
+package price
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed ratecards
+var defaultRateCardFiles embed.FS
+
+var defaultRateCards = mustParseRateCards(defaultRateCardFiles, "ratecards")
+
+// RateCards is a dated history of rate cards, ordered by the date that they
+// are effective from. A shipment is priced with the rate card that was
+// effective when it was created, which makes it possible to reprice a
+// shipment consistently after the rules have changed.
+type RateCards []*RateCard
+
+// NewRateCards will return the history of the rate cards, where no two rate
+// cards can have the same version. Rate cards that are effective from the
+// same date keep their order and the last of them is the effective one.
+func NewRateCards(rateCards ...*RateCard) (_ RateCards, err error) {
+	if len(rateCards) == 0 {
+		err = fmt.Errorf("at least one rate card is required")
+		return
+	}
+
+	versions := map[string]bool{}
+
+	for _, rateCard := range rateCards {
+		if versions[rateCard.Version] {
+			err = fmt.Errorf("rate card version: %s is not unique", rateCard.Version)
+			return
+		}
+
+		versions[rateCard.Version] = true
+	}
+
+	history := append(RateCards{}, rateCards...)
+	sort.SliceStable(history, func(i, j int) bool {
+		return history[i].EffectiveFrom.Before(history[j].EffectiveFrom)
+	})
+
+	return history, nil
+}
+
+// DefaultRateCards will return the history of the rate cards which is used
+// when no other rate cards are configured, it starts with the original
+// price rules.
+func DefaultRateCards() RateCards {
+	return defaultRateCards
+}
+
+// LoadRateCards will read and validate the history of the YAML or JSON rate
+// cards in the directory, every .yaml, .yml or .json file is a rate card.
+func LoadRateCards(dir string) (RateCards, error) {
+	return parseRateCards(os.DirFS(dir), ".")
+}
+
+func parseRateCards(files fs.FS, dir string) (_ RateCards, err error) {
+	entries, err := fs.ReadDir(files, dir)
+	if err != nil {
+		err = fmt.Errorf("failed to read rate cards: %w", err)
+		return
+	}
+
+	var rateCards []*RateCard
+
+	for _, entry := range entries {
+		switch path.Ext(entry.Name()) {
+		case ".yaml", ".yml", ".json":
+		default:
+			continue
+		}
+
+		var data []byte
+
+		if data, err = fs.ReadFile(files, path.Join(dir, entry.Name())); err != nil {
+			err = fmt.Errorf("failed to read rate card: %s: %w", entry.Name(), err)
+			return
+		}
+
+		var rateCard *RateCard
+
+		if rateCard, err = ParseRateCard(data); err != nil {
+			err = fmt.Errorf("file: %s: %w", entry.Name(), err)
+			return
+		}
+
+		rateCards = append(rateCards, rateCard)
+	}
+
+	return NewRateCards(rateCards...)
+}
+
+func mustParseRateCards(files fs.FS, dir string) RateCards {
+	rateCards, err := parseRateCards(files, dir)
+	if err != nil {
+		panic(err)
+	}
+
+	return rateCards
+}
+
+// At will return the rate card that was effective at the time, a
+// RateCardError is returned if no rate card was effective yet.
+func (rcs RateCards) At(at time.Time) (_ *RateCard, err error) {
+	for idx := len(rcs) - 1; idx >= 0; idx-- {
+		if !rcs[idx].EffectiveFrom.After(at) {
+			return rcs[idx], nil
+		}
+	}
+
+	err = RateCardError{At: at}
+
+	return
+}
+
+// Override will parse and validate a YAML or JSON rate card which overrides
+// the rate card that is effective from the same date, or the first rate card
+// when it is effective before all of them.
+func (rcs RateCards) Override(data []byte) (_ *RateCard, err error) {
+	if len(rcs) == 0 {
+		return ParseRateCard(data)
+	}
+
+	var dated struct {
+		EffectiveFrom time.Time `yaml:"effectiveFrom"`
+	}
+
+	if err = yaml.Unmarshal(data, &dated); err != nil {
+		err = fmt.Errorf("failed to parse rate card: %w", err)
+		return
+	}
+
+	base := rcs[0]
+
+	if rateCard, atErr := rcs.At(dated.EffectiveFrom); atErr == nil {
+		base = rateCard
+	}
+
+	return base.Override(data)
+}
+
+// OverriddenBy will return the history where the rate cards are replaced by
+// the overriding rate cards from the date that the first of them is effective.
+func (rcs RateCards) OverriddenBy(overrides ...*RateCard) (_ RateCards, err error) {
+	if len(overrides) == 0 {
+		return rcs, nil
+	}
+
+	overriding, err := NewRateCards(overrides...)
+	if err != nil {
+		return
+	}
+
+	var history RateCards
+
+	for _, rateCard := range rcs {
+		if rateCard.EffectiveFrom.Before(overriding[0].EffectiveFrom) {
+			history = append(history, rateCard)
+		}
+	}
+
+	return append(history, overriding...), nil
+}
@@ -0,0 +1,104 @@
+package price_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lonnblad/shipment-service-backend/businesslogic/models"
+	"github.com/lonnblad/shipment-service-backend/businesslogic/price"
+)
+
+func Test_RateCards(t *testing.T) {
+	first, err := price.ParseRateCard([]byte(validRateCard))
+	require.NoError(t, err)
+
+	second, err := price.ParseRateCard([]byte(strings.NewReplacer(
+		`version: "2"`, `version: "3"`,
+		`2022-01-01`, `2022-03-01`,
+		`basePrice: 50`, `basePrice: 60`,
+	).Replace(validRateCard)))
+	require.NoError(t, err)
+
+	rateCards, err := price.NewRateCards(second, first)
+	require.NoError(t, err)
+
+	shipment := models.Shipment{}
+	shipment.Sender.CountryCode = "US"
+	shipment.Package.Weight = 5
+
+	// The rate card is selected by when the shipment was created.
+	for createdAt, expected := range map[string]int{"2022-01-01": 15000, "2022-02-28": 15000, "2022-03-01": 18000, "2023-01-01": 18000} {
+		shipment.CreatedAt, err = time.Parse("2006-01-02", createdAt)
+		require.NoError(t, err)
+
+		actualPrice, calculateErr := price.Calculate(rateCards, shipment)
+		require.NoError(t, calculateErr)
+		assert.Equal(t, expected, actualPrice, createdAt)
+	}
+
+	shipment.CreatedAt = first.EffectiveFrom.Add(-time.Nanosecond)
+
+	_, err = price.Calculate(rateCards, shipment)
+	assert.Equal(t, price.RateCardError{At: shipment.CreatedAt}, err)
+
+	_, err = price.NewRateCards(first, first)
+	assert.Error(t, err)
+}
+
+func Test_RateCards_OverriddenBy(t *testing.T) {
+	rateCards := price.DefaultRateCards()
+
+	contract, err := rateCards.Override([]byte("version: contract-1\neffectiveFrom: 2022-01-01T00:00:00Z\ndiscountPercent: 10"))
+	require.NoError(t, err)
+
+	history, err := rateCards.OverriddenBy(contract)
+	require.NoError(t, err)
+
+	shipment := models.Shipment{CreatedAt: time.Date(2021, time.June, 1, 0, 0, 0, 0, time.UTC)}
+	shipment.Sender.CountryCode = "SE"
+	shipment.Package.Weight = 10
+
+	// The default rate card is used until the overriding rate card is effective.
+	breakdown, err := price.CalculateBreakdown(history, shipment)
+	require.NoError(t, err)
+	assert.Equal(t, "2021-01-01", breakdown.RateCardVersion)
+	assert.Equal(t, 10000, breakdown.Price)
+
+	shipment.CreatedAt = contract.EffectiveFrom
+
+	breakdown, err = price.CalculateBreakdown(history, shipment)
+	require.NoError(t, err)
+	assert.Equal(t, "contract-1", breakdown.RateCardVersion)
+	assert.Equal(t, 9000, breakdown.Price)
+
+	unchanged, err := rateCards.OverriddenBy()
+	require.NoError(t, err)
+	assert.Equal(t, rateCards, unchanged)
+}
+
+func Test_LoadRateCards(t *testing.T) {
+	dir := t.TempDir()
+
+	second := strings.Replace(strings.Replace(validRateCard, `"2"`, `"3"`, 1), "2022-01-01", "2022-03-01", 1)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.yaml"), []byte(second), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "b.yml"), []byte(validRateCard), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("# Rate cards"), 0o600))
+
+	rateCards, err := price.LoadRateCards(dir)
+	require.NoError(t, err)
+	require.Len(t, rateCards, 2)
+	assert.Equal(t, "2", rateCards[0].Version)
+	assert.Equal(t, "3", rateCards[1].Version)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "c.json"), []byte("{"), 0o600))
+
+	_, err = price.LoadRateCards(dir)
+	assert.Error(t, err)
+}
@@ -1,130 +1,345 @@
 package price
 
 import (
+	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/pariz/gountries"
 
 	"github.com/lonnblad/shipment-service-backend/businesslogic/models"
 )
 
-// Calculate will return a price or an error if it didn't succeed in
-// calculating a price.
-//
-// Note. the price is returned as an integer representing the real value,
-// as the base prices are all multiplies of 10 and the specified region
-// multiplier only use one decimal, this is fine since the result will
-// always be an integer.
+// Apply will price the shipment with the rate card that was effective when it
+// was created. The price is converted to the currency of the package, or to the
+// default currency of the rate card when the package doesn't have a currency.
+func Apply(ctx context.Context, rateCards RateCards, converter Converter, s models.Shipment) (_ models.Shipment, err error) {
+	rateCard, err := rateCards.At(s.CreatedAt)
+	if err != nil {
+		return
+	}
+
+	return rateCard.Apply(ctx, converter, s)
+}
+
+// Apply will price the shipment with the rate card, regardless of when the
+// shipment was created. The price is converted to the currency of the package,
+// or to the default currency of the rate card when the package doesn't have a
+// currency, with the exchange rate at when the shipment was created. Each
+// parcel is given its own price before the consignment discount, where the
+// parcel prices add up to the converted price before the discount.
+func (rc *RateCard) Apply(ctx context.Context, converter Converter, s models.Shipment) (_ models.Shipment, err error) {
+	parcelBreakdowns, breakdown, err := rc.calculate(s)
+	if err != nil {
+		return
+	}
+
+	if s.Package.Currency == "" {
+		s.Package.Currency = rc.defaultCurrency()
+	}
+
+	if s.Parcels, err = priceParcels(ctx, converter, s, parcelBreakdowns); err != nil {
+		return
+	}
+
+	s.Package.Price, s.ExchangeRate, err = converter.Convert(ctx, breakdown.Price, breakdown.Currency, s.Package.Currency, s.CreatedAt)
+	if err != nil {
+		return
+	}
+
+	s.PriceBreakdown = &breakdown
+	s.RateCardVersion = breakdown.RateCardVersion
+
+	return s, nil
+}
+
+// priceParcels will price the parcels of the shipment with their breakdowns,
+// converted to the currency of the package. The sum of the parcel prices is
+// converted once and the rounding remainder is given to the last parcel, so
+// that the parcel prices add up to the converted sum.
+func priceParcels(
+	ctx context.Context, converter Converter, s models.Shipment, parcelBreakdowns []models.PriceBreakdown,
+) (parcels []models.Parcel, err error) {
+	if len(s.Parcels) == 0 {
+		return
+	}
+
+	var subtotal int
+	for _, parcelBreakdown := range parcelBreakdowns {
+		subtotal += parcelBreakdown.Price
+	}
+
+	from := parcelBreakdowns[0].Currency
+
+	convertedSubtotal, _, err := converter.Convert(ctx, subtotal, from, s.Package.Currency, s.CreatedAt)
+	if err != nil {
+		return
+	}
+
+	// The parcels are copied to not price the parcels of the provided shipment.
+	parcels = append([]models.Parcel(nil), s.Parcels...)
+	last := len(parcels) - 1
+
+	var allocated int
+
+	for idx := range parcels {
+		parcel := &parcels[idx]
+		parcel.Currency = s.Package.Currency
+		parcel.PriceBreakdown = &parcelBreakdowns[idx]
+
+		if idx == last {
+			parcel.Price = convertedSubtotal - allocated
+			break
+		}
+
+		if parcel.Price, _, err = converter.Convert(ctx, parcel.PriceBreakdown.Price, from, parcel.Currency, s.CreatedAt); err != nil {
+			return
+		}
+
+		allocated += parcel.Price
+	}
+
+	return parcels, nil
+}
+
+// Calculate will return a price in the currency of the rate card that was
+// effective when the shipment was created, or an error if it didn't
+// succeed in calculating a price.
 //
-// In a real world scenario, where there might be a need for prices with
-// decimals, the returned integer should compensate so that a value like
-// 100.05 is returned as 10005, with a property stating the value 100 as
-// the decimal multipler.
+// Note. the price is returned as an integer in the minor units of the
+// currency, e.g. 100.05 SEK is returned as 10005, which makes the region
+// multipliers with one decimal exact as long as the base prices are whole
+// units of the currency. A discount is rounded down to the closest minor
+// unit.
 //
 // This article talks about why floating points shouldn't be used for currency.
 // https://husobee.github.io/money/float/2016/09/23/never-use-floats-for-currency.html
-func Calculate(s models.Shipment) (_ int, err error) {
-	basePrice, err := findBasePrice(s.Package.Weight)
+func Calculate(rateCards RateCards, s models.Shipment) (_ int, err error) {
+	breakdown, err := CalculateBreakdown(rateCards, s)
+	if err != nil {
+		return
+	}
+
+	return breakdown.Price, nil
+}
+
+// CalculateBreakdown will return the price according to the rate card that
+// was effective when the shipment was created together with how it was
+// calculated, or an error if it didn't succeed in calculating a price.
+func CalculateBreakdown(rateCards RateCards, s models.Shipment) (_ models.PriceBreakdown, err error) {
+	rateCard, err := rateCards.At(s.CreatedAt)
+	if err != nil {
+		return
+	}
+
+	return rateCard.CalculateBreakdown(s)
+}
+
+// Calculate will return the price according to the rate card, regardless
+// of when the shipment was created.
+func (rc *RateCard) Calculate(s models.Shipment) (_ int, err error) {
+	breakdown, err := rc.CalculateBreakdown(s)
 	if err != nil {
 		return
 	}
 
-	multiplier, err := findRegionMultiplier(s.Sender.CountryCode)
+	return breakdown.Price, nil
+}
+
+// CalculateBreakdown will return the price according to the rate card together
+// with how it was calculated, regardless of when the shipment was created. The
+// weight class of each parcel is selected by its chargeable weight, and the
+// price of the shipment is the sum of the parcels minus the consignment discount.
+func (rc *RateCard) CalculateBreakdown(s models.Shipment) (breakdown models.PriceBreakdown, err error) {
+	_, breakdown, err = rc.calculate(s)
+	return
+}
+
+// AdjustmentConsignmentDiscount is the name of the adjustment
+// of the consignment discount of the rate card.
+const AdjustmentConsignmentDiscount = "consignment-discount"
+
+// calculate will return the breakdown of each parcel of the shipment together
+// with the breakdown of the shipment, a shipment without parcels is
+// calculated as a single parcel of its package.
+func (rc *RateCard) calculate(s models.Shipment) (parcelBreakdowns []models.PriceBreakdown, breakdown models.PriceBreakdown, err error) {
+	packages := []models.Package{s.Package}
+
+	if len(s.Parcels) > 0 {
+		packages = make([]models.Package, len(s.Parcels))
+
+		for idx, parcel := range s.Parcels {
+			packages[idx] = parcel.Package
+		}
+	}
+
+	for _, pkg := range packages {
+		var parcelBreakdown models.PriceBreakdown
+
+		if parcelBreakdown, err = rc.calculateParcel(s.Sender.CountryCode, pkg); err != nil {
+			return
+		}
+
+		parcelBreakdowns = append(parcelBreakdowns, parcelBreakdown)
+	}
+
+	breakdown = sumBreakdowns(parcelBreakdowns)
+
+	if discount := rc.ConsignmentDiscount; discount != nil && len(packages) >= discount.MinParcels {
+		amount := breakdown.Price * discount.Percent / percentDivisor
+
+		breakdown.Adjustments = append(breakdown.Adjustments, models.PriceAdjustment{
+			Name: AdjustmentConsignmentDiscount, Amount: -amount,
+		})
+		breakdown.Price -= amount
+	}
+
+	return parcelBreakdowns, breakdown, nil
+}
+
+// sumBreakdowns will return the sum of the breakdowns of the parcels, where
+// the adjustments with the same name are summed up. The weight class is only
+// kept when all parcels have the same weight class.
+func sumBreakdowns(parcelBreakdowns []models.PriceBreakdown) models.PriceBreakdown {
+	breakdown := parcelBreakdowns[0]
+	breakdown.Adjustments = append([]models.PriceAdjustment(nil), breakdown.Adjustments...)
+
+	for _, parcelBreakdown := range parcelBreakdowns[1:] {
+		if parcelBreakdown.WeightClass != breakdown.WeightClass {
+			breakdown.WeightClass = ""
+		}
+
+		breakdown.VolumetricWeight += parcelBreakdown.VolumetricWeight
+		breakdown.ChargeableWeight += parcelBreakdown.ChargeableWeight
+		breakdown.BasePrice += parcelBreakdown.BasePrice
+		breakdown.Price += parcelBreakdown.Price
+
+		for _, adjustment := range parcelBreakdown.Adjustments {
+			breakdown.Adjustments = addAdjustment(breakdown.Adjustments, adjustment)
+		}
+	}
+
+	return breakdown
+}
+
+// addAdjustment will add the amount of the adjustment to the adjustment
+// with the same name, or append it when there isn't one.
+func addAdjustment(adjustments []models.PriceAdjustment, adjustment models.PriceAdjustment) []models.PriceAdjustment {
+	for idx := range adjustments {
+		if adjustments[idx].Name == adjustment.Name {
+			adjustments[idx].Amount += adjustment.Amount
+			return adjustments
+		}
+	}
+
+	return append(adjustments, adjustment)
+}
+
+// calculateParcel will return the breakdown of the price of a
+// single parcel that is sent from the country of the sender.
+func (rc *RateCard) calculateParcel(countryCode string, pkg models.Package) (breakdown models.PriceBreakdown, err error) {
+	chargeableWeight := rc.ChargeableWeight(pkg)
+
+	band, err := rc.findWeightBand(chargeableWeight)
+	if err != nil {
+		return
+	}
+
+	region, err := rc.findRegion(countryCode)
 	if err != nil {
 		return
 	}
 
-	return basePrice * multiplier / regionMulitplierAdjustment, nil
+	breakdown.RateCardVersion = rc.Version
+	breakdown.Currency = rc.Currency
+	breakdown.VolumetricWeight = rc.VolumetricWeight(pkg)
+	breakdown.ChargeableWeight = chargeableWeight
+	breakdown.WeightClass = band.Name
+	breakdown.BasePrice = band.BasePrice * minorUnits[rc.Currency]
+	breakdown.Region = region.Name
+	breakdown.RegionMultiplier = region.multiplier()
+	breakdown.Price = breakdown.BasePrice * breakdown.RegionMultiplier / regionMulitplierAdjustment
+
+	if rc.DiscountPercent > 0 {
+		discount := breakdown.Price * rc.DiscountPercent / percentDivisor
+
+		breakdown.Adjustments = append(breakdown.Adjustments, models.PriceAdjustment{
+			Name: AdjustmentDiscount, Amount: -discount,
+		})
+		breakdown.Price -= discount
+	}
+
+	return breakdown, nil
 }
 
+// AdjustmentDiscount is the name of the adjustment
+// of the discount of the rate card.
+const AdjustmentDiscount = "discount"
+
 type (
-	// WeightError will be returned by Calculate when there isn't
-	// a defined weight class for the provided weight.
+	// WeightError will be returned by Calculate when there isn't a
+	// defined weight class for the provided weight, which is in grams.
 	WeightClassError struct{ Weight int }
 
 	// CountryCodeError will be returned by Calculate when there
 	// isn't a defined a country for the provided country code.
 	CountryCodeError struct{ CountryCode string }
+
+	// RateCardError will be returned by Calculate when no rate
+	// card was effective when the shipment was created.
+	RateCardError struct{ At time.Time }
 )
 
 func (we WeightClassError) Error() string {
-	return fmt.Sprintf("weight: %d don't have a defined price", we.Weight)
+	return fmt.Sprintf("weight: %d g don't have a defined price", we.Weight)
 }
 
 func (cce CountryCodeError) Error() string {
 	return fmt.Sprintf("countryCode: %s is not defined", cce.CountryCode)
 }
 
-const (
-	// - Small (0 - 10kg): 100sek
-	basePriceSmall        = 100
-	weightLowerBoundSmall = 0
-	weightUpperBoundSmall = 10
-
-	// - Medium (10 - 25kg): 300sek
-	basePriceMedium        = 300
-	weightUpperBoundMedium = 25
-
-	// - Large (26 - 50kg): 500sek
-	basePriceLarge        = 500
-	weightUpperBoundLarge = 50
-
-	// - Huge (51 - 1000kg): 2000sek
-	basePriceHuge        = 2000
-	weightUpperBoundHuge = 1000
-
-	// All the region multipliers are multiplied by 10
-	// to remove the need of using a floating pointer.
-	// - Nordic region (Sweden, Norway, Denmark, Finland), the price is multiplied by 1
-	// - EU region, the price is multiplied by 1.5
-	// - Outside the EU, the price is multiplied by 2.5
-	regionMulitplierAdjustment = 10
-	regionMultiplierNordic     = 10
-	regionMultiplierEU         = 15
-	regionMultiplierNonEU      = 25
-)
+func (rce RateCardError) Error() string {
+	return fmt.Sprintf("no rate card was effective at: %s", rce.At.Format(time.RFC3339))
+}
 
-func findBasePrice(weight int) (_ int, err error) {
-	var price int
-
-	switch {
-	case weightLowerBoundSmall <= weight && weight <= weightUpperBoundSmall:
-		price = basePriceSmall
-	case weightUpperBoundSmall < weight && weight <= weightUpperBoundMedium:
-		price = basePriceMedium
-	case weightUpperBoundMedium < weight && weight <= weightUpperBoundLarge:
-		price = basePriceLarge
-	case weightUpperBoundLarge < weight && weight <= weightUpperBoundHuge:
-		price = basePriceHuge
-	default:
+// findWeightBand will return the first weight band with a max weight that
+// isn't below the weight, which is in grams, as the weight bands start right
+// after the previous weight band.
+func (rc *RateCard) findWeightBand(weight int) (_ WeightBand, err error) {
+	if weight < 0 {
 		err = WeightClassError{Weight: weight}
 		return
 	}
 
-	return price, nil
+	for _, band := range rc.WeightBands {
+		if weight <= band.maxWeight() {
+			return band, nil
+		}
+	}
+
+	err = WeightClassError{Weight: weight}
+
+	return
 }
 
 var countries = gountries.New()
 
-func findRegionMultiplier(countryCode string) (_ int, err error) {
+func (rc *RateCard) findRegion(countryCode string) (_ Region, err error) {
 	country, err := countries.FindCountryByAlpha(countryCode)
 	if err != nil {
 		err = CountryCodeError{CountryCode: countryCode}
 		return
 	}
 
-	// Nordic Region
-	switch strings.ToLower(country.Alpha2) {
-	case "se", "no", "dk", "fi":
-		return regionMultiplierNordic, nil
+	if idx, ok := rc.regions[strings.ToUpper(country.Alpha2)]; ok {
+		return rc.Regions[idx], nil
 	}
 
-	// EU Region
-	if country.EuMember {
-		return regionMultiplierEU, nil
+	if rc.defaultRegion == nil {
+		err = CountryCodeError{CountryCode: countryCode}
+		return
 	}
 
-	// Outside the EU
-	return regionMultiplierNonEU, nil
+	return rc.Regions[*rc.defaultRegion], nil
 }
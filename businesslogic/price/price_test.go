@@ -1,9 +1,12 @@
 package price_test
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/lonnblad/shipment-service-backend/businesslogic/models"
 	"github.com/lonnblad/shipment-service-backend/businesslogic/price"
@@ -22,10 +25,12 @@ func (tc testCase) Name() string {
 
 func Test_PriceCalculation(t *testing.T) {
 	for _, tc := range createTestCases() {
+		tc := tc
+
 		t.Run(tc.Name(), func(t *testing.T) {
 			t.Parallel()
 
-			actualPrice, actualError := price.Calculate(tc.shipment)
+			actualPrice, actualError := price.Calculate(price.DefaultRateCards(), tc.shipment)
 
 			assert.Equal(t, tc.expectedError, actualError)
 			assert.Equal(t, tc.expectedPrice, actualPrice)
@@ -33,25 +38,208 @@ func Test_PriceCalculation(t *testing.T) {
 	}
 }
 
+func Test_PriceBreakdown(t *testing.T) {
+	shipment := models.Shipment{CreatedAt: createdAt}
+	shipment.Sender.CountryCode = "DE"
+	shipment.Package.Weight = 25000
+
+	breakdown, err := price.CalculateBreakdown(price.DefaultRateCards(), shipment)
+	assert.NoError(t, err)
+
+	expected := models.PriceBreakdown{
+		RateCardVersion:  "2021-01-01",
+		Currency:         "SEK",
+		ChargeableWeight: 25000,
+		WeightClass:      "medium",
+		BasePrice:        30000,
+		Region:           "eu",
+		RegionMultiplier: 15,
+		Price:            45000,
+	}
+	assert.Equal(t, expected, breakdown)
+}
+
+func Test_VolumetricWeight(t *testing.T) {
+	shipment := models.Shipment{CreatedAt: createdAt}
+	shipment.Sender.CountryCode = "SE"
+	shipment.Package = models.Package{Weight: 2000, Length: 100, Width: 50, Height: 30}
+
+	// 150000 cm³ divided by 5000 is 30 kg, which is charged as a large package.
+	breakdown, err := price.CalculateBreakdown(price.DefaultRateCards(), shipment)
+	assert.NoError(t, err)
+	assert.Equal(t, 30000, breakdown.VolumetricWeight)
+	assert.Equal(t, 30000, breakdown.ChargeableWeight)
+	assert.Equal(t, "large", breakdown.WeightClass)
+	assert.Equal(t, 50000, breakdown.Price)
+
+	rateCard := price.RateCard{VolumetricDivisor: 6000}
+	assert.Equal(t, 25000, rateCard.VolumetricWeight(shipment.Package))
+
+	// The weight is charged when it is greater than the volumetric weight.
+	shipment.Package.Weight = 40000
+	assert.Equal(t, 40000, rateCard.ChargeableWeight(shipment.Package))
+
+	// The volumetric weight is rounded up to the closest gram.
+	shipment.Package = models.Package{Weight: 50, Length: 7, Width: 7, Height: 7}
+	assert.Equal(t, 58, rateCard.VolumetricWeight(shipment.Package))
+	assert.Equal(t, 58, rateCard.ChargeableWeight(shipment.Package))
+
+	// A package without dimensions is charged by its weight.
+	shipment.Package = models.Package{Weight: 5000}
+	assert.Equal(t, 0, rateCard.VolumetricWeight(shipment.Package))
+	assert.Equal(t, 5000, rateCard.ChargeableWeight(shipment.Package))
+}
+
+func Test_Parcels(t *testing.T) {
+	shipment := models.Shipment{CreatedAt: createdAt}
+	shipment.Sender.CountryCode = "SE"
+	shipment = shipment.WithParcels([]models.Parcel{
+		{Package: models.Package{Weight: 6000, Length: 100, Width: 50, Height: 30}},
+		{Package: models.Package{Weight: 4000}},
+	})
+
+	// The parcels are priced one by one, the first is charged by its volumetric weight.
+	breakdown, err := price.CalculateBreakdown(price.DefaultRateCards(), shipment)
+	require.NoError(t, err)
+	assert.Equal(t, models.PriceBreakdown{
+		RateCardVersion:  "2021-01-01",
+		Currency:         "SEK",
+		VolumetricWeight: 30000,
+		ChargeableWeight: 34000,
+		BasePrice:        60000,
+		Region:           "nordic",
+		RegionMultiplier: 10,
+		Price:            60000,
+	}, breakdown)
+
+	exchangeRates, err := price.ParseStaticExchangeRates([]byte(validExchangeRates))
+	require.NoError(t, err)
+
+	shipment.Package.Currency = "EUR"
+	converter := price.Converter{Provider: exchangeRates, Rounding: price.RoundHalfUp}
+
+	priced, err := price.Apply(context.Background(), price.DefaultRateCards(), converter, shipment)
+	require.NoError(t, err)
+	assert.Equal(t, 5250, priced.Package.Price)
+	assert.Equal(t, models.Package{Weight: 6000, Length: 100, Width: 50, Height: 30, Price: 4375, Currency: "EUR"}, priced.Parcels[0].Package)
+	assert.Equal(t, 50000, priced.Parcels[0].PriceBreakdown.Price)
+	assert.Equal(t, "large", priced.Parcels[0].PriceBreakdown.WeightClass)
+	assert.Equal(t, models.Package{Weight: 4000, Price: 875, Currency: "EUR"}, priced.Parcels[1].Package)
+
+	// The parcels of the provided shipment are left as they were.
+	assert.Zero(t, shipment.Parcels[0].Price)
+	assert.Nil(t, shipment.Parcels[0].PriceBreakdown)
+}
+
+func Test_Parcels_RoundingRemainder(t *testing.T) {
+	rateCard, err := price.DefaultRateCards().Override([]byte(`
+version: contract-1
+effectiveFrom: 2022-01-01T00:00:00Z
+discountPercent: 33
+`))
+	require.NoError(t, err)
+
+	exchangeRates, err := price.ParseStaticExchangeRates([]byte(validExchangeRates))
+	require.NoError(t, err)
+
+	shipment := models.Shipment{CreatedAt: createdAt}
+	shipment.Sender.CountryCode = "SE"
+	shipment.Package.Currency = "EUR"
+	shipment = shipment.WithParcels([]models.Parcel{
+		{Package: models.Package{Weight: 6000, Length: 100, Width: 50, Height: 30}},
+		{Package: models.Package{Weight: 4000}},
+	})
+
+	converter := price.Converter{Provider: exchangeRates, Rounding: price.RoundHalfUp}
+
+	// 335 SEK and 67 SEK would be rounded down to 29.31 EUR and 5.86 EUR one by one,
+	// while the 402 SEK of the package is rounded up to 35.18 EUR.
+	priced, err := rateCard.Apply(context.Background(), converter, shipment)
+	require.NoError(t, err)
+	assert.Equal(t, 3518, priced.Package.Price)
+	assert.Equal(t, 2931, priced.Parcels[0].Price)
+	assert.Equal(t, 587, priced.Parcels[1].Price)
+}
+
+func Test_ConsignmentDiscount(t *testing.T) {
+	rateCard, err := price.DefaultRateCards().Override([]byte(`
+version: contract-1
+effectiveFrom: 2022-01-01T00:00:00Z
+discountPercent: 15
+consignmentDiscount: {minParcels: 2, percent: 10}
+regions:
+  - {name: everywhere, multiplier: 2, default: true}
+`))
+	require.NoError(t, err)
+
+	shipment := models.Shipment{}
+	shipment.Sender.CountryCode = "SE"
+	shipment = shipment.WithParcels([]models.Parcel{
+		{Package: models.Package{Weight: 33000}},
+		{Package: models.Package{Weight: 5000}},
+	})
+
+	// The discounts of the parcels are summed up before the consignment discount.
+	breakdown, err := rateCard.CalculateBreakdown(shipment)
+	require.NoError(t, err)
+	assert.Equal(t, models.PriceBreakdown{
+		RateCardVersion:  "contract-1",
+		Currency:         "SEK",
+		ChargeableWeight: 38000,
+		BasePrice:        60000,
+		Region:           "everywhere",
+		RegionMultiplier: 20,
+		Adjustments: []models.PriceAdjustment{
+			{Name: price.AdjustmentDiscount, Amount: -18000},
+			{Name: price.AdjustmentConsignmentDiscount, Amount: -10200},
+		},
+		Price: 91800,
+	}, breakdown)
+
+	// A single parcel doesn't get the consignment discount.
+	shipment = shipment.WithParcels(shipment.Parcels[:1])
+
+	breakdown, err = rateCard.CalculateBreakdown(shipment)
+	require.NoError(t, err)
+	assert.Equal(t, "large", breakdown.WeightClass)
+	assert.Equal(t, []models.PriceAdjustment{{Name: price.AdjustmentDiscount, Amount: -15000}}, breakdown.Adjustments)
+	assert.Equal(t, 85000, breakdown.Price)
+}
+
+// createdAt is when the shipments of the test cases are created,
+// which is when the first of the default rate cards is effective.
+var createdAt = time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC)
+
 func createTestCases() (tcs []testCase) {
-	tcs = append(tcs, newTestCase("Nordic/Small", "SE", 10, 100, nil))
-	tcs = append(tcs, newTestCase("Nordic/Medium", "SE", 25, 300, nil))
-	tcs = append(tcs, newTestCase("Nordic/Large", "SE", 50, 500, nil))
-	tcs = append(tcs, newTestCase("Nordic/Huge", "SE", 1000, 2000, nil))
+	tcs = append(tcs, newTestCase("Nordic/Small", "SE", 10000, 10000, nil))
+	tcs = append(tcs, newTestCase("Nordic/Medium", "SE", 25000, 30000, nil))
+	tcs = append(tcs, newTestCase("Nordic/Large", "SE", 50000, 50000, nil))
+	tcs = append(tcs, newTestCase("Nordic/Huge", "SE", 1000000, 200000, nil))
 
-	tcs = append(tcs, newTestCase("EU/Small", "DE", 10, 150, nil))
-	tcs = append(tcs, newTestCase("EU/Medium", "DE", 25, 450, nil))
-	tcs = append(tcs, newTestCase("EU/Large", "DE", 50, 750, nil))
-	tcs = append(tcs, newTestCase("EU/Huge", "DE", 1000, 3000, nil))
+	tcs = append(tcs, newTestCase("EU/Small", "DE", 10000, 15000, nil))
+	tcs = append(tcs, newTestCase("EU/Medium", "DE", 25000, 45000, nil))
+	tcs = append(tcs, newTestCase("EU/Large", "DE", 50000, 75000, nil))
+	tcs = append(tcs, newTestCase("EU/Huge", "DE", 1000000, 300000, nil))
 
-	tcs = append(tcs, newTestCase("Outside_EU/Small", "US", 10, 250, nil))
-	tcs = append(tcs, newTestCase("Outside_EU/Medium", "US", 25, 750, nil))
-	tcs = append(tcs, newTestCase("Outside_EU/Large", "US", 50, 1250, nil))
-	tcs = append(tcs, newTestCase("Outside_EU/Huge", "US", 1000, 5000, nil))
+	tcs = append(tcs, newTestCase("Outside_EU/Small", "US", 10000, 25000, nil))
+	tcs = append(tcs, newTestCase("Outside_EU/Medium", "US", 25000, 75000, nil))
+	tcs = append(tcs, newTestCase("Outside_EU/Large", "US", 50000, 125000, nil))
+	tcs = append(tcs, newTestCase("Outside_EU/Huge", "US", 1000000, 500000, nil))
+
+	// The weight bands include their max weight and start right after the previous band.
+	tcs = append(tcs, newTestCase("Nordic/Small/Light", "SE", 400, 10000, nil))
+	tcs = append(tcs, newTestCase("Nordic/Medium/Min", "SE", 10001, 30000, nil))
+	tcs = append(tcs, newTestCase("Nordic/Medium/Between", "SE", 10500, 30000, nil))
+	tcs = append(tcs, newTestCase("Nordic/Large/Min", "SE", 25001, 50000, nil))
 
 	tcs = append(tcs, newTestCase("Bad_Weight", "SE", -1, 0, price.WeightClassError{Weight: -1}))
+	tcs = append(tcs, newTestCase("Bad_Weight/Above", "SE", 1000001, 0, price.WeightClassError{Weight: 1000001}))
 	tcs = append(tcs, newTestCase("Bad_CountryCode", "XX", 0, 0, price.CountryCodeError{CountryCode: "XX"}))
 
+	beforeRateCards := newTestCase("Before_RateCards", "SE", 10000, 0, price.RateCardError{At: createdAt.Add(-time.Second)})
+	beforeRateCards.shipment.CreatedAt = createdAt.Add(-time.Second)
+	tcs = append(tcs, beforeRateCards)
+
 	return tcs
 }
 
@@ -59,6 +247,7 @@ func newTestCase(name, senderCountryCode string, weight, expectedPrice int, expe
 	tc := testCase{}
 
 	tc.name = name
+	tc.shipment.CreatedAt = createdAt
 	tc.shipment.Sender.CountryCode = senderCountryCode
 	tc.shipment.Package.Weight = weight
 	tc.expectedError = expectedErr
@@ -0,0 +1,316 @@
+package price
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/lonnblad/shipment-service-backend/businesslogic/models"
+)
+
+const (
+	// regionMulitplierAdjustment is what the region multipliers are
+	// multiplied by to remove the need of using a floating point.
+	regionMulitplierAdjustment = 10
+
+	// multiplierTolerance is the rounding error allowed when checking
+	// that a multiplier has one decimal or that a weight is whole grams.
+	multiplierTolerance = 1e-9
+
+	// gramsPerKg is what the weights of the weight bands
+	// are multiplied by to get the weights in grams.
+	gramsPerKg = 1000
+
+	// defaultVolumetricDivisor is the cm³ per kg that is used when
+	// the rate card doesn't have a volumetric divisor.
+	defaultVolumetricDivisor = 5000
+
+	// minConsignmentParcels is the fewest parcels that a
+	// consignment discount can be given for.
+	minConsignmentParcels = 2
+)
+
+// RateCard is a versioned set of price rules, which is loaded from a YAML
+// or JSON file. The price of a parcel is the base price of the weight band
+// of its chargeable weight, multiplied by the multiplier of the region of the
+// sender, minus the discount. The price of a shipment is the sum of the prices
+// of its parcels, minus the consignment discount.
+type RateCard struct {
+	Version       string       `yaml:"version"`
+	EffectiveFrom time.Time    `yaml:"effectiveFrom"`
+	WeightBands   []WeightBand `yaml:"weightBands"`
+	Regions       []Region     `yaml:"regions"`
+
+	// Currency is the currency of the base prices, which are whole units
+	// of the currency, the price is calculated in its minor units.
+	Currency string `yaml:"currency"`
+
+	// DefaultCurrency is the currency that a shipment is priced in when it
+	// doesn't have a currency, it defaults to the Currency.
+	DefaultCurrency string `yaml:"defaultCurrency"`
+
+	// DiscountPercent is the percent of the price that is discounted,
+	// the discount is rounded down to the closest integer.
+	DiscountPercent int `yaml:"discountPercent"`
+
+	// ConsignmentDiscount is an optional discount of the
+	// shipments with at least a number of parcels.
+	ConsignmentDiscount *ConsignmentDiscount `yaml:"consignmentDiscount"`
+
+	// VolumetricDivisor is the cm³ per kg that the volume of a package is
+	// divided by to get its volumetric weight, it defaults to 5000.
+	VolumetricDivisor int `yaml:"volumetricDivisor"`
+
+	// regions is the index of the region of every country in Regions,
+	// countries that are not in the index are in the default region.
+	regions       map[string]int
+	defaultRegion *int
+}
+
+// WeightBand is the base price of the weights above the MaxWeight of the
+// previous weight band up to and including the MaxWeight, which is in kg with
+// at most three decimals. The first weight band starts at 0 kg, which means
+// that the weight bands never have gaps or overlap.
+//
+// Note. rate cards that were written when the weight bands were whole kg also
+// have a minWeight, which is ignored, as every weight band starts right after
+// the previous weight band.
+type WeightBand struct {
+	Name      string  `yaml:"name"`
+	MaxWeight float64 `yaml:"maxWeight"`
+	BasePrice int     `yaml:"basePrice"`
+}
+
+// ConsignmentDiscount is the percent of the price of a shipment with at least
+// MinParcels parcels that is discounted, the discount is rounded down to the
+// closest integer.
+type ConsignmentDiscount struct {
+	MinParcels int `yaml:"minParcels"`
+	Percent    int `yaml:"percent"`
+}
+
+// Region is the multiplier of the Countries, which are alpha-2 or alpha-3
+// country codes. The Default region is the region of every country that
+// isn't in another region.
+type Region struct {
+	Name       string   `yaml:"name"`
+	Multiplier float64  `yaml:"multiplier"`
+	Countries  []string `yaml:"countries"`
+	Default    bool     `yaml:"default"`
+}
+
+// ParseRateCard will parse and validate a YAML or JSON rate card.
+func ParseRateCard(data []byte) (*RateCard, error) {
+	return parseRateCard(data, RateCard{})
+}
+
+// Override will parse and validate a YAML or JSON rate card which overrides
+// this rate card, the weight bands, regions and currency of this rate card
+// are used when the overriding rate card doesn't have any, as is the volumetric
+// divisor. The version, the effective from date, the default currency and the
+// discounts are never inherited.
+func (rc *RateCard) Override(data []byte) (*RateCard, error) {
+	return parseRateCard(data, RateCard{
+		WeightBands: rc.WeightBands, Regions: rc.Regions, Currency: rc.Currency, VolumetricDivisor: rc.VolumetricDivisor,
+	})
+}
+
+// parseRateCard will parse the data into the rate card, which
+// keeps the values of the rate card that are not in the data.
+func parseRateCard(data []byte, rateCard RateCard) (_ *RateCard, err error) {
+	if err = yaml.Unmarshal(data, &rateCard); err != nil {
+		err = fmt.Errorf("failed to parse rate card: %w", err)
+		return
+	}
+
+	if err = rateCard.Validate(); err != nil {
+		err = fmt.Errorf("rate card: %s is invalid: %w", rateCard.Version, err)
+		return
+	}
+
+	rateCard.index()
+
+	return &rateCard, nil
+}
+
+// Validate will return an error if the rate card doesn't have a version, an
+// effective from date or a supported currency, if the max weights of the weight
+// bands aren't increasing, or if a country is in more than one region.
+func (rc RateCard) Validate() error {
+	if rc.Version == "" {
+		return fmt.Errorf("version is required")
+	}
+
+	if rc.EffectiveFrom.IsZero() {
+		return fmt.Errorf("effective from is required")
+	}
+
+	if _, err := MinorUnits(rc.Currency); err != nil {
+		return err
+	}
+
+	if _, err := MinorUnits(rc.defaultCurrency()); err != nil {
+		return fmt.Errorf("default currency: %w", err)
+	}
+
+	if rc.DiscountPercent < 0 || rc.DiscountPercent > percentDivisor {
+		return fmt.Errorf("discount percent: %d is not between 0 and 100", rc.DiscountPercent)
+	}
+
+	if discount := rc.ConsignmentDiscount; discount != nil {
+		if discount.MinParcels < minConsignmentParcels {
+			return fmt.Errorf("consignment discount needs at least: %d min parcels", minConsignmentParcels)
+		}
+
+		if discount.Percent < 0 || discount.Percent > percentDivisor {
+			return fmt.Errorf("consignment discount percent: %d is not between 0 and 100", discount.Percent)
+		}
+	}
+
+	if rc.VolumetricDivisor < 0 {
+		return fmt.Errorf("volumetric divisor: %d can't be negative", rc.VolumetricDivisor)
+	}
+
+	if err := rc.validateWeightBands(); err != nil {
+		return err
+	}
+
+	return rc.validateRegions()
+}
+
+func (rc RateCard) validateWeightBands() error {
+	if len(rc.WeightBands) == 0 {
+		return fmt.Errorf("at least one weight band is required")
+	}
+
+	names := map[string]bool{}
+
+	for idx, band := range rc.WeightBands {
+		switch {
+		case band.Name == "" || names[band.Name]:
+			return fmt.Errorf("weight band: %d needs a unique name", idx)
+		case band.BasePrice < 0:
+			return fmt.Errorf("weight band: %s has a negative base price: %d", band.Name, band.BasePrice)
+		case band.MaxWeight < 0:
+			return fmt.Errorf("weight band: %s has a negative max weight: %v", band.Name, band.MaxWeight)
+		case math.Abs(float64(band.maxWeight())-band.MaxWeight*gramsPerKg) > multiplierTolerance:
+			return fmt.Errorf("max weight: %v of weight band: %s has more than three decimals", band.MaxWeight, band.Name)
+		case idx > 0 && band.maxWeight() <= rc.WeightBands[idx-1].maxWeight():
+			return fmt.Errorf(
+				"weight band: %s needs a greater max weight than weight band: %s", band.Name, rc.WeightBands[idx-1].Name,
+			)
+		}
+
+		names[band.Name] = true
+	}
+
+	return nil
+}
+
+func (rc RateCard) validateRegions() error {
+	if len(rc.Regions) == 0 {
+		return fmt.Errorf("at least one region is required")
+	}
+
+	names := map[string]bool{}
+	regionOfCountry := map[string]string{}
+	defaultRegion := ""
+
+	for idx, region := range rc.Regions {
+		switch {
+		case region.Name == "" || names[region.Name]:
+			return fmt.Errorf("region: %d needs a unique name", idx)
+		case region.Multiplier <= 0:
+			return fmt.Errorf("region: %s needs a positive multiplier", region.Name)
+		case math.Abs(float64(region.multiplier())-region.Multiplier*regionMulitplierAdjustment) > multiplierTolerance:
+			return fmt.Errorf("multiplier: %v of region: %s has more than one decimal", region.Multiplier, region.Name)
+		case region.Default && defaultRegion != "":
+			return fmt.Errorf("region: %s and region: %s are both default", defaultRegion, region.Name)
+		case region.Default:
+			defaultRegion = region.Name
+		}
+
+		names[region.Name] = true
+
+		for _, countryCode := range region.Countries {
+			country, err := countries.FindCountryByAlpha(countryCode)
+			if err != nil {
+				return fmt.Errorf("region: %s has an unknown country code: %s", region.Name, countryCode)
+			}
+
+			if other, ok := regionOfCountry[country.Alpha2]; ok {
+				return fmt.Errorf("country: %s is in both region: %s and region: %s", country.Alpha2, other, region.Name)
+			}
+
+			regionOfCountry[country.Alpha2] = region.Name
+		}
+	}
+
+	return nil
+}
+
+// index will index the region of every country of a valid rate card.
+func (rc *RateCard) index() {
+	rc.regions = map[string]int{}
+
+	for idx, region := range rc.Regions {
+		if region.Default {
+			defaultRegion := idx
+			rc.defaultRegion = &defaultRegion
+		}
+
+		// The country codes have been validated.
+		for _, countryCode := range region.Countries {
+			if country, err := countries.FindCountryByAlpha(countryCode); err == nil {
+				rc.regions[strings.ToUpper(country.Alpha2)] = idx
+			}
+		}
+	}
+}
+
+// defaultCurrency will return the currency that a shipment
+// is priced in when it doesn't have a currency.
+func (rc RateCard) defaultCurrency() string {
+	if rc.DefaultCurrency != "" {
+		return rc.DefaultCurrency
+	}
+
+	return rc.Currency
+}
+
+// ChargeableWeight will return the weight in grams that the package is charged
+// for, which is the greater of its weight and its volumetric weight.
+func (rc RateCard) ChargeableWeight(p models.Package) int {
+	if volumetricWeight := rc.VolumetricWeight(p); volumetricWeight > p.Weight && p.HasDimensions() {
+		return volumetricWeight
+	}
+
+	return p.Weight
+}
+
+// VolumetricWeight will return the volume of the package in cm³ divided by the
+// volumetric divisor in grams, rounded up to the closest gram, or zero when the
+// package doesn't have any dimensions.
+func (rc RateCard) VolumetricWeight(p models.Package) int {
+	divisor := rc.VolumetricDivisor
+	if divisor == 0 {
+		divisor = defaultVolumetricDivisor
+	}
+
+	volume := p.Length * p.Width * p.Height * gramsPerKg
+
+	return (volume + divisor - 1) / divisor
+}
+
+// maxWeight will return the max weight of the weight band in grams.
+func (b WeightBand) maxWeight() int {
+	return int(math.Round(b.MaxWeight * gramsPerKg))
+}
+
+// multiplier will return the region multiplier multiplied by 10.
+func (r Region) multiplier() int {
+	return int(math.Round(r.Multiplier * regionMulitplierAdjustment))
+}
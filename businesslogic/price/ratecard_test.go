@@ -0,0 +1,160 @@
+package price_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lonnblad/shipment-service-backend/businesslogic/models"
+	"github.com/lonnblad/shipment-service-backend/businesslogic/price"
+)
+
+const validRateCard = `
+version: "2"
+effectiveFrom: 2022-01-01T00:00:00Z
+currency: SEK
+weightBands:
+  - {name: light, maxWeight: 5, basePrice: 50}
+  - {name: heavy, maxWeight: 100, basePrice: 400}
+regions:
+  - {name: home, multiplier: 1.1, countries: [SE, NOR]}
+  - {name: away, multiplier: 3, default: true}
+`
+
+func Test_ParseRateCard(t *testing.T) {
+	rateCard, err := price.ParseRateCard([]byte(validRateCard))
+	require.NoError(t, err)
+	assert.Equal(t, "2", rateCard.Version)
+
+	shipment := models.Shipment{}
+	shipment.Sender.CountryCode = "NO"
+	shipment.Package.Weight = 5001
+
+	breakdown, err := rateCard.CalculateBreakdown(shipment)
+	require.NoError(t, err)
+	assert.Equal(t, models.PriceBreakdown{
+		RateCardVersion: "2", Currency: "SEK", ChargeableWeight: 5001, WeightClass: "heavy",
+		BasePrice: 40000, Region: "home", RegionMultiplier: 11, Price: 44000,
+	}, breakdown)
+
+	shipment.Sender.CountryCode = "US"
+	shipment.Package.Weight = 5000
+
+	actualPrice, err := rateCard.Calculate(shipment)
+	require.NoError(t, err)
+	assert.Equal(t, 15000, actualPrice)
+
+	shipment.Package.Weight = 100001
+
+	_, err = rateCard.Calculate(shipment)
+	assert.Equal(t, price.WeightClassError{Weight: 100001}, err)
+}
+
+func Test_ParseRateCard_JSON(t *testing.T) {
+	rateCard, err := price.ParseRateCard([]byte(`{
+		"version": "json",
+		"effectiveFrom": "2022-01-01T00:00:00Z",
+		"currency": "JPY",
+		"weightBands": [{"name": "any", "minWeight": 0, "maxWeight": 10, "basePrice": 10}],
+		"regions": [{"name": "se", "multiplier": 1, "countries": ["SE"]}]
+	}`))
+	require.NoError(t, err)
+
+	shipment := models.Shipment{}
+	shipment.Sender.CountryCode = "DE"
+
+	// There is no default region.
+	_, err = rateCard.Calculate(shipment)
+	assert.Equal(t, price.CountryCodeError{CountryCode: "DE"}, err)
+
+	// The yen has no minor units.
+	shipment.Sender.CountryCode = "SE"
+
+	actualPrice, err := rateCard.Calculate(shipment)
+	require.NoError(t, err)
+	assert.Equal(t, 10, actualPrice)
+}
+
+func Test_ParseRateCard_MinWeight(t *testing.T) {
+	// A rate card from when the weight bands were whole kg with a min weight.
+	rateCard, err := price.ParseRateCard([]byte(strings.Replace(strings.Replace(validRateCard,
+		`{name: light, maxWeight: 5,`, `{name: light, minWeight: 0, maxWeight: 5,`, 1),
+		`{name: heavy, maxWeight: 100,`, `{name: heavy, minWeight: 6, maxWeight: 100,`, 1,
+	)))
+	require.NoError(t, err)
+
+	shipment := models.Shipment{}
+	shipment.Sender.CountryCode = "SE"
+	shipment.Package.Weight = 5500
+
+	// The weights between 5 and 6 kg are in the heavy weight band.
+	breakdown, err := rateCard.CalculateBreakdown(shipment)
+	require.NoError(t, err)
+	assert.Equal(t, "heavy", breakdown.WeightClass)
+}
+
+func Test_RateCardOverride(t *testing.T) {
+	rateCard, err := price.DefaultRateCards().Override([]byte(`
+version: contract-1
+effectiveFrom: 2022-01-01T00:00:00Z
+discountPercent: 15
+regions:
+  - {name: everywhere, multiplier: 2, default: true}
+`))
+	require.NoError(t, err)
+
+	shipment := models.Shipment{}
+	shipment.Sender.CountryCode = "SE"
+	shipment.Package.Weight = 33000
+
+	// The weight bands of the default rate card are used.
+	breakdown, err := rateCard.CalculateBreakdown(shipment)
+	require.NoError(t, err)
+	assert.Equal(t, models.PriceBreakdown{
+		RateCardVersion:  "contract-1",
+		Currency:         "SEK",
+		ChargeableWeight: 33000,
+		WeightClass:      "large",
+		BasePrice:        50000,
+		Region:           "everywhere",
+		RegionMultiplier: 20,
+		Adjustments:      []models.PriceAdjustment{{Name: price.AdjustmentDiscount, Amount: -15000}},
+		Price:            85000,
+	}, breakdown)
+
+	_, err = price.DefaultRateCards().Override([]byte("version: contract-2\neffectiveFrom: 2022-01-01T00:00:00Z\ndiscountPercent: 101"))
+	assert.Error(t, err)
+}
+
+func Test_ParseRateCard_Invalid(t *testing.T) {
+	for name, rateCard := range map[string]string{
+		"NoVersion":           strings.Replace(validRateCard, `version: "2"`, ``, 1),
+		"NoEffectiveFrom":     strings.Replace(validRateCard, `effectiveFrom: 2022-01-01T00:00:00Z`, ``, 1),
+		"NoCurrency":          strings.Replace(validRateCard, `currency: SEK`, ``, 1),
+		"UnknownCurrency":     strings.Replace(validRateCard, `currency: SEK`, `currency: XXX`, 1),
+		"UnknownDefault":      strings.Replace(validRateCard, `currency: SEK`, "currency: SEK\ndefaultCurrency: XXX", 1),
+		"SameMaxWeight":       strings.Replace(validRateCard, `maxWeight: 100`, `maxWeight: 5`, 1),
+		"DecreasingMaxWeight": strings.Replace(validRateCard, `maxWeight: 100`, `maxWeight: 4.5`, 1),
+		"NegativeMaxWeight":   strings.Replace(validRateCard, `maxWeight: 5,`, `maxWeight: -5,`, 1),
+		"FourDecimals":        strings.Replace(validRateCard, `maxWeight: 5,`, `maxWeight: 5.0005,`, 1),
+		"DuplicateBand":       strings.Replace(validRateCard, `name: heavy`, `name: light`, 1),
+		"CountryInTwoRegions": strings.Replace(validRateCard, `default: true`, `countries: [NO]`, 1),
+		"UnknownCountry":      strings.Replace(validRateCard, `[SE, NOR]`, `[SE, XX]`, 1),
+		"TwoDecimals":         strings.Replace(validRateCard, `1.1`, `1.15`, 1),
+		"NoMultiplier":        strings.Replace(validRateCard, `multiplier: 3, `, ``, 1),
+		"TwoDefaultRegions":   strings.Replace(validRateCard, `countries: [SE, NOR]`, `default: true`, 1),
+		"NegativeDivisor":     strings.Replace(validRateCard, `currency: SEK`, "currency: SEK\nvolumetricDivisor: -1", 1),
+		"OneParcelDiscount":   strings.Replace(validRateCard, `currency: SEK`, "currency: SEK\nconsignmentDiscount: {minParcels: 1}", 1),
+		"ConsignmentAbove100": strings.Replace(validRateCard, `currency: SEK`, "currency: SEK\nconsignmentDiscount: {percent: 101}", 1),
+		"NotYAML":             "{",
+	} {
+		rateCard := rateCard
+
+		t.Run(name, func(t *testing.T) {
+			_, err := price.ParseRateCard([]byte(rateCard))
+			assert.Error(t, err)
+		})
+	}
+}
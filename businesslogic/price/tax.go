@@ -0,0 +1,125 @@
+package price
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pariz/gountries"
+
+	"github.com/lonnblad/shipment-service-backend/businesslogic/models"
+)
+
+// fullTaxRate is a tax rate of 100%, as the tax rates are the
+// percent multiplied by 10 to avoid floating points.
+const fullTaxRate = 1000
+
+// The tax rules that apply to a shipment, depending on where it is sent
+// from and to, and if the sender is a business registered for VAT.
+const (
+	// TaxRuleDomestic is when the sender and receiver are in the same
+	// country, the VAT of the country is charged.
+	TaxRuleDomestic = "domestic"
+	// TaxRuleIntraEUB2C is when a sender that isn't registered for VAT sends
+	// a package to another EU country, the VAT of the sender's country is charged.
+	TaxRuleIntraEUB2C = "intra-eu-b2c"
+	// TaxRuleIntraEUB2B is when a sender that is registered for VAT sends a package
+	// to another EU country, no VAT is charged as the sender reverse charges it.
+	TaxRuleIntraEUB2B = "intra-eu-b2b"
+	// TaxRuleExport is when a package is sent from the EU to a country
+	// outside of the EU, which is zero-rated.
+	TaxRuleExport = "export"
+	// TaxRuleOutsideScope is when a package is sent from outside of the EU
+	// to another country, no VAT is charged.
+	TaxRuleOutsideScope = "outside-scope"
+)
+
+// TaxRules are the standard VAT rates in percent multiplied by 10 of the
+// countries where VAT is charged, by their ISO 3166-1 alpha-2 code. Every EU
+// member state needs a rate, a country outside of the EU with a rate has VAT
+// charged on packages that are sent within it.
+type TaxRules struct {
+	Rates map[string]int
+}
+
+// DefaultTaxRules will return the standard VAT rates of the EU member states.
+func DefaultTaxRules() TaxRules {
+	return TaxRules{Rates: map[string]int{
+		"AT": 200, "BE": 210, "BG": 200, "CY": 190, "CZ": 210, "DE": 190, "DK": 250,
+		"EE": 240, "ES": 210, "FI": 255, "FR": 200, "GR": 240, "HR": 250, "HU": 270,
+		"IE": 230, "IT": 220, "LT": 210, "LU": 170, "LV": 210, "MT": 180, "NL": 210,
+		"PL": 230, "PT": 230, "RO": 210, "SE": 250, "SI": 220, "SK": 230,
+	}}
+}
+
+// Validate will return an error if a country is unknown, if a
+// rate is above 100% or if an EU member state doesn't have a rate.
+func (r TaxRules) Validate() error {
+	for countryCode, rate := range r.Rates {
+		country, err := countries.FindCountryByAlpha(countryCode)
+		if err != nil || strings.ToUpper(country.Alpha2) != countryCode {
+			return fmt.Errorf("tax rate country code: %s is not an upper case alpha-2 code", countryCode)
+		}
+
+		if rate < 0 || rate > fullTaxRate {
+			return fmt.Errorf("tax rate of: %s needs to be between 0 and 100%%", countryCode)
+		}
+	}
+
+	for _, country := range countries.FindAllCountries() {
+		if _, ok := r.Rates[strings.ToUpper(country.Alpha2)]; euMember(country) && !ok {
+			return fmt.Errorf("EU member state: %s doesn't have a tax rate", country.Alpha2)
+		}
+	}
+
+	return nil
+}
+
+// Calculate will return the VAT of the price of the shipment, according to
+// the rule that applies to where it is sent from and to. The VAT is rounded
+// half up to the closest minor unit of the currency.
+func (r TaxRules) Calculate(s models.Shipment) (tax models.Tax, err error) {
+	sender, err := countries.FindCountryByAlpha(s.Sender.CountryCode)
+	if err != nil {
+		err = CountryCodeError{CountryCode: s.Sender.CountryCode}
+		return
+	}
+
+	receiver, err := countries.FindCountryByAlpha(s.Receiver.CountryCode)
+	if err != nil {
+		err = CountryCodeError{CountryCode: s.Receiver.CountryCode}
+		return
+	}
+
+	senderCode := strings.ToUpper(sender.Alpha2)
+	_, senderHasRate := r.Rates[senderCode]
+
+	switch {
+	case sender.Alpha2 == receiver.Alpha2 && senderHasRate:
+		tax.Rule, tax.CountryCode = TaxRuleDomestic, senderCode
+	case !euMember(sender):
+		tax.Rule = TaxRuleOutsideScope
+	case !euMember(receiver):
+		tax.Rule = TaxRuleExport
+	case s.Sender.VATNumber != "":
+		tax.Rule = TaxRuleIntraEUB2B
+	default:
+		tax.Rule, tax.CountryCode = TaxRuleIntraEUB2C, senderCode
+	}
+
+	tax.Net = s.Package.Price
+
+	if tax.CountryCode != "" {
+		tax.Rate = r.Rates[tax.CountryCode]
+		tax.Amount = int(RoundHalfUp.divide(int64(tax.Net)*int64(tax.Rate), fullTaxRate))
+	}
+
+	tax.Gross = tax.Net + tax.Amount
+
+	return tax, nil
+}
+
+// euMember will return true if the country is in the EU VAT area, the
+// country data still has the United Kingdom, which left it in 2021.
+func euMember(country gountries.Country) bool {
+	return country.EuMember && country.Alpha2 != "GB"
+}
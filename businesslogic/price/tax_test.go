@@ -0,0 +1,105 @@
+package price_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lonnblad/shipment-service-backend/businesslogic/models"
+	"github.com/lonnblad/shipment-service-backend/businesslogic/price"
+)
+
+func Test_TaxRules_Calculate(t *testing.T) {
+	rules := price.DefaultTaxRules()
+	rules.Rates["NO"] = 250
+
+	tcs := []struct {
+		name      string
+		sender    string
+		receiver  string
+		vatNumber string
+		expected  models.Tax
+	}{
+		{
+			name: "Domestic", sender: "SE", receiver: "SE",
+			expected: models.Tax{Rule: price.TaxRuleDomestic, CountryCode: "SE", Rate: 250, Net: 10005, Amount: 2501, Gross: 12506},
+		},
+		{
+			name: "Domestic/B2B", sender: "DE", receiver: "DEU", vatNumber: "DE123456789",
+			expected: models.Tax{Rule: price.TaxRuleDomestic, CountryCode: "DE", Rate: 190, Net: 10005, Amount: 1901, Gross: 11906},
+		},
+		{
+			name: "Domestic/OutsideEU", sender: "NO", receiver: "NO",
+			expected: models.Tax{Rule: price.TaxRuleDomestic, CountryCode: "NO", Rate: 250, Net: 10005, Amount: 2501, Gross: 12506},
+		},
+		{
+			name: "IntraEU/B2C", sender: "FI", receiver: "SE",
+			expected: models.Tax{Rule: price.TaxRuleIntraEUB2C, CountryCode: "FI", Rate: 255, Net: 10005, Amount: 2551, Gross: 12556},
+		},
+		{
+			name: "IntraEU/B2B", sender: "SE", receiver: "DE", vatNumber: "SE556677889901",
+			expected: models.Tax{Rule: price.TaxRuleIntraEUB2B, Net: 10005, Gross: 10005},
+		},
+		{
+			name: "Export", sender: "SE", receiver: "US",
+			expected: models.Tax{Rule: price.TaxRuleExport, Net: 10005, Gross: 10005},
+		},
+		{
+			name: "Export/UnitedKingdom", sender: "DE", receiver: "GB",
+			expected: models.Tax{Rule: price.TaxRuleExport, Net: 10005, Gross: 10005},
+		},
+		{
+			name: "OutsideScope", sender: "US", receiver: "SE",
+			expected: models.Tax{Rule: price.TaxRuleOutsideScope, Net: 10005, Gross: 10005},
+		},
+		{
+			name: "OutsideScope/Domestic", sender: "US", receiver: "US",
+			expected: models.Tax{Rule: price.TaxRuleOutsideScope, Net: 10005, Gross: 10005},
+		},
+	}
+
+	for _, tc := range tcs {
+		shipment := models.Shipment{}
+		shipment.Sender.CountryCode = tc.sender
+		shipment.Sender.VATNumber = tc.vatNumber
+		shipment.Receiver.CountryCode = tc.receiver
+		shipment.Package.Price = 10005
+
+		tax, err := rules.Calculate(shipment)
+		require.NoError(t, err, tc.name)
+		assert.Equal(t, tc.expected, tax, tc.name)
+	}
+
+	shipment := models.Shipment{}
+	shipment.Sender.CountryCode = "SE"
+	shipment.Receiver.CountryCode = "XX"
+
+	_, err := rules.Calculate(shipment)
+	assert.Equal(t, price.CountryCodeError{CountryCode: "XX"}, err)
+}
+
+func Test_TaxRules_Validate(t *testing.T) {
+	assert.NoError(t, price.DefaultTaxRules().Validate())
+
+	for name, rates := range map[string]map[string]int{
+		"UnknownCountry": {"XX": 200},
+		"Alpha3":         {"NOR": 250},
+		"LowerCase":      {"no": 250},
+		"Negative":       {"NO": -1},
+		"AboveFull":      {"NO": 1001},
+	} {
+		rules := price.DefaultTaxRules()
+
+		for countryCode, rate := range rates {
+			rules.Rates[countryCode] = rate
+		}
+
+		assert.Error(t, rules.Validate(), name)
+	}
+
+	rules := price.DefaultTaxRules()
+	delete(rules.Rates, "SE")
+
+	assert.Error(t, rules.Validate())
+}
@@ -0,0 +1,149 @@
+package businesslogic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/lonnblad/shipment-service-backend/businesslogic/models"
+	"github.com/lonnblad/shipment-service-backend/businesslogic/price"
+	"github.com/lonnblad/shipment-service-backend/storage"
+	"github.com/lonnblad/shipment-service-backend/trace"
+)
+
+// CreateQuote will validate the details of the quote, calculate the
+// price and its breakdown with the rate card that is effective when the
+// quote is created, in the requested currency or the default currency of
+// the rate card, together with its VAT, and store the quote, which expires
+// after the quote time to live.
+func (bl *BusinessLogic) CreateQuote(ctx context.Context, quote models.Quote) (_ models.Quote, err error) {
+	ctx, span := trace.Tracer().Start(ctx, "businesslogic.CreateQuote")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("quote.tenant_id", quote.TenantID.String()),
+		attribute.String("quote.sender.country_code", quote.Sender.CountryCode),
+		attribute.String("quote.package.currency", quote.Package.Currency),
+	)
+
+	quote.ID = uuid.New()
+	quote.CreatedAt = time.Now()
+
+	shipment := quote.Shipment()
+
+	if err = shipment.Validate(); err != nil {
+		err = fmt.Errorf("quote was invalid: %w", err)
+		return
+	}
+
+	shipment = withNewParcelIDs(shipment)
+
+	rateCards, err := bl.rateCardsOf(ctx, quote.TenantID)
+	if err != nil {
+		return
+	}
+
+	if shipment, err = price.Apply(ctx, rateCards, bl.converter, shipment); err != nil {
+		err = fmt.Errorf("could not calculate the price of the quote: %w", err)
+		return
+	}
+
+	if shipment, err = bl.applyTax(shipment); err != nil {
+		return
+	}
+
+	quote.ExpiresAt = quote.CreatedAt.Add(bl.quoteTTL)
+	quote.Package = shipment.Package
+	quote.Parcels = shipment.Parcels
+	quote.PriceBreakdown = shipment.PriceBreakdown
+	quote.ExchangeRate = shipment.ExchangeRate
+	quote.Tax = shipment.Tax
+
+	span.SetAttributes(
+		attribute.String("quote.id", quote.ID.String()),
+		attribute.String("quote.expires_at", quote.ExpiresAt.Format(time.RFC3339)),
+		attribute.Int("quote.package.weight", quote.Package.Weight),
+		attribute.Int("quote.package.price", quote.Package.Price),
+		attribute.String("quote.package.currency", quote.Package.Currency),
+	)
+
+	if err = bl.quotes.StoreQuote(ctx, quote.ToDatalayer()); err != nil {
+		err = fmt.Errorf("could not create quote in storage: %w", err)
+		return
+	}
+
+	return quote, nil
+}
+
+// validQuote will return the quote of the shipment, a ValidationError
+// is returned if the quote doesn't exist, has expired or if the
+// shipment doesn't match what was quoted, a shipment without a
+// currency takes the currency of the quote.
+func (bl *BusinessLogic) validQuote(ctx context.Context, shipment models.Shipment) (_ models.Quote, err error) {
+	dlQuote, err := bl.quotes.GetQuote(ctx, shipment.TenantID.String(), shipment.QuoteID.String())
+	if errors.Is(err, storage.ErrNotFound) {
+		err = models.ValidationError{Err: fmt.Errorf("quote: %s doesn't exist", shipment.QuoteID)}
+		return
+	}
+
+	if err != nil {
+		err = fmt.Errorf("could not get quote: %w", err)
+		return
+	}
+
+	quote := models.Quote{}.FromDatalayer(dlQuote)
+
+	if quote.Expired(time.Now()) {
+		err = models.ValidationError{Err: fmt.Errorf(
+			"quote: %s expired at: %s", quote.ID, quote.ExpiresAt.Format(time.RFC3339),
+		)}
+
+		return
+	}
+
+	if !sameParcels(shipment.Parcels, quote.Parcels) ||
+		shipment.Sender.CountryCode != quote.Sender.CountryCode ||
+		shipment.Receiver.CountryCode != quote.Receiver.CountryCode ||
+		shipment.Sender.VATNumber != quote.Sender.VATNumber {
+		err = models.ValidationError{Err: fmt.Errorf(
+			"quote: %s doesn't match the parcels, countries and sender VAT number of the shipment", quote.ID,
+		)}
+
+		return
+	}
+
+	if shipment.Package.Currency != "" && shipment.Package.Currency != quote.Package.Currency {
+		err = models.ValidationError{Err: fmt.Errorf(
+			"quote: %s is in: %s, not in the currency: %s of the shipment", quote.ID, quote.Package.Currency, shipment.Package.Currency,
+		)}
+
+		return
+	}
+
+	return quote, nil
+}
+
+// sameParcels will return true if the parcels have the
+// same weights and dimensions, in the same order.
+func sameParcels(parcels, others []models.Parcel) bool {
+	if len(parcels) != len(others) {
+		return false
+	}
+
+	for idx := range parcels {
+		if !sameMeasures(parcels[idx].Package, others[idx].Package) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// sameMeasures will return true if the packages have the same weight and dimensions.
+func sameMeasures(pkg, other models.Package) bool {
+	return pkg.Weight == other.Weight && pkg.Length == other.Length && pkg.Width == other.Width && pkg.Height == other.Height
+}
@@ -0,0 +1,65 @@
+package businesslogic_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lonnblad/shipment-service-backend/businesslogic/models"
+	"github.com/lonnblad/shipment-service-backend/businesslogic/price"
+)
+
+func newQuote(tenantID uuid.UUID) models.Quote {
+	return models.Quote{
+		TenantID: tenantID,
+		Sender:   models.Sender{Name: "Sender", Email: "sender@example.com", Address: "Street 1", CountryCode: "SE"},
+		Receiver: models.Receiver{Name: "Receiver", Email: "receiver@example.com", Address: "Street 2", CountryCode: "DK"},
+		Package:  models.Package{Weight: 10},
+	}
+}
+
+func Test_CreateShipment_KeepsTheQuotedTax(t *testing.T) {
+	ctx := context.Background()
+	logic := newBusinessLogic(t)
+
+	quote, err := logic.CreateQuote(ctx, newQuote(uuid.New()))
+	require.NoError(t, err)
+	require.NotNil(t, quote.Tax)
+
+	// The tax rules changes after the quote was created.
+	taxRules := price.DefaultTaxRules()
+	taxRules.Rates["SE"] = 120
+	taxRules.Rates["DK"] = 120
+	logic.WithTaxRules(taxRules)
+
+	shipment := quote.Shipment()
+	shipment.Tax = nil
+
+	actual, err := logic.CreateShipment(ctx, shipment)
+	require.NoError(t, err)
+	assert.Equal(t, quote.Package, actual.Package)
+	assert.Equal(t, quote.Tax, actual.Tax)
+}
+
+func Test_CreateShipment_RejectsAQuoteWithAnotherVATNumber(t *testing.T) {
+	ctx := context.Background()
+	logic := newBusinessLogic(t)
+
+	quote := newQuote(uuid.New())
+	quote.Sender.VATNumber = "SE556677889901"
+
+	quote, err := logic.CreateQuote(ctx, quote)
+	require.NoError(t, err)
+
+	for _, vatNumber := range []string{"", "SE112233445501"} {
+		shipment := quote.Shipment()
+		shipment.Tax = nil
+		shipment.Sender.VATNumber = vatNumber
+
+		_, err = logic.CreateShipment(ctx, shipment)
+		assert.ErrorAs(t, err, &models.ValidationError{}, vatNumber)
+	}
+}
@@ -0,0 +1,245 @@
+package businesslogic
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/lonnblad/shipment-service-backend/businesslogic/models"
+	"github.com/lonnblad/shipment-service-backend/businesslogic/price"
+	"github.com/lonnblad/shipment-service-backend/storage"
+	"github.com/lonnblad/shipment-service-backend/trace"
+)
+
+// UploadRateCard will validate and store the YAML or JSON rate card of the
+// tenant, which overrides the default rate cards for the shipments of the
+// tenant that are created after it is effective. A rate card without weight
+// bands or regions uses the weight bands or regions of the default rate card
+// that is effective from the same date. A rate card can't be effective from
+// before it is uploaded, as that would change the price rules of shipments
+// that have already been created.
+func (bl *BusinessLogic) UploadRateCard(
+	ctx context.Context, tenantID uuid.UUID, document []byte,
+) (_ models.RateCard, err error) {
+	ctx, span := trace.Tracer().Start(ctx, "businesslogic.UploadRateCard")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant_id", tenantID.String()),
+	)
+
+	rateCard, err := bl.priceRules.Override(document)
+	if err != nil {
+		err = models.ValidationError{Err: err}
+		return
+	}
+
+	uploadedAt := time.Now()
+
+	if rateCard.EffectiveFrom.Before(uploadedAt) {
+		err = models.ValidationError{
+			Err: fmt.Errorf("effectiveFrom: %s is before the upload time: %s",
+				rateCard.EffectiveFrom.Format(time.RFC3339), uploadedAt.Format(time.RFC3339)),
+		}
+
+		return
+	}
+
+	internal := models.RateCard{
+		TenantID:      tenantID,
+		Version:       rateCard.Version,
+		EffectiveFrom: rateCard.EffectiveFrom,
+		UploadedAt:    uploadedAt,
+		Document:      document,
+	}
+
+	span.SetAttributes(
+		attribute.String("rate_card.version", internal.Version),
+	)
+
+	if err = bl.rateCards.StoreRateCard(ctx, internal.ToDatalayer()); err != nil {
+		err = fmt.Errorf("could not store rate card: %w", err)
+		return
+	}
+
+	bl.rateCardCache.invalidate(tenantID)
+
+	return internal, nil
+}
+
+// ListRateCards will return the rate cards of the tenant,
+// ordered by when they were uploaded.
+func (bl *BusinessLogic) ListRateCards(ctx context.Context, tenantID uuid.UUID) (_ models.RateCards, err error) {
+	ctx, span := trace.Tracer().Start(ctx, "businesslogic.ListRateCards")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant_id", tenantID.String()),
+	)
+
+	dlRateCards, err := bl.rateCards.ListRateCards(ctx, tenantID.String())
+	if err != nil {
+		err = fmt.Errorf("could not list rate cards: %w", err)
+		return
+	}
+
+	return models.RateCards{}.FromDatalayer(dlRateCards), nil
+}
+
+// PreviewRateCard will return how the prices of a sample of the latest
+// shipments of the tenant would change if they were priced with the YAML
+// or JSON rate card, which isn't stored. The rate card is validated in
+// the same way as when it is uploaded.
+func (bl *BusinessLogic) PreviewRateCard(
+	ctx context.Context, tenantID uuid.UUID, document []byte, sampleSize int,
+) (_ models.RateCardPreview, err error) {
+	ctx, span := trace.Tracer().Start(ctx, "businesslogic.PreviewRateCard")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant_id", tenantID.String()),
+		attribute.Int("sample_size", sampleSize),
+	)
+
+	rateCard, err := bl.priceRules.Override(document)
+	if err != nil {
+		err = models.ValidationError{Err: err}
+		return
+	}
+
+	query := models.ListShipmentsQuery{Limit: sampleSize, Sort: storage.Sort{Descending: true}}
+
+	dlPage, err := bl.storage.ListShipments(ctx, tenantID.String(), query.ToDatalayer())
+	if err != nil {
+		err = fmt.Errorf("could not list shipments: %w", err)
+		return
+	}
+
+	shipments := models.Shipments{}.FromDatalayer(dlPage.Shipments)
+	preview := models.RateCardPreview{Version: rateCard.Version, EffectiveFrom: rateCard.EffectiveFrom}
+
+	for _, shipment := range shipments {
+		pricePreview := models.PricePreview{Shipment: shipment}
+
+		// A shipment that the rate card can't price is part of the preview,
+		// the preview price is in the currency of the shipment.
+		if priced, calculateErr := rateCard.Apply(ctx, bl.converter, shipment); calculateErr != nil {
+			pricePreview.Err = calculateErr
+		} else {
+			pricePreview.Price = priced.Package.Price
+			pricePreview.PriceBreakdown = priced.PriceBreakdown
+			pricePreview.ExchangeRate = priced.ExchangeRate
+		}
+
+		preview.Prices = append(preview.Prices, pricePreview)
+	}
+
+	span.SetAttributes(
+		attribute.String("rate_card.version", preview.Version),
+		attribute.Int("shipments", len(preview.Prices)),
+	)
+
+	return preview, nil
+}
+
+// rateCardsOf will return the history of the rate cards that price the
+// shipments of the tenant, which are the default rate cards until the
+// first of the rate cards of the tenant is effective. The history is
+// cached, so the rate cards of the tenant are only parsed again when
+// they have changed.
+func (bl *BusinessLogic) rateCardsOf(ctx context.Context, tenantID uuid.UUID) (_ price.RateCards, err error) {
+	dlRateCards, err := bl.rateCards.ListRateCards(ctx, tenantID.String())
+	if err != nil {
+		err = fmt.Errorf("could not list rate cards: %w", err)
+		return
+	}
+
+	if rateCards, ok := bl.rateCardCache.get(tenantID, dlRateCards); ok {
+		return rateCards, nil
+	}
+
+	overrides := make([]*price.RateCard, len(dlRateCards))
+
+	for idx, dlRateCard := range dlRateCards {
+		if overrides[idx], err = bl.priceRules.Override(dlRateCard.Document); err != nil {
+			err = fmt.Errorf("could not parse rate card: %s: %w", dlRateCard.Version, err)
+			return
+		}
+	}
+
+	rateCards, err := bl.priceRules.OverriddenBy(overrides...)
+	if err != nil {
+		err = fmt.Errorf("could not create the rate card history of the tenant: %w", err)
+		return
+	}
+
+	bl.rateCardCache.set(tenantID, dlRateCards, rateCards)
+
+	return rateCards, nil
+}
+
+// rateCardCache is a cache of the parsed rate card history of every tenant.
+// An entry is invalidated when a rate card is uploaded, and as the rate
+// cards of a tenant are only ever added, an entry is also stale when the
+// number of stored rate cards or the latest version differs, which is the
+// case when a rate card was uploaded through another instance.
+type rateCardCache struct {
+	mutex   sync.Mutex
+	entries map[uuid.UUID]rateCardCacheEntry
+}
+
+type rateCardCacheEntry struct {
+	count         int
+	latestVersion string
+	rateCards     price.RateCards
+}
+
+func newRateCardCache() *rateCardCache {
+	return &rateCardCache{entries: map[uuid.UUID]rateCardCacheEntry{}}
+}
+
+// get will return the cached history of the tenant,
+// ok is false when it isn't cached or is stale.
+func (c *rateCardCache) get(tenantID uuid.UUID, stored []storage.RateCard) (_ price.RateCards, ok bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	entry, ok := c.entries[tenantID]
+	if !ok || entry.count != len(stored) || entry.latestVersion != latestVersion(stored) {
+		return nil, false
+	}
+
+	return entry.rateCards, true
+}
+
+func (c *rateCardCache) set(tenantID uuid.UUID, stored []storage.RateCard, rateCards price.RateCards) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.entries[tenantID] = rateCardCacheEntry{
+		count:         len(stored),
+		latestVersion: latestVersion(stored),
+		rateCards:     rateCards,
+	}
+}
+
+func (c *rateCardCache) invalidate(tenantID uuid.UUID) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	delete(c.entries, tenantID)
+}
+
+// latestVersion will return the version of the rate card that was
+// uploaded last, the rate cards are ordered by when they were uploaded.
+func latestVersion(stored []storage.RateCard) string {
+	if len(stored) == 0 {
+		return ""
+	}
+
+	return stored[len(stored)-1].Version
+}
@@ -0,0 +1,83 @@
+package businesslogic
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/lonnblad/shipment-service-backend/businesslogic/models"
+	"github.com/lonnblad/shipment-service-backend/trace"
+)
+
+// AppendTrackingEvent will add the event to the tracking timeline of the
+// shipment, an event without an OccurredAt is taken to occur now.
+func (bl *BusinessLogic) AppendTrackingEvent(
+	ctx context.Context, event models.TrackingEvent,
+) (_ models.TrackingEvent, err error) {
+	ctx, span := trace.Tracer().Start(ctx, "businesslogic.AppendTrackingEvent")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tracking_event.tenant_id", event.TenantID.String()),
+		attribute.String("tracking_event.shipment_id", event.ShipmentID.String()),
+		attribute.String("tracking_event.source", event.Source),
+	)
+
+	if err = event.Validate(); err != nil {
+		err = fmt.Errorf("tracking event was invalid: %w", err)
+		return
+	}
+
+	if _, err = bl.storage.GetShipment(ctx, event.TenantID.String(), event.ShipmentID.String()); err != nil {
+		err = fmt.Errorf("could not get shipment: %w", err)
+		return
+	}
+
+	event.ID = uuid.New()
+
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now()
+	}
+
+	span.SetAttributes(
+		attribute.String("tracking_event.id", event.ID.String()),
+		attribute.String("tracking_event.occurred_at", event.OccurredAt.Format(time.RFC3339)),
+	)
+
+	if err = bl.trackingEvents.AppendTrackingEvent(ctx, event.ToDatalayer()); err != nil {
+		err = fmt.Errorf("could not append tracking event in storage: %w", err)
+		return
+	}
+
+	return event, nil
+}
+
+// ListTrackingEvents will return the tracking timeline of the shipment.
+func (bl *BusinessLogic) ListTrackingEvents(
+	ctx context.Context, tenantID, shipmentID uuid.UUID,
+) (_ models.TrackingEvents, err error) {
+	ctx, span := trace.Tracer().Start(ctx, "businesslogic.ListTrackingEvents")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant_id", tenantID.String()),
+		attribute.String("shipment_id", shipmentID.String()),
+	)
+
+	// A shipment that doesn't exist is not found, rather than without events.
+	if _, err = bl.storage.GetShipment(ctx, tenantID.String(), shipmentID.String()); err != nil {
+		err = fmt.Errorf("could not get shipment: %w", err)
+		return
+	}
+
+	dlEvents, err := bl.trackingEvents.ListTrackingEvents(ctx, tenantID.String(), shipmentID.String())
+	if err != nil {
+		err = fmt.Errorf("could not list tracking events: %w", err)
+		return
+	}
+
+	return models.TrackingEvents{}.FromDatalayer(dlEvents), nil
+}
@@ -0,0 +1,164 @@
+package businesslogic
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/lonnblad/shipment-service-backend/businesslogic/models"
+	"github.com/lonnblad/shipment-service-backend/businesslogic/price"
+	"github.com/lonnblad/shipment-service-backend/trace"
+)
+
+// UpdateShipment will apply the patch to the sender, receiver and package of
+// the shipment, which is only allowed before the shipment is picked up and
+// if the precondition allows a change of the current version.
+//
+// The price is calculated again when a parcel is added, removed or has its
+// weight or dimensions changed, or when the country of the sender is changed,
+// which means that a quoted price is no longer honoured, otherwise the shipment
+// keeps its price. The price is calculated with the rate card that was
+// effective when the shipment was created, in the currency that the shipment
+// is priced in. The VAT is always calculated again, so a change of the country
+// of the receiver only changes the VAT.
+func (bl *BusinessLogic) UpdateShipment(
+	ctx context.Context, tenantID, shipmentID uuid.UUID, precondition models.Precondition, patch models.ShipmentPatch,
+) (_ models.Shipment, err error) {
+	ctx, span := trace.Tracer().Start(ctx, "businesslogic.UpdateShipment")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant_id", tenantID.String()),
+		attribute.String("shipment_id", shipmentID.String()),
+	)
+
+	dlShipment, err := bl.storage.GetShipment(ctx, tenantID.String(), shipmentID.String())
+	if err != nil {
+		err = fmt.Errorf("could not get shipment: %w", err)
+		return
+	}
+
+	shipment := models.Shipment{}.FromDatalayer(dlShipment)
+
+	if err = precondition.Check(shipment); err != nil {
+		err = fmt.Errorf("could not update shipment: %w", err)
+		return
+	}
+
+	if err = shipment.Status.Editable(); err != nil {
+		err = fmt.Errorf("could not update shipment: %w", err)
+		return
+	}
+
+	patched, err := patch.Apply(shipment)
+	if err != nil {
+		err = fmt.Errorf("could not apply the patch to the shipment: %w", err)
+		return
+	}
+
+	// Only the details can be patched, the rest is kept as it was stored.
+	parcels, parcelsChanged, err := patchParcels(shipment.Parcels, patched.Parcels)
+	if err != nil {
+		return
+	}
+
+	repriced := parcelsChanged ||
+		patched.Sender.CountryCode != shipment.Sender.CountryCode
+
+	shipment.Sender = patched.Sender
+	shipment.Receiver = patched.Receiver
+	shipment = shipment.WithParcels(parcels)
+
+	if err = shipment.Validate(); err != nil {
+		err = fmt.Errorf("shipment was invalid: %w", err)
+		return
+	}
+
+	if repriced {
+		if shipment, err = bl.repriceShipment(ctx, shipment); err != nil {
+			return
+		}
+	}
+
+	if shipment, err = bl.applyTax(shipment); err != nil {
+		return
+	}
+
+	span.SetAttributes(
+		attribute.Bool("shipment.repriced", repriced),
+		attribute.Int("shipment.package.weight", shipment.Package.Weight),
+		attribute.Int("shipment.package.price", shipment.Package.Price),
+	)
+
+	// The shipment is only updated if no one else has changed it since
+	// it was read, otherwise storage.ErrConflict is returned.
+	dlShipment, err = bl.storage.UpdateShipment(ctx, shipment.ToDatalayer())
+	if err != nil {
+		err = fmt.Errorf("could not update the shipment in storage: %w", err)
+		return
+	}
+
+	return models.Shipment{}.FromDatalayer(dlShipment), nil
+}
+
+// patchParcels will return the patched parcels, where a parcel that is kept has
+// its weight and dimensions patched and a parcel without an ID is added with a
+// new ID, together with if any parcel was added, removed or had its weight or
+// dimensions changed. A ValidationError is returned if an ID isn't the ID of a
+// parcel of the shipment, or if a parcel is kept twice.
+func patchParcels(stored, patched []models.Parcel) (parcels []models.Parcel, changed bool, err error) {
+	storedParcels := make(map[uuid.UUID]models.Parcel, len(stored))
+	for _, parcel := range stored {
+		storedParcels[parcel.ID] = parcel
+	}
+
+	changed = len(stored) != len(patched)
+	parcels = make([]models.Parcel, len(patched))
+
+	for idx, parcel := range patched {
+		if parcel.ID == uuid.Nil {
+			parcel.ID = uuid.New()
+			parcels[idx], changed = parcel, true
+
+			continue
+		}
+
+		storedParcel, ok := storedParcels[parcel.ID]
+		if !ok {
+			err = models.ValidationError{Err: fmt.Errorf("parcel: %s is not a parcel of the shipment or is kept twice", parcel.ID)}
+			return
+		}
+
+		delete(storedParcels, parcel.ID)
+
+		if !sameMeasures(storedParcel.Package, parcel.Package) {
+			storedParcel.Weight = parcel.Weight
+			storedParcel.Length, storedParcel.Width, storedParcel.Height = parcel.Length, parcel.Width, parcel.Height
+			changed = true
+		}
+
+		parcels[idx] = storedParcel
+	}
+
+	return parcels, changed, nil
+}
+
+// repriceShipment will calculate the price of the shipment again, which
+// means that a quoted price is no longer honoured.
+func (bl *BusinessLogic) repriceShipment(ctx context.Context, shipment models.Shipment) (_ models.Shipment, err error) {
+	shipment.QuoteID = uuid.Nil
+
+	rateCards, err := bl.rateCardsOf(ctx, shipment.TenantID)
+	if err != nil {
+		return
+	}
+
+	if shipment, err = price.Apply(ctx, rateCards, bl.converter, shipment); err != nil {
+		err = fmt.Errorf("could not calculate the price of the shipment: %w", err)
+		return
+	}
+
+	return shipment, nil
+}
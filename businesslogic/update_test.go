@@ -0,0 +1,71 @@
+package businesslogic_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lonnblad/shipment-service-backend/businesslogic"
+	"github.com/lonnblad/shipment-service-backend/businesslogic/models"
+	"github.com/lonnblad/shipment-service-backend/businesslogic/price"
+	memdb "github.com/lonnblad/shipment-service-backend/storage/go-memdb"
+)
+
+// patchFunc is a models.ShipmentPatch that is applied by calling it.
+type patchFunc func(models.Shipment) (models.Shipment, error)
+
+func (f patchFunc) Apply(shipment models.Shipment) (models.Shipment, error) {
+	return f(shipment)
+}
+
+func newBusinessLogic(t *testing.T) *businesslogic.BusinessLogic {
+	shipmentStorage, err := memdb.NewShipmentStorage()
+	require.NoError(t, err)
+
+	return businesslogic.New(shipmentStorage, shipmentStorage, shipmentStorage, shipmentStorage)
+}
+
+func newShipment(tenantID uuid.UUID) models.Shipment {
+	return models.Shipment{
+		TenantID: tenantID,
+		Sender:   models.Sender{Name: "Sender", Email: "sender@example.com", Address: "Street 1", CountryCode: "SE"},
+		Receiver: models.Receiver{Name: "Receiver", Email: "receiver@example.com", Address: "Street 2", CountryCode: "DK"},
+		Package:  models.Package{Weight: 10},
+	}
+}
+
+func Test_UpdateShipment_ReceiverCountryKeepsThePrice(t *testing.T) {
+	ctx := context.Background()
+	logic := newBusinessLogic(t)
+
+	created, err := logic.CreateShipment(ctx, newShipment(uuid.New()))
+	require.NoError(t, err)
+
+	require.NotNil(t, created.Tax)
+	assert.Equal(t, price.TaxRuleIntraEUB2C, created.Tax.Rule)
+
+	// The price only depends on the weight and the country of the sender,
+	// a shipment to the US is exported, which only changes the VAT.
+	updated, err := logic.UpdateShipment(ctx, created.TenantID, created.ID, nil, patchFunc(func(s models.Shipment) (models.Shipment, error) {
+		s.Receiver.CountryCode = "US"
+		return s, nil
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, "US", updated.Receiver.CountryCode)
+	assert.Equal(t, created.Package, updated.Package)
+	require.NotNil(t, updated.Tax)
+	assert.Equal(t, price.TaxRuleExport, updated.Tax.Rule)
+	assert.Equal(t, created.Tax.Net, updated.Tax.Net)
+	assert.Zero(t, updated.Tax.Amount)
+	assert.NotEqual(t, created.Tax, updated.Tax)
+
+	updated, err = logic.UpdateShipment(ctx, created.TenantID, created.ID, nil, patchFunc(func(s models.Shipment) (models.Shipment, error) {
+		s.Sender.CountryCode = "US"
+		return s, nil
+	}))
+	require.NoError(t, err)
+	assert.NotEqual(t, created.Package.Price, updated.Package.Price)
+}
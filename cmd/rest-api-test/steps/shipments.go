@@ -49,7 +49,7 @@ func decorateWithValues(createShipmentReq v1.CreateShipmentRequest, values *godo
 		case "receiver - country code":
 			createShipmentReq.Receiver.CountryCode = value
 		case "package - weight":
-			createShipmentReq.Package.Weight, err = strconv.Atoi(value)
+			createShipmentReq.Package.Weight, err = strconv.ParseFloat(value, 64)
 			if err != nil {
 				return
 			}
@@ -87,7 +87,8 @@ func (state *sharedState) theReturnedShipmentShouldHave(arg1 *godog.Table) error
 		switch key {
 		case "package - price":
 			expectedPrice := value
-			actualPrice := strconv.Itoa(createShipmentResp.Shipment.Package.Price.Amount)
+			price := createShipmentResp.Shipment.Package.Price
+			actualPrice := formatAmount(price.Amount, price.DecimalMultiplier)
 
 			if expectedPrice != actualPrice {
 				return fmt.Errorf("expected price: [%s] and actual price: [%s] are not equal", expectedPrice, actualPrice)
@@ -100,6 +101,22 @@ func (state *sharedState) theReturnedShipmentShouldHave(arg1 *godog.Table) error
 	return nil
 }
 
+// formatAmount will format an amount in minor units as a decimal in units,
+// which has no decimals when the amount is a whole number of units.
+func formatAmount(amount, decimalMultiplier int) string {
+	if decimalMultiplier <= 1 {
+		return strconv.Itoa(amount)
+	}
+
+	if amount%decimalMultiplier == 0 {
+		return strconv.Itoa(amount / decimalMultiplier)
+	}
+
+	decimals := len(strconv.Itoa(decimalMultiplier)) - 1
+
+	return strconv.FormatFloat(float64(amount)/float64(decimalMultiplier), 'f', decimals, 64)
+}
+
 func (state *sharedState) theReturnedErrorShouldHave(arg1 *godog.Table) error {
 	var errResp utils.ErrorResponse
 
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"os"
 	"os/signal"
@@ -9,8 +10,11 @@ import (
 
 	"github.com/lonnblad/shipment-service-backend/boundaries/rest"
 	"github.com/lonnblad/shipment-service-backend/businesslogic"
+	"github.com/lonnblad/shipment-service-backend/businesslogic/price"
 	"github.com/lonnblad/shipment-service-backend/config"
+	"github.com/lonnblad/shipment-service-backend/storage"
 	"github.com/lonnblad/shipment-service-backend/storage/go-memdb"
+	sqlstorage "github.com/lonnblad/shipment-service-backend/storage/sql"
 	"github.com/lonnblad/shipment-service-backend/trace"
 )
 
@@ -24,13 +28,35 @@ func main() {
 
 	defer trace.Stop(ctx)
 
-	shipmentStorage, err := memdb.NewShipmentStorage()
+	shipmentStorage, closeStorage, err := newShipmentStorage(ctx)
 	if err != nil {
 		log.Println(err)
 		return
 	}
 
-	logic := businesslogic.New(shipmentStorage)
+	rateCards, err := newRateCards()
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	converter, err := newConverter()
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	cancellationFees, err := newCancellationFeeRules()
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	logic := businesslogic.New(shipmentStorage, shipmentStorage, shipmentStorage, shipmentStorage).
+		WithRateCards(rateCards).
+		WithConverter(converter).
+		WithCancellationFeeRules(cancellationFees).
+		WithQuoteTTL(config.GetQuoteTTL())
 
 	restAPI, err := rest.New(config.GetRestURL(), logic)
 	if err != nil {
@@ -38,6 +64,8 @@ func main() {
 		return
 	}
 
+	restAPI.WithShutdownHook(closeStorage)
+
 	restAPI.ListenAndServe(config.GetRestPort())
 
 	quit := make(chan os.Signal, 1)
@@ -49,3 +77,113 @@ func main() {
 
 	restAPI.Shutdown(ctx)
 }
+
+// storageBackend is implemented by all storage backends, which
+// store the shipments, their tracking events, the quotes and the rate cards.
+type storageBackend interface {
+	storage.ShipmentStorage
+	storage.TrackingEventStorage
+	storage.QuoteStorage
+	storage.RateCardStorage
+}
+
+// newShipmentStorage will return the storage backend that is configured
+// and a function which flushes and releases its resources.
+func newShipmentStorage(ctx context.Context) (storageBackend, func(context.Context) error, error) {
+	var driverName string
+
+	switch backend := config.GetStorageBackend(); backend {
+	case config.StorageBackendMemDB:
+		var opts []memdb.Option
+
+		if dataDir := config.GetMemDBDataDir(); dataDir != "" {
+			opts = append(opts, memdb.WithDurability(dataDir, config.GetMemDBSnapshotInterval()))
+		}
+
+		shipmentStorage, err := memdb.NewShipmentStorage(opts...)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return shipmentStorage, shipmentStorage.Close, nil
+	case config.StorageBackendSQLite:
+		driverName = sqlstorage.DriverSQLite
+	case config.StorageBackendPostgres:
+		driverName = sqlstorage.DriverPostgres
+	default:
+		return nil, nil, fmt.Errorf("storage backend: %s is not supported", backend)
+	}
+
+	shipmentStorage, err := sqlstorage.NewShipmentStorage(ctx, driverName, config.GetStorageDSN())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	closeStorage := func(context.Context) error {
+		return shipmentStorage.Close()
+	}
+
+	return shipmentStorage, closeStorage, nil
+}
+
+// newRateCards will return the history of the configured rate cards,
+// or the default rate cards when no rate cards are configured.
+func newRateCards() (price.RateCards, error) {
+	dir := config.GetRateCardDir()
+	if dir == "" {
+		return price.DefaultRateCards(), nil
+	}
+
+	rateCards, err := price.LoadRateCards(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the rate cards: %w", err)
+	}
+
+	return rateCards, nil
+}
+
+// newConverter will return the currency converter with the configured
+// rounding, which converts prices with the configured exchange rates.
+func newConverter() (price.Converter, error) {
+	converter := price.DefaultConverter()
+	converter.Rounding = price.Rounding(config.GetCurrencyRounding())
+
+	if err := converter.Rounding.Validate(); err != nil {
+		return price.Converter{}, fmt.Errorf("the currency rounding is invalid: %w", err)
+	}
+
+	if path := config.GetExchangeRatesFile(); path != "" {
+		exchangeRates, err := price.LoadStaticExchangeRates(path)
+		if err != nil {
+			return price.Converter{}, fmt.Errorf("failed to load the exchange rates: %w", err)
+		}
+
+		converter.Provider = exchangeRates
+	}
+
+	return converter, nil
+}
+
+// newCancellationFeeRules will return the default cancellation fee
+// rules, with the percents and minimums that are configured replaced.
+func newCancellationFeeRules() (price.CancellationFeeRules, error) {
+	rules := price.DefaultCancellationFeeRules()
+
+	for idx := range rules {
+		status := string(rules[idx].Status)
+
+		if percent, ok := config.GetCancellationFeePercent(status); ok {
+			rules[idx].Percent = percent
+		}
+
+		if minimum, ok := config.GetCancellationFeeMinimum(status); ok {
+			rules[idx].Minimum = minimum
+		}
+	}
+
+	if err := rules.Validate(); err != nil {
+		return nil, fmt.Errorf("the cancellation fee rules are invalid: %w", err)
+	}
+
+	return rules, nil
+}
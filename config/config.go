@@ -15,6 +15,11 @@ const (
 	defaultRestPort        = "8080"
 	defaultRestURL         = "http://localhost:" + defaultRestPort
 	defaultShutdownTimeout = 20 * time.Second
+	defaultStorageDSN      = "shipments.db"
+	defaultSnapshotPeriod  = 5 * time.Minute
+	defaultIdempotencyTTL  = 24 * time.Hour
+	defaultQuoteTTL        = 30 * time.Minute
+	defaultRounding        = "half-up"
 
 	configKeyEnvironment    = "environment"
 	configKeyServiceName    = "service-name"
@@ -22,6 +27,20 @@ const (
 	configKeyRestPort       = "rest-port"
 	configKeyRestURL        = "rest-url"
 	configKeyShutdownTimout = "shutdown-timeout"
+	configKeyStorageBackend = "storage-backend"
+	configKeyStorageDSN     = "storage-dsn"
+	configKeyMemDBDataDir   = "memdb-data-dir"
+	configKeyMemDBSnapshot  = "memdb-snapshot-interval"
+	configKeyIdempotencyTTL = "idempotency-key-ttl"
+	configKeyQuoteTTL       = "quote-ttl"
+	configKeyRateCardDir    = "rate-card-dir"
+	configKeyExchangeRates  = "exchange-rates-file"
+	configKeyRounding       = "currency-rounding"
+	configKeyAdminToken     = "admin-token"
+
+	configKeyPrefixCancellationFee = "cancellation-fee-"
+	configKeySuffixPercent         = "-percent"
+	configKeySuffixMinimum         = "-minimum"
 )
 
 func init() {
@@ -53,6 +72,30 @@ func init() {
 	if viper.GetDuration(configKeyShutdownTimout) == 0 {
 		viper.SetDefault(configKeyShutdownTimout, defaultShutdownTimeout)
 	}
+
+	if viper.GetString(configKeyStorageBackend) == "" {
+		viper.SetDefault(configKeyStorageBackend, StorageBackendMemDB.String())
+	}
+
+	if viper.GetString(configKeyStorageDSN) == "" {
+		viper.SetDefault(configKeyStorageDSN, defaultStorageDSN)
+	}
+
+	if viper.GetDuration(configKeyMemDBSnapshot) == 0 {
+		viper.SetDefault(configKeyMemDBSnapshot, defaultSnapshotPeriod)
+	}
+
+	if viper.GetDuration(configKeyIdempotencyTTL) == 0 {
+		viper.SetDefault(configKeyIdempotencyTTL, defaultIdempotencyTTL)
+	}
+
+	if viper.GetDuration(configKeyQuoteTTL) == 0 {
+		viper.SetDefault(configKeyQuoteTTL, defaultQuoteTTL)
+	}
+
+	if viper.GetString(configKeyRounding) == "" {
+		viper.SetDefault(configKeyRounding, defaultRounding)
+	}
 }
 
 func mustGetString(key string) string {
@@ -92,3 +135,75 @@ func GetRestURL() url.URL {
 func GetShutdownTimeout() time.Duration {
 	return viper.GetDuration(configKeyShutdownTimout)
 }
+
+func GetStorageBackend() StorageBackend {
+	return StorageBackend(strings.ToLower(mustGetString(configKeyStorageBackend)))
+}
+
+func GetStorageDSN() string {
+	return mustGetString(configKeyStorageDSN)
+}
+
+// GetMemDBDataDir returns the directory where the memdb storage keeps
+// its snapshot and write-ahead log, an empty string means that the
+// memdb storage isn't durable.
+func GetMemDBDataDir() string {
+	return viper.GetString(configKeyMemDBDataDir)
+}
+
+func GetMemDBSnapshotInterval() time.Duration {
+	return viper.GetDuration(configKeyMemDBSnapshot)
+}
+
+// GetIdempotencyKeyTTL returns for how long the response of
+// a request with an Idempotency-Key header is replayed.
+func GetIdempotencyKeyTTL() time.Duration {
+	return viper.GetDuration(configKeyIdempotencyTTL)
+}
+
+// GetRateCardDir returns the path to the directory with the YAML or JSON
+// rate cards, an empty string means that the default rate cards are used.
+func GetRateCardDir() string {
+	return viper.GetString(configKeyRateCardDir)
+}
+
+// GetExchangeRatesFile returns the path to the YAML file with the exchange
+// rates, an empty string means that prices can't be converted to another
+// currency than the currency of the rate card.
+func GetExchangeRatesFile() string {
+	return viper.GetString(configKeyExchangeRates)
+}
+
+// GetCurrencyRounding returns how a converted price is rounded to a
+// minor unit, which is one of half-up, half-even, down and up.
+func GetCurrencyRounding() string {
+	return strings.ToLower(mustGetString(configKeyRounding))
+}
+
+// GetAdminToken returns the token that is required as a Bearer token by the
+// admin endpoints, an empty string means that the admin endpoints are disabled.
+func GetAdminToken() string {
+	return viper.GetString(configKeyAdminToken)
+}
+
+// GetQuoteTTL returns for how long a quote is valid.
+func GetQuoteTTL() time.Duration {
+	return viper.GetDuration(configKeyQuoteTTL)
+}
+
+// GetCancellationFeePercent returns the configured percent of the price
+// that is kept when a shipment is cancelled in the status, e.g. the env
+// CANCELLATION_FEE_BOOKED_PERCENT, ok is false when it isn't configured.
+func GetCancellationFeePercent(status string) (percent int, ok bool) {
+	key := configKeyPrefixCancellationFee + status + configKeySuffixPercent
+	return viper.GetInt(key), viper.IsSet(key)
+}
+
+// GetCancellationFeeMinimum returns the configured minimum fee, in whole
+// units of the currency of the rate card, that is kept when a shipment is
+// cancelled in the status, e.g. the env
+// CANCELLATION_FEE_BOOKED_MINIMUM, ok is false when it isn't configured.
+func GetCancellationFeeMinimum(status string) (minimum int, ok bool) {
+	key := configKeyPrefixCancellationFee + status + configKeySuffixMinimum
+	return viper.GetInt(key), viper.IsSet(key)
+}
@@ -0,0 +1,13 @@
+package config
+
+type StorageBackend string
+
+var (
+	StorageBackendMemDB    StorageBackend = "memdb"
+	StorageBackendSQLite   StorageBackend = "sqlite"
+	StorageBackendPostgres StorageBackend = "postgres"
+)
+
+func (s StorageBackend) String() string {
+	return string(s)
+}
@@ -0,0 +1,157 @@
+package storage
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidCursor is returned when a cursor can't be parsed,
+// or when it is used with another sort than it was created for.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor is a position in a sorted list of shipments, where the sort
+// field Value, the creation time and the ID decide the order. A listing
+// continues after the position of the cursor, or with the page before
+// it when the cursor is Backward.
+type Cursor struct {
+	Sort      Sort
+	Value     interface{}
+	CreatedAt time.Time
+	ID        string
+	Backward  bool
+}
+
+type encodedCursor struct {
+	Field      SortField       `json:"s,omitempty"`
+	Descending bool            `json:"d,omitempty"`
+	Value      json.RawMessage `json:"v,omitempty"`
+	CreatedAt  int64           `json:"t,omitempty"`
+	ID         string          `json:"id,omitempty"`
+	Backward   bool            `json:"b,omitempty"`
+}
+
+// NewCursor will return a cursor positioned at the shipment.
+func NewCursor(sort Sort, shipment Shipment) Cursor {
+	return Cursor{Sort: sort, Value: sort.Value(shipment), CreatedAt: shipment.CreatedAt, ID: shipment.ID}
+}
+
+// NewBackwardCursor will return a cursor to the page before the shipment.
+func NewBackwardCursor(sort Sort, shipment Shipment) Cursor {
+	cursor := NewCursor(sort, shipment)
+	cursor.Backward = true
+
+	return cursor
+}
+
+// LastPageCursor will return a cursor to the last page.
+func LastPageCursor(sort Sort) Cursor {
+	return Cursor{Sort: sort, Backward: true}
+}
+
+// IsLastPage will return true if the cursor is positioned after all shipments.
+func (c Cursor) IsLastPage() bool {
+	return c.Backward && c.ID == ""
+}
+
+// String will return the cursor encoded as an opaque string.
+func (c Cursor) String() string {
+	encoded := encodedCursor{Field: c.Sort.Field, Descending: c.Sort.Descending, ID: c.ID, Backward: c.Backward}
+
+	if !c.IsLastPage() {
+		encoded.CreatedAt = c.CreatedAt.UnixNano()
+
+		if c.Value != nil {
+			encoded.Value, _ = json.Marshal(c.Value) //nolint:errcheck
+		}
+	}
+
+	bs, _ := json.Marshal(encoded) //nolint:errcheck
+
+	return base64.RawURLEncoding.EncodeToString(bs)
+}
+
+// After will return true if the shipment comes after the cursor.
+func (c Cursor) After(shipment Shipment) bool {
+	return c.Sort.compare(shipment, c) > 0
+}
+
+// Before will return true if the shipment comes before the cursor.
+func (c Cursor) Before(shipment Shipment) bool {
+	return c.IsLastPage() || c.Sort.compare(shipment, c) < 0
+}
+
+// Continues will return true if the shipment is on the side of the
+// cursor that a listing continues with.
+func (c Cursor) Continues(shipment Shipment) bool {
+	if c.Backward {
+		return c.Before(shipment)
+	}
+
+	return c.After(shipment)
+}
+
+// ParseCursor will parse a cursor returned by Cursor.String.
+func ParseCursor(s string) (_ Cursor, err error) {
+	bs, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		err = fmt.Errorf("%w: %s", ErrInvalidCursor, err.Error())
+		return
+	}
+
+	var encoded encodedCursor
+	if err = json.Unmarshal(bs, &encoded); err != nil {
+		err = fmt.Errorf("%w: %s", ErrInvalidCursor, err.Error())
+		return
+	}
+
+	sort := Sort{Field: encoded.Field, Descending: encoded.Descending}
+	if !sort.Field.Valid() {
+		err = fmt.Errorf("%w: unknown sort field: %s", ErrInvalidCursor, sort.Field)
+		return
+	}
+
+	if encoded.Backward && encoded.ID == "" {
+		return LastPageCursor(sort), nil
+	}
+
+	if _, err = uuid.Parse(encoded.ID); err != nil {
+		err = fmt.Errorf("%w: %s", ErrInvalidCursor, err.Error())
+		return
+	}
+
+	cursor := Cursor{
+		Sort:      sort,
+		CreatedAt: time.Unix(0, encoded.CreatedAt).UTC(),
+		ID:        encoded.ID,
+		Backward:  encoded.Backward,
+	}
+
+	if cursor.Value, err = decodeValue(sort.Field, encoded.Value); err != nil {
+		err = fmt.Errorf("%w: %s", ErrInvalidCursor, err.Error())
+		return
+	}
+
+	return cursor, nil
+}
+
+func decodeValue(field SortField, bs json.RawMessage) (interface{}, error) {
+	switch field {
+	case SortByPackagePrice, SortByPackageWeight:
+		var value int
+		err := json.Unmarshal(bs, &value)
+
+		return value, err
+	case SortBySenderCountryCode, SortByReceiverCountryCode:
+		var value string
+		err := json.Unmarshal(bs, &value)
+
+		return value, err
+	default:
+		return nil, nil
+	}
+}
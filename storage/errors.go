@@ -0,0 +1,16 @@
+package storage
+
+import "errors"
+
+var (
+	// ErrNotFound is returned when the requested data doesn't exist.
+	ErrNotFound = errors.New("not found")
+
+	// ErrConflict is returned when the data conflicts with
+	// data that is already stored, like a duplicate ID.
+	ErrConflict = errors.New("conflict")
+
+	// ErrUnavailable is returned when the storage can't be
+	// reached or isn't able to serve the request right now.
+	ErrUnavailable = errors.New("storage unavailable")
+)
@@ -0,0 +1,47 @@
+package storage
+
+import "time"
+
+// ShipmentsFilter selects the shipments to list, the zero value of
+// a field doesn't filter on it and all ranges are inclusive except
+// CreatedTo, which is exclusive.
+type ShipmentsFilter struct {
+	SenderCountryCode   string
+	ReceiverCountryCode string
+
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+
+	// MinWeight and MaxWeight are in grams.
+	MinWeight *int
+	MaxWeight *int
+
+	// Currency selects the shipments that are priced in the currency,
+	// MinPrice and MaxPrice are in the minor units of a currency.
+	Currency string
+	MinPrice *int
+	MaxPrice *int
+}
+
+// Matches will return true if the shipment is selected by the filter.
+func (f ShipmentsFilter) Matches(shipment Shipment) bool {
+	switch {
+	case f.SenderCountryCode != "" && shipment.Sender.CountryCode != f.SenderCountryCode:
+		return false
+	case f.ReceiverCountryCode != "" && shipment.Receiver.CountryCode != f.ReceiverCountryCode:
+		return false
+	case !f.CreatedFrom.IsZero() && shipment.CreatedAt.Before(f.CreatedFrom):
+		return false
+	case !f.CreatedTo.IsZero() && !shipment.CreatedAt.Before(f.CreatedTo):
+		return false
+	case f.Currency != "" && shipment.Package.Currency != f.Currency:
+		return false
+	}
+
+	return inRange(shipment.Package.Weight, f.MinWeight, f.MaxWeight) &&
+		inRange(shipment.Package.Price, f.MinPrice, f.MaxPrice)
+}
+
+func inRange(value int, min, max *int) bool {
+	return (min == nil || value >= *min) && (max == nil || value <= *max)
+}
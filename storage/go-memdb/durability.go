@@ -0,0 +1,496 @@
+package memdb
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/hashicorp/go-memdb"
+
+	"github.com/lonnblad/shipment-service-backend/storage"
+)
+
+const (
+	walFilename      = "shipments.wal"
+	snapshotFilename = "shipments.snapshot"
+
+	// Every record in the write-ahead log is prefixed by a header
+	// with the length of the payload and a CRC-32 of the payload.
+	walHeaderSize    = 8
+	walMaxRecordSize = 1 << 24
+
+	walOpStoreShipment       = "store_shipment"
+	walOpUpdateShipment      = "update_shipment"
+	walOpAppendTrackingEvent = "append_tracking_event"
+	walOpStoreQuote          = "store_quote"
+	walOpStoreRateCard       = "store_rate_card"
+
+	// formatVersion is the version of the format of the snapshot and the
+	// records of the write-ahead log, which are upgraded when replayed.
+	// Unversioned ones have the prices in whole SEK and the weights in kg,
+	// version 1 has the prices in the minor units of the currency of the
+	// package and version 2 has the weights in grams.
+	formatVersion              = formatVersionWeightInGrams
+	formatVersionMinorUnits    = 1
+	formatVersionWeightInGrams = 2
+
+	legacyCurrency   = "SEK"
+	legacyMinorUnits = 100
+	legacyGramsPerKg = 1000
+)
+
+var errCorruptRecord = errors.New("corrupt record")
+
+type walRecord struct {
+	Version       int                    `json:"version,omitempty"`
+	Op            string                 `json:"op"`
+	Shipment      storage.Shipment       `json:"shipment"`
+	TrackingEvent *storage.TrackingEvent `json:"trackingEvent,omitempty"`
+	Quote         *storage.Quote         `json:"quote,omitempty"`
+	RateCard      *storage.RateCard      `json:"rateCard,omitempty"`
+}
+
+type snapshot struct {
+	Version        int                     `json:"version,omitempty"`
+	Shipments      []storage.Shipment      `json:"shipments"`
+	TrackingEvents []storage.TrackingEvent `json:"trackingEvents,omitempty"`
+	Quotes         []storage.Quote         `json:"quotes,omitempty"`
+	RateCards      []storage.RateCard      `json:"rateCards,omitempty"`
+}
+
+// writeAheadLog is an append only file of all the writes
+// since the last snapshot was taken.
+type writeAheadLog struct {
+	dir  string
+	file *os.File
+	size int64
+}
+
+// openWriteAheadLog will restore the snapshot and replay the write-ahead log
+// found in dir into the db, and then open the write-ahead log for appending.
+//
+// A torn or corrupted tail of the write-ahead log, which is what a crash in
+// the middle of a write leaves behind, is truncated.
+func openWriteAheadLog(dir string, db *memdb.MemDB) (_ *writeAheadLog, err error) {
+	if err = os.MkdirAll(dir, 0o750); err != nil {
+		err = fmt.Errorf("failed to create the data dir: %w", err)
+		return
+	}
+
+	txn := db.Txn(writeMode)
+	defer txn.Abort()
+
+	if err = restoreSnapshot(txn, filepath.Join(dir, snapshotFilename)); err != nil {
+		return
+	}
+
+	file, err := os.OpenFile(filepath.Join(dir, walFilename), os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		err = fmt.Errorf("failed to open the write-ahead log: %w", err)
+		return
+	}
+
+	size, err := replay(txn, file)
+	if err != nil {
+		file.Close()
+		return
+	}
+
+	txn.Commit()
+
+	return &writeAheadLog{dir: dir, file: file, size: size}, nil
+}
+
+// append will write the record to the write-ahead log and
+// make sure that it has reached the disk before returning.
+func (wal *writeAheadLog) append(record walRecord) error {
+	record.Version = formatVersion
+
+	payload, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal record: %w", err)
+	}
+
+	buf := make([]byte, walHeaderSize+len(payload))
+	binary.BigEndian.PutUint32(buf[0:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(buf[4:8], crc32.ChecksumIEEE(payload))
+	copy(buf[walHeaderSize:], payload)
+
+	if _, err = wal.file.Write(buf); err == nil {
+		err = wal.file.Sync()
+	}
+
+	if err != nil {
+		// Remove what might have been written, otherwise the partial
+		// record would hide all later records when the log is replayed.
+		if truncErr := wal.rewind(wal.size); truncErr != nil {
+			log.Printf("Failed to remove partial record from the write-ahead log: %s", truncErr.Error())
+		}
+
+		return fmt.Errorf("failed to write record: %w", err)
+	}
+
+	wal.size += int64(len(buf))
+
+	return nil
+}
+
+// rewind will truncate the write-ahead log to size and
+// position the file for the next append.
+func (wal *writeAheadLog) rewind(size int64) error {
+	if err := wal.file.Truncate(size); err != nil {
+		return err
+	}
+
+	if _, err := wal.file.Seek(size, io.SeekStart); err != nil {
+		return err
+	}
+
+	wal.size = size
+
+	return nil
+}
+
+// snapshot will write all shipments, tracking events, quotes and rate cards to a new snapshot
+// and then truncate the write-ahead log, the txn needs to be a write
+// transaction so that no writes can happen in between.
+func (wal *writeAheadLog) snapshot(txn *memdb.Txn) error {
+	it, err := txn.Get(tableShipments, tableShipmentsIndexKeyShipment)
+	if err != nil {
+		return fmt.Errorf("could not look up shipments: %w", err)
+	}
+
+	snap := snapshot{Version: formatVersion}
+	for obj := it.Next(); obj != nil; obj = it.Next() {
+		snap.Shipments = append(snap.Shipments, obj.(storage.Shipment))
+	}
+
+	if it, err = txn.Get(tableTrackingEvents, tableTrackingEventsIndexKeyEvent); err != nil {
+		return fmt.Errorf("could not look up tracking events: %w", err)
+	}
+
+	for obj := it.Next(); obj != nil; obj = it.Next() {
+		snap.TrackingEvents = append(snap.TrackingEvents, obj.(storage.TrackingEvent))
+	}
+
+	if it, err = txn.Get(tableQuotes, tableQuotesIndexKeyQuote); err != nil {
+		return fmt.Errorf("could not look up quotes: %w", err)
+	}
+
+	for obj := it.Next(); obj != nil; obj = it.Next() {
+		snap.Quotes = append(snap.Quotes, obj.(storage.Quote))
+	}
+
+	if it, err = txn.Get(tableRateCards, tableRateCardsIndexKeyVersion); err != nil {
+		return fmt.Errorf("could not look up rate cards: %w", err)
+	}
+
+	for obj := it.Next(); obj != nil; obj = it.Next() {
+		snap.RateCards = append(snap.RateCards, obj.(storage.RateCard))
+	}
+
+	if err = writeSnapshot(filepath.Join(wal.dir, snapshotFilename), snap); err != nil {
+		return err
+	}
+
+	if err = wal.rewind(0); err != nil {
+		return fmt.Errorf("failed to truncate the write-ahead log: %w", err)
+	}
+
+	return wal.file.Sync()
+}
+
+func (wal *writeAheadLog) close() error {
+	return wal.file.Close()
+}
+
+// writeSnapshot writes to a temporary file which replaces the
+// previous snapshot once it has reached the disk, so that a
+// crash never leaves a partial snapshot behind.
+func writeSnapshot(path string, snap snapshot) error {
+	tmpPath := path + ".tmp"
+
+	file, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot: %w", err)
+	}
+
+	if err = json.NewEncoder(file).Encode(snap); err == nil {
+		err = file.Sync()
+	}
+
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+
+	if err = os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to replace snapshot: %w", err)
+	}
+
+	return nil
+}
+
+func restoreSnapshot(txn *memdb.Txn, path string) error {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to open snapshot: %w", err)
+	}
+
+	defer file.Close()
+
+	var snap snapshot
+	if err = json.NewDecoder(file).Decode(&snap); err != nil {
+		return fmt.Errorf("failed to read snapshot: %w", err)
+	}
+
+	if err = checkFormatVersion(snap.Version); err != nil {
+		return fmt.Errorf("failed to restore snapshot: %w", err)
+	}
+
+	for _, shipment := range snap.Shipments {
+		if err = txn.Insert(tableShipments, upgradeShipment(shipment, snap.Version)); err != nil {
+			return fmt.Errorf("failed to restore shipment: %w", err)
+		}
+	}
+
+	for _, event := range snap.TrackingEvents {
+		if err = txn.Insert(tableTrackingEvents, event); err != nil {
+			return fmt.Errorf("failed to restore tracking event: %w", err)
+		}
+	}
+
+	for _, quote := range snap.Quotes {
+		if err = txn.Insert(tableQuotes, upgradeQuote(quote, snap.Version)); err != nil {
+			return fmt.Errorf("failed to restore quote: %w", err)
+		}
+	}
+
+	for _, rateCard := range snap.RateCards {
+		if err = txn.Insert(tableRateCards, rateCard); err != nil {
+			return fmt.Errorf("failed to restore rate card: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// replay will apply all records in the write-ahead log, leave the file
+// positioned at the end of the last complete record and return its offset.
+func replay(txn *memdb.Txn, file *os.File) (int64, error) {
+	reader := bufio.NewReader(file)
+
+	var offset int64
+
+	for {
+		record, size, err := readRecord(reader)
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			log.Printf("Truncating the write-ahead log at offset %d: %s", offset, err.Error())
+
+			if err = file.Truncate(offset); err != nil {
+				return 0, fmt.Errorf("failed to truncate the write-ahead log: %w", err)
+			}
+
+			break
+		}
+
+		if err = applyRecord(txn, record); err != nil {
+			return 0, err
+		}
+
+		offset += size
+	}
+
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return 0, fmt.Errorf("failed to seek in the write-ahead log: %w", err)
+	}
+
+	return offset, nil
+}
+
+// readRecord will return io.EOF when there are no more records,
+// a torn or corrupted record will return errCorruptRecord.
+func readRecord(reader io.Reader) (record walRecord, size int64, err error) {
+	header := make([]byte, walHeaderSize)
+
+	if _, err = io.ReadFull(reader, header); err != nil {
+		if err != io.EOF {
+			err = fmt.Errorf("%w: torn header: %s", errCorruptRecord, err.Error())
+		}
+
+		return
+	}
+
+	length := binary.BigEndian.Uint32(header[0:4])
+	checksum := binary.BigEndian.Uint32(header[4:8])
+
+	if length > walMaxRecordSize {
+		err = fmt.Errorf("%w: length: %d is above max: %d", errCorruptRecord, length, walMaxRecordSize)
+		return
+	}
+
+	payload := make([]byte, length)
+
+	if _, err = io.ReadFull(reader, payload); err != nil {
+		err = fmt.Errorf("%w: torn payload: %s", errCorruptRecord, err.Error())
+		return
+	}
+
+	if crc32.ChecksumIEEE(payload) != checksum {
+		err = fmt.Errorf("%w: checksum mismatch", errCorruptRecord)
+		return
+	}
+
+	if err = json.Unmarshal(payload, &record); err != nil {
+		err = fmt.Errorf("%w: %s", errCorruptRecord, err.Error())
+		return
+	}
+
+	return record, int64(walHeaderSize) + int64(length), nil
+}
+
+func applyRecord(txn *memdb.Txn, record walRecord) error {
+	if err := checkFormatVersion(record.Version); err != nil {
+		return fmt.Errorf("failed to replay record: %w", err)
+	}
+
+	switch record.Op {
+	case walOpStoreShipment, walOpUpdateShipment:
+		// An insert of an existing shipment replaces it.
+		if err := txn.Insert(tableShipments, upgradeShipment(record.Shipment, record.Version)); err != nil {
+			return fmt.Errorf("failed to replay shipment: %w", err)
+		}
+	case walOpAppendTrackingEvent:
+		if record.TrackingEvent == nil {
+			return fmt.Errorf("tracking event record is missing the tracking event")
+		}
+
+		if err := txn.Insert(tableTrackingEvents, *record.TrackingEvent); err != nil {
+			return fmt.Errorf("failed to replay tracking event: %w", err)
+		}
+	case walOpStoreQuote:
+		if record.Quote == nil {
+			return fmt.Errorf("quote record is missing the quote")
+		}
+
+		if err := txn.Insert(tableQuotes, upgradeQuote(*record.Quote, record.Version)); err != nil {
+			return fmt.Errorf("failed to replay quote: %w", err)
+		}
+	case walOpStoreRateCard:
+		if record.RateCard == nil {
+			return fmt.Errorf("rate card record is missing the rate card")
+		}
+
+		if err := txn.Insert(tableRateCards, *record.RateCard); err != nil {
+			return fmt.Errorf("failed to replay rate card: %w", err)
+		}
+	default:
+		return fmt.Errorf("unsupported write-ahead log operation: %s", record.Op)
+	}
+
+	return nil
+}
+
+// checkFormatVersion will return an error for a version that is newer than
+// the format version, as it was written by a newer version of the service.
+func checkFormatVersion(version int) error {
+	if version > formatVersion {
+		return fmt.Errorf("format version: %d is newer than the supported version: %d", version, formatVersion)
+	}
+
+	return nil
+}
+
+// upgradeShipment will convert a shipment of an earlier format version to
+// the current one, which is what the sql implementation migrates the stored
+// shipments to.
+func upgradeShipment(shipment storage.Shipment, version int) storage.Shipment {
+	if version < formatVersionMinorUnits {
+		shipment.Package.Currency = legacyCurrency
+		shipment.Package.Price *= legacyMinorUnits
+		shipment.PriceBreakdown = upgradePriceBreakdownPrices(shipment.PriceBreakdown)
+
+		if shipment.Cancellation != nil {
+			cancellation := *shipment.Cancellation
+			cancellation.Fee *= legacyMinorUnits
+			cancellation.Refund *= legacyMinorUnits
+			shipment.Cancellation = &cancellation
+		}
+	}
+
+	if version < formatVersionWeightInGrams {
+		shipment.Package.Weight *= legacyGramsPerKg
+		shipment.PriceBreakdown = upgradePriceBreakdownWeights(shipment.PriceBreakdown)
+	}
+
+	return shipment
+}
+
+// upgradeQuote will convert a quote of an earlier
+// format version to the current one.
+func upgradeQuote(quote storage.Quote, version int) storage.Quote {
+	if version < formatVersionMinorUnits {
+		quote.Package.Currency = legacyCurrency
+		quote.Package.Price *= legacyMinorUnits
+		quote.PriceBreakdown = upgradePriceBreakdownPrices(quote.PriceBreakdown)
+	}
+
+	if version < formatVersionWeightInGrams {
+		quote.Package.Weight *= legacyGramsPerKg
+		quote.PriceBreakdown = upgradePriceBreakdownWeights(quote.PriceBreakdown)
+	}
+
+	return quote
+}
+
+// upgradePriceBreakdownPrices will return a copy of an unversioned
+// breakdown with the amounts in the minor units of SEK.
+func upgradePriceBreakdownPrices(breakdown *storage.PriceBreakdown) *storage.PriceBreakdown {
+	if breakdown == nil {
+		return nil
+	}
+
+	upgraded := *breakdown
+	upgraded.Currency = legacyCurrency
+	upgraded.BasePrice *= legacyMinorUnits
+	upgraded.Price *= legacyMinorUnits
+	upgraded.Adjustments = append([]storage.PriceAdjustment(nil), breakdown.Adjustments...)
+
+	for idx := range upgraded.Adjustments {
+		upgraded.Adjustments[idx].Amount *= legacyMinorUnits
+	}
+
+	return &upgraded
+}
+
+// upgradePriceBreakdownWeights will return a copy of a breakdown
+// of a version before 2 with the weights in grams.
+func upgradePriceBreakdownWeights(breakdown *storage.PriceBreakdown) *storage.PriceBreakdown {
+	if breakdown == nil {
+		return nil
+	}
+
+	upgraded := *breakdown
+	upgraded.VolumetricWeight *= legacyGramsPerKg
+	upgraded.ChargeableWeight *= legacyGramsPerKg
+
+	return &upgraded
+}
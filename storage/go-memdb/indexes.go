@@ -0,0 +1,154 @@
+package memdb
+
+import (
+	"encoding/binary"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+const (
+	int64IndexSize = 8
+	// Flipping the sign bit makes the big endian encoding of a
+	// signed integer sort in the same order as the integer.
+	int64IndexSignBit = 1 << 63
+)
+
+// timeFieldIndex is used to extract a time.Time field from an object
+// using reflection and builds an index on it, the index is ordered by
+// the time with nanosecond precision.
+type timeFieldIndex struct {
+	Field string
+}
+
+func (t *timeFieldIndex) FromObject(obj interface{}) (bool, []byte, error) {
+	fv, err := fieldByPath(obj, t.Field)
+	if err != nil {
+		return false, nil, err
+	}
+
+	value, ok := fv.Interface().(time.Time)
+	if !ok {
+		return false, nil, fmt.Errorf("field '%s' is not a time.Time", t.Field)
+	}
+
+	return true, encodeInt64(value.UnixNano()), nil
+}
+
+func (t *timeFieldIndex) FromArgs(args ...interface{}) ([]byte, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("must provide only a single argument")
+	}
+
+	value, ok := args[0].(time.Time)
+	if !ok {
+		return nil, fmt.Errorf("argument must be a time.Time: %#v", args[0])
+	}
+
+	return encodeInt64(value.UnixNano()), nil
+}
+
+// intFieldIndex is used to extract an int field from an object using
+// reflection and builds an index on it, the index is ordered by the
+// value of the int, which makes it usable for range scans.
+//
+// The Field can be the path to a field in a nested struct, e.g. Package.Weight.
+type intFieldIndex struct {
+	Field string
+}
+
+func (i *intFieldIndex) FromObject(obj interface{}) (bool, []byte, error) {
+	fv, err := fieldByPath(obj, i.Field)
+	if err != nil {
+		return false, nil, err
+	}
+
+	if fv.Kind() != reflect.Int {
+		return false, nil, fmt.Errorf("field '%s' is not an int", i.Field)
+	}
+
+	return true, encodeInt64(fv.Int()), nil
+}
+
+func (i *intFieldIndex) FromArgs(args ...interface{}) ([]byte, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("must provide only a single argument")
+	}
+
+	value, ok := args[0].(int)
+	if !ok {
+		return nil, fmt.Errorf("argument must be an int: %#v", args[0])
+	}
+
+	return encodeInt64(int64(value)), nil
+}
+
+// stringFieldIndex is used to extract a string field from an object
+// using reflection and builds an index on it.
+//
+// The Field can be the path to a field in a nested struct, e.g. Sender.CountryCode.
+type stringFieldIndex struct {
+	Field string
+}
+
+func (s *stringFieldIndex) FromObject(obj interface{}) (bool, []byte, error) {
+	fv, err := fieldByPath(obj, s.Field)
+	if err != nil {
+		return false, nil, err
+	}
+
+	if fv.Kind() != reflect.String {
+		return false, nil, fmt.Errorf("field '%s' is not a string", s.Field)
+	}
+
+	return true, encodeString(fv.String()), nil
+}
+
+func (s *stringFieldIndex) FromArgs(args ...interface{}) ([]byte, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("must provide only a single argument")
+	}
+
+	value, ok := args[0].(string)
+	if !ok {
+		return nil, fmt.Errorf("argument must be a string: %#v", args[0])
+	}
+
+	return encodeString(value), nil
+}
+
+// PrefixFromArgs only matches the complete string, which lets
+// the index be the last prefix argument of a compound index.
+func (s *stringFieldIndex) PrefixFromArgs(args ...interface{}) ([]byte, error) {
+	return s.FromArgs(args...)
+}
+
+func fieldByPath(obj interface{}, path string) (reflect.Value, error) {
+	fv := reflect.Indirect(reflect.ValueOf(obj))
+
+	for _, field := range strings.Split(path, ".") {
+		if fv.Kind() != reflect.Struct {
+			return reflect.Value{}, fmt.Errorf("field '%s' for %#v is invalid", path, obj)
+		}
+
+		if fv = fv.FieldByName(field); !fv.IsValid() {
+			return reflect.Value{}, fmt.Errorf("field '%s' for %#v is invalid", path, obj)
+		}
+	}
+
+	return fv, nil
+}
+
+func encodeInt64(value int64) []byte {
+	buf := make([]byte, int64IndexSize)
+	binary.BigEndian.PutUint64(buf, uint64(value)^int64IndexSignBit)
+
+	return buf
+}
+
+// encodeString will null terminate the string, so
+// that no value is the prefix of another value.
+func encodeString(value string) []byte {
+	return []byte(value + "\x00")
+}
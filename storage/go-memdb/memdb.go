@@ -3,25 +3,68 @@ package memdb
 import (
 	"context"
 	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
 
 	"github.com/hashicorp/go-memdb"
 	"go.opentelemetry.io/otel/attribute"
 
 	"github.com/lonnblad/shipment-service-backend/storage"
+	"github.com/lonnblad/shipment-service-backend/storage/search"
 	"github.com/lonnblad/shipment-service-backend/trace"
 )
 
-var _ storage.ShipmentStorage = &ShipmentStorage{}
+var (
+	_ storage.ShipmentStorage      = &ShipmentStorage{}
+	_ storage.TrackingEventStorage = &ShipmentStorage{}
+	_ storage.QuoteStorage         = &ShipmentStorage{}
+	_ storage.RateCardStorage      = &ShipmentStorage{}
+)
 
 const (
 	writeMode = true
 	readMode  = false
 
-	tableShipments                   = "shipment"
-	tableShipmentsIndexKeyTenant     = "tenant"
-	tableShipmentsIndexFieldTenant   = "TenantID"
-	tableShipmentsIndexKeyShipment   = "id"
-	tableShipmentsIndexFieldShipment = "ID"
+	tableShipments                    = "shipment"
+	tableShipmentsIndexKeyTenant      = "tenant"
+	tableShipmentsIndexFieldTenant    = "TenantID"
+	tableShipmentsIndexKeyShipment    = "id"
+	tableShipmentsIndexFieldShipment  = "ID"
+	tableShipmentsIndexKeyCreatedAt   = "tenant_created_at"
+	tableShipmentsIndexFieldCreatedAt = "CreatedAt"
+
+	tableShipmentsIndexKeySenderCountry     = "tenant_sender_country_created_at"
+	tableShipmentsIndexFieldSenderCountry   = "Sender.CountryCode"
+	tableShipmentsIndexKeyReceiverCountry   = "tenant_receiver_country_created_at"
+	tableShipmentsIndexFieldReceiverCountry = "Receiver.CountryCode"
+	tableShipmentsIndexKeyWeight            = "tenant_weight_created_at"
+	tableShipmentsIndexFieldWeight          = "Package.Weight"
+	tableShipmentsIndexKeyPrice             = "tenant_price_created_at"
+	tableShipmentsIndexFieldPrice           = "Package.Price"
+
+	tableTrackingEvents                     = "tracking_event"
+	tableTrackingEventsIndexKeyEvent        = "id"
+	tableTrackingEventsIndexFieldEvent      = "ID"
+	tableTrackingEventsIndexFieldTenant     = "TenantID"
+	tableTrackingEventsIndexFieldShipment   = "ShipmentID"
+	tableTrackingEventsIndexKeyOccurredAt   = "tenant_shipment_occurred_at"
+	tableTrackingEventsIndexFieldOccurredAt = "OccurredAt"
+
+	tableQuotes                 = "quote"
+	tableQuotesIndexKeyQuote    = "id"
+	tableQuotesIndexFieldID     = "ID"
+	tableQuotesIndexFieldTenant = "TenantID"
+
+	tableRateCards                     = "rate_card"
+	tableRateCardsIndexKeyVersion      = "id"
+	tableRateCardsIndexFieldVersion    = "Version"
+	tableRateCardsIndexFieldTenant     = "TenantID"
+	tableRateCardsIndexKeyUploadedAt   = "tenant_uploaded_at"
+	tableRateCardsIndexFieldUploadedAt = "UploadedAt"
+
+	prefixSuffix = "_prefix"
 )
 
 // Create the DB schema
@@ -45,25 +88,255 @@ var schema = &memdb.DBSchema{
 					Unique:  false,
 					Indexer: &memdb.UUIDFieldIndex{Field: tableShipmentsIndexFieldTenant},
 				},
+				// The ID is part of the index to order shipments created at the
+				// same time and to make the index unique.
+				tableShipmentsIndexKeyCreatedAt: {
+					Name:   tableShipmentsIndexKeyCreatedAt,
+					Unique: true,
+					Indexer: &memdb.CompoundIndex{
+						Indexes: []memdb.Indexer{
+							&memdb.UUIDFieldIndex{Field: tableShipmentsIndexFieldTenant},
+							&timeFieldIndex{Field: tableShipmentsIndexFieldCreatedAt},
+							&memdb.UUIDFieldIndex{Field: tableShipmentsIndexFieldShipment},
+						},
+					},
+				},
+				// The value indexes are in the order of the sort by their value,
+				// which is the order of the creation time index within a value.
+				tableShipmentsIndexKeySenderCountry: valueIndex(
+					tableShipmentsIndexKeySenderCountry, &stringFieldIndex{Field: tableShipmentsIndexFieldSenderCountry},
+				),
+				tableShipmentsIndexKeyReceiverCountry: valueIndex(
+					tableShipmentsIndexKeyReceiverCountry, &stringFieldIndex{Field: tableShipmentsIndexFieldReceiverCountry},
+				),
+				tableShipmentsIndexKeyWeight: valueIndex(
+					tableShipmentsIndexKeyWeight, &intFieldIndex{Field: tableShipmentsIndexFieldWeight},
+				),
+				tableShipmentsIndexKeyPrice: valueIndex(
+					tableShipmentsIndexKeyPrice, &intFieldIndex{Field: tableShipmentsIndexFieldPrice},
+				),
+			},
+		},
+		tableTrackingEvents: {
+			Name: tableTrackingEvents,
+			Indexes: map[string]*memdb.IndexSchema{
+				tableTrackingEventsIndexKeyEvent: {
+					Name:   tableTrackingEventsIndexKeyEvent,
+					Unique: true,
+					Indexer: &memdb.CompoundIndex{
+						Indexes: []memdb.Indexer{
+							&memdb.UUIDFieldIndex{Field: tableTrackingEventsIndexFieldTenant},
+							&memdb.UUIDFieldIndex{Field: tableTrackingEventsIndexFieldShipment},
+							&memdb.UUIDFieldIndex{Field: tableTrackingEventsIndexFieldEvent},
+						},
+					},
+				},
+				// The ID is part of the index to order events that occurred
+				// at the same time and to make the index unique.
+				tableTrackingEventsIndexKeyOccurredAt: {
+					Name:   tableTrackingEventsIndexKeyOccurredAt,
+					Unique: true,
+					Indexer: &memdb.CompoundIndex{
+						Indexes: []memdb.Indexer{
+							&memdb.UUIDFieldIndex{Field: tableTrackingEventsIndexFieldTenant},
+							&memdb.UUIDFieldIndex{Field: tableTrackingEventsIndexFieldShipment},
+							&timeFieldIndex{Field: tableTrackingEventsIndexFieldOccurredAt},
+							&memdb.UUIDFieldIndex{Field: tableTrackingEventsIndexFieldEvent},
+						},
+					},
+				},
+			},
+		},
+		tableQuotes: {
+			Name: tableQuotes,
+			Indexes: map[string]*memdb.IndexSchema{
+				tableQuotesIndexKeyQuote: {
+					Name:   tableQuotesIndexKeyQuote,
+					Unique: true,
+					Indexer: &memdb.CompoundIndex{
+						Indexes: []memdb.Indexer{
+							&memdb.UUIDFieldIndex{Field: tableQuotesIndexFieldTenant},
+							&memdb.UUIDFieldIndex{Field: tableQuotesIndexFieldID},
+						},
+					},
+				},
+			},
+		},
+		tableRateCards: {
+			Name: tableRateCards,
+			Indexes: map[string]*memdb.IndexSchema{
+				tableRateCardsIndexKeyVersion: {
+					Name:   tableRateCardsIndexKeyVersion,
+					Unique: true,
+					Indexer: &memdb.CompoundIndex{
+						Indexes: []memdb.Indexer{
+							&memdb.UUIDFieldIndex{Field: tableRateCardsIndexFieldTenant},
+							&memdb.StringFieldIndex{Field: tableRateCardsIndexFieldVersion},
+						},
+					},
+				},
+				// The version is part of the index to order rate cards that were
+				// uploaded at the same time and to make the index unique.
+				tableRateCardsIndexKeyUploadedAt: {
+					Name:   tableRateCardsIndexKeyUploadedAt,
+					Unique: true,
+					Indexer: &memdb.CompoundIndex{
+						Indexes: []memdb.Indexer{
+							&memdb.UUIDFieldIndex{Field: tableRateCardsIndexFieldTenant},
+							&timeFieldIndex{Field: tableRateCardsIndexFieldUploadedAt},
+							&memdb.StringFieldIndex{Field: tableRateCardsIndexFieldVersion},
+						},
+					},
+				},
 			},
 		},
 	},
 }
 
-// ShipmentStorage implements storage.ShipmentStorage
+func valueIndex(name string, value memdb.Indexer) *memdb.IndexSchema {
+	return &memdb.IndexSchema{
+		Name:   name,
+		Unique: true,
+		Indexer: &memdb.CompoundIndex{
+			Indexes: []memdb.Indexer{
+				&memdb.UUIDFieldIndex{Field: tableShipmentsIndexFieldTenant},
+				value,
+				&timeFieldIndex{Field: tableShipmentsIndexFieldCreatedAt},
+				&memdb.UUIDFieldIndex{Field: tableShipmentsIndexFieldShipment},
+			},
+		},
+	}
+}
+
+// ShipmentStorage implements storage.ShipmentStorage, storage.TrackingEventStorage,
+// storage.QuoteStorage and storage.RateCardStorage
 type ShipmentStorage struct {
-	db *memdb.MemDB
+	db     *memdb.MemDB
+	wal    *writeAheadLog
+	search *search.Index
+
+	stopSnapshots chan struct{}
+	snapshotsDone sync.WaitGroup
+}
+
+// Option configures a ShipmentStorage.
+type Option func(*options)
+
+type options struct {
+	dataDir          string
+	snapshotInterval time.Duration
+}
+
+// WithDurability will make the ShipmentStorage append every write to a
+// write-ahead log in dataDir and write a compacted snapshot of all shipments
+// every snapshotInterval, a snapshotInterval of 0 disables the scheduled
+// snapshots.
+func WithDurability(dataDir string, snapshotInterval time.Duration) Option {
+	return func(opts *options) {
+		opts.dataDir = dataDir
+		opts.snapshotInterval = snapshotInterval
+	}
 }
 
 // NewShipmentStorage will return a pointer to a new in-mem ShipmentStorage
-func NewShipmentStorage() (_ *ShipmentStorage, err error) {
+//
+// When durability is enabled, the snapshot and the write-ahead log in the
+// data dir are replayed before the ShipmentStorage is returned.
+func NewShipmentStorage(opts ...Option) (_ *ShipmentStorage, err error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	db, err := memdb.NewMemDB(schema)
 	if err != nil {
 		err = fmt.Errorf("failed to create a new memdb: %w", err)
 		return
 	}
 
-	return &ShipmentStorage{db: db}, nil
+	s := &ShipmentStorage{db: db, search: search.NewIndex()}
+
+	if o.dataDir == "" {
+		return s, nil
+	}
+
+	if s.wal, err = openWriteAheadLog(o.dataDir, db); err != nil {
+		err = fmt.Errorf("failed to restore the memdb from: %s: %w", o.dataDir, err)
+		return
+	}
+
+	if err = s.indexShipments(); err != nil {
+		err = fmt.Errorf("failed to index the restored shipments: %w", err)
+		return
+	}
+
+	if o.snapshotInterval > 0 {
+		s.stopSnapshots = make(chan struct{})
+		s.snapshotsDone.Add(1)
+
+		go s.snapshotLoop(o.snapshotInterval)
+	}
+
+	return s, nil
+}
+
+// Snapshot will write a compacted snapshot of all shipments and truncate
+// the write-ahead log, it is a no-op when durability isn't enabled.
+func (s *ShipmentStorage) Snapshot(ctx context.Context) error {
+	if s.wal == nil {
+		return nil
+	}
+
+	_, span := trace.Tracer().Start(ctx, "memdb.Snapshot")
+	defer span.End()
+
+	// A write transaction blocks all writers while the snapshot is written,
+	// which guarantees that no write is lost when the log is truncated.
+	txn := s.db.Txn(writeMode)
+	defer txn.Abort()
+
+	if err := s.wal.snapshot(txn); err != nil {
+		return fmt.Errorf("failed to snapshot the memdb: %w", err)
+	}
+
+	return nil
+}
+
+// Close will stop the scheduled snapshots, write a final snapshot and
+// close the write-ahead log.
+func (s *ShipmentStorage) Close(ctx context.Context) error {
+	if s.wal == nil {
+		return nil
+	}
+
+	if s.stopSnapshots != nil {
+		close(s.stopSnapshots)
+		s.snapshotsDone.Wait()
+	}
+
+	if err := s.Snapshot(ctx); err != nil {
+		return err
+	}
+
+	return s.wal.close()
+}
+
+func (s *ShipmentStorage) snapshotLoop(interval time.Duration) {
+	defer s.snapshotsDone.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stopSnapshots:
+			return
+		case <-ticker.C:
+			if err := s.Snapshot(context.Background()); err != nil {
+				log.Printf("Scheduled snapshot failed: %s", err.Error())
+			}
+		}
+	}
 }
 
 func (s *ShipmentStorage) StoreShipment(ctx context.Context, shipment storage.Shipment) error {
@@ -78,12 +351,32 @@ func (s *ShipmentStorage) StoreShipment(ctx context.Context, shipment storage.Sh
 	txn := s.db.Txn(writeMode)
 	defer txn.Commit()
 
-	err := txn.Insert(tableShipments, shipment)
+	existing, err := txn.First(tableShipments, tableShipmentsIndexKeyShipment, shipment.TenantID, shipment.ID)
+	if err != nil {
+		txn.Abort()
+		return fmt.Errorf("could not look up shipment: %w", err)
+	}
+
+	if existing != nil {
+		txn.Abort()
+		return fmt.Errorf("shipment: %s already exists: %w", shipment.ID, storage.ErrConflict)
+	}
+
+	err = txn.Insert(tableShipments, shipment)
 	if err != nil {
 		txn.Abort()
 		return fmt.Errorf("failed to insert shipment: %w", err)
 	}
 
+	if s.wal != nil {
+		if err = s.wal.append(walRecord{Op: walOpStoreShipment, Shipment: shipment}); err != nil {
+			txn.Abort()
+			return fmt.Errorf("failed to log shipment: %s: %w", err.Error(), storage.ErrUnavailable)
+		}
+	}
+
+	s.search.Add(shipment)
+
 	return nil
 }
 
@@ -105,52 +398,227 @@ func (s *ShipmentStorage) GetShipment(ctx context.Context, tenantID, shipmentID
 	}
 
 	if obj == nil {
-		err = fmt.Errorf("could not find up shipment: %w", err)
+		err = fmt.Errorf("could not find shipment: %s: %w", shipmentID, storage.ErrNotFound)
 		return
 	}
 
 	return obj.(storage.Shipment), nil
 }
 
-func (s *ShipmentStorage) ListShipments(ctx context.Context, tenantID string, limit, offset int) (_ []storage.Shipment, err error) {
+func (s *ShipmentStorage) UpdateShipment(ctx context.Context, update storage.Shipment) (_ storage.Shipment, err error) {
+	_, span := trace.Tracer().Start(ctx, "memdb.UpdateShipment")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("shipment.tenant_id", update.TenantID),
+		attribute.String("shipment.id", update.ID),
+	)
+
+	txn := s.db.Txn(writeMode)
+	defer txn.Abort()
+
+	obj, err := txn.First(tableShipments, tableShipmentsIndexKeyShipment, update.TenantID, update.ID)
+	if err != nil {
+		err = fmt.Errorf("could not look up shipment: %w", err)
+		return
+	}
+
+	if obj == nil {
+		err = fmt.Errorf("could not find shipment: %s: %w", update.ID, storage.ErrNotFound)
+		return
+	}
+
+	// The version is checked inside of the write transaction, which
+	// makes the check and the update atomic.
+	shipment := obj.(storage.Shipment)
+	if shipment.Version != update.Version {
+		err = fmt.Errorf("shipment: %s doesn't have version: %d: %w", update.ID, update.Version, storage.ErrConflict)
+		return
+	}
+
+	shipment.Version++
+	shipment.Sender = update.Sender
+	shipment.Receiver = update.Receiver
+	shipment.Package = update.Package
+	shipment.QuoteID = update.QuoteID
+	shipment.PriceBreakdown = update.PriceBreakdown
+	shipment.RateCardVersion = update.RateCardVersion
+	shipment.ExchangeRate = update.ExchangeRate
+	shipment.Tax = update.Tax
+	shipment.Parcels = update.Parcels
+
+	if err = txn.Insert(tableShipments, shipment); err != nil {
+		err = fmt.Errorf("failed to update shipment: %w", err)
+		return
+	}
+
+	if s.wal != nil {
+		if err = s.wal.append(walRecord{Op: walOpUpdateShipment, Shipment: shipment}); err != nil {
+			err = fmt.Errorf("failed to log shipment: %s: %w", err.Error(), storage.ErrUnavailable)
+			return
+		}
+	}
+
+	txn.Commit()
+
+	s.search.Add(shipment)
+
+	return shipment, nil
+}
+
+func (s *ShipmentStorage) UpdateShipmentStatus(
+	ctx context.Context, tenantID, shipmentID string, version int, update storage.StatusUpdate,
+) (_ storage.Shipment, err error) {
+	_, span := trace.Tracer().Start(ctx, "memdb.UpdateShipmentStatus")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant_id", tenantID),
+		attribute.String("shipment_id", shipmentID),
+		attribute.Int("version", version),
+		attribute.String("status.to", update.Status),
+	)
+
+	txn := s.db.Txn(writeMode)
+	defer txn.Abort()
+
+	obj, err := txn.First(tableShipments, tableShipmentsIndexKeyShipment, tenantID, shipmentID)
+	if err != nil {
+		err = fmt.Errorf("could not look up shipment: %w", err)
+		return
+	}
+
+	if obj == nil {
+		err = fmt.Errorf("could not find shipment: %s: %w", shipmentID, storage.ErrNotFound)
+		return
+	}
+
+	shipment := obj.(storage.Shipment)
+	if shipment.Version != version {
+		err = fmt.Errorf("shipment: %s doesn't have version: %d: %w", shipmentID, version, storage.ErrConflict)
+		return
+	}
+
+	shipment.Version++
+	shipment.Status = update.Status
+
+	if update.Cancellation != nil {
+		cancellation := *update.Cancellation
+		shipment.Cancellation = &cancellation
+	}
+
+	if err = txn.Insert(tableShipments, shipment); err != nil {
+		err = fmt.Errorf("failed to update shipment: %w", err)
+		return
+	}
+
+	if s.wal != nil {
+		if err = s.wal.append(walRecord{Op: walOpUpdateShipment, Shipment: shipment}); err != nil {
+			err = fmt.Errorf("failed to log shipment: %s: %w", err.Error(), storage.ErrUnavailable)
+			return
+		}
+	}
+
+	txn.Commit()
+
+	return shipment, nil
+}
+
+func (s *ShipmentStorage) ListShipments(
+	ctx context.Context, tenantID string, query storage.ListShipmentsQuery,
+) (_ storage.ShipmentsPage, err error) {
 	_, span := trace.Tracer().Start(ctx, "memdb.ListShipments")
 	defer span.End()
 
 	span.SetAttributes(
 		attribute.String("tenant_id", tenantID),
-		attribute.Int("limit", limit),
-		attribute.Int("offset", offset),
+		attribute.Int("limit", query.Limit),
+		attribute.Int("offset", query.Offset),
+		attribute.String("sort", string(query.Sort.Field)),
+		attribute.Bool("sort.descending", query.Sort.Descending),
 	)
 
+	if err = query.Validate(); err != nil {
+		return
+	}
+
 	txn := s.db.Txn(readMode)
+	plan := planShipments(tenantID, query.Filter, query.Sort)
+	page := storage.ShipmentsPage{Shipments: make([]storage.Shipment, 0, query.Limit)}
 
-	it, err := txn.Get(tableShipments, tableShipmentsIndexKeyTenant, tenantID)
+	if page.Total, err = plan.count(txn); err != nil {
+		err = fmt.Errorf("could not count shipments: %w", err)
+		return
+	}
+
+	if query.Limit == 0 {
+		return page, nil
+	}
+
+	shipments, hasMore, err := plan.read(txn, query.Cursor, query.Offset, query.Limit)
 	if err != nil {
 		err = fmt.Errorf("could not look up shipments: %w", err)
 		return
 	}
 
-	shipments := make([]storage.Shipment, 0, limit)
+	page.Shipments = append(page.Shipments, shipments...)
+
+	exists := func(cursor storage.Cursor) (bool, error) { return plan.has(txn, cursor) }
 
-	var limitCounter = 0
-	if limitCounter == limit {
-		return shipments, nil
+	if err = page.Paginate(query, hasMore, exists); err != nil {
+		err = fmt.Errorf("could not look up shipments: %w", err)
+		return
 	}
 
-	var offsetCounter = 0
+	return page, nil
+}
 
-	for obj := it.Next(); obj != nil; obj = it.Next() {
-		if offsetCounter++; offsetCounter <= offset {
-			continue
-		}
+func (s *ShipmentStorage) SearchShipments(
+	ctx context.Context, tenantID string, query storage.SearchShipmentsQuery,
+) (_ storage.ShipmentsPage, err error) {
+	_, span := trace.Tracer().Start(ctx, "memdb.SearchShipments")
+	defer span.End()
 
-		shipment := obj.(storage.Shipment)
-		shipments = append(shipments, shipment)
+	span.SetAttributes(
+		attribute.String("tenant_id", tenantID),
+		attribute.Int("limit", query.Limit),
+	)
+
+	txn := s.db.Txn(readMode)
+	ids := s.search.Search(tenantID, query.Text)
+	shipments := make([]storage.Shipment, 0, len(ids))
+
+	for _, id := range ids {
+		obj, err := txn.First(tableShipments, tableShipmentsIndexKeyShipment, tenantID, id)
+		if err != nil {
+			return storage.ShipmentsPage{}, fmt.Errorf("could not look up shipment: %w", err)
+		}
 
-		if limitCounter++; limitCounter == limit {
-			break
+		// A shipment is indexed before its transaction is committed.
+		if obj != nil {
+			shipments = append(shipments, obj.(storage.Shipment))
 		}
 	}
 
-	return shipments, nil
+	sort.Slice(shipments, func(i, j int) bool {
+		return storage.Sort{}.Less(shipments[i], shipments[j])
+	})
+
+	return storage.PaginateSorted(shipments, storage.ListShipmentsQuery{Limit: query.Limit, Cursor: query.Cursor})
+}
+
+// indexShipments will add all shipments to the search index.
+func (s *ShipmentStorage) indexShipments() error {
+	txn := s.db.Txn(readMode)
+
+	it, err := txn.Get(tableShipments, tableShipmentsIndexKeyShipment)
+	if err != nil {
+		return err
+	}
+
+	for obj := it.Next(); obj != nil; obj = it.Next() {
+		s.search.Add(obj.(storage.Shipment))
+	}
+
+	return nil
 }
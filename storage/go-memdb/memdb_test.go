@@ -0,0 +1,315 @@
+package memdb_test
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lonnblad/shipment-service-backend/storage"
+	memdb "github.com/lonnblad/shipment-service-backend/storage/go-memdb"
+	"github.com/lonnblad/shipment-service-backend/storage/storagetest"
+)
+
+func Test_Conformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storage.ShipmentStorage {
+		shipmentStorage, err := memdb.NewShipmentStorage()
+		require.NoError(t, err)
+
+		return shipmentStorage
+	})
+}
+
+func Test_Conformance_Durable(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storage.ShipmentStorage {
+		shipmentStorage, err := memdb.NewShipmentStorage(memdb.WithDurability(t.TempDir(), 0))
+		require.NoError(t, err)
+
+		t.Cleanup(func() {
+			assert.NoError(t, shipmentStorage.Close(context.Background()))
+		})
+
+		return shipmentStorage
+	})
+}
+
+func Test_Conformance_TrackingEvents(t *testing.T) {
+	storagetest.RunTrackingEvents(t, func(t *testing.T) storage.TrackingEventStorage {
+		shipmentStorage, err := memdb.NewShipmentStorage()
+		require.NoError(t, err)
+
+		return shipmentStorage
+	})
+}
+
+func Test_Conformance_Quotes(t *testing.T) {
+	storagetest.RunQuotes(t, func(t *testing.T) storage.QuoteStorage {
+		shipmentStorage, err := memdb.NewShipmentStorage()
+		require.NoError(t, err)
+
+		return shipmentStorage
+	})
+}
+
+func Test_Conformance_RateCards(t *testing.T) {
+	storagetest.RunRateCards(t, func(t *testing.T) storage.RateCardStorage {
+		shipmentStorage, err := memdb.NewShipmentStorage()
+		require.NoError(t, err)
+
+		return shipmentStorage
+	})
+}
+
+func Test_Durability_ReplaysSnapshotAndLog(t *testing.T) {
+	ctx := context.Background()
+	dataDir := t.TempDir()
+	tenantID := uuid.New().String()
+
+	shipmentStorage, err := memdb.NewShipmentStorage(memdb.WithDurability(dataDir, 0))
+	require.NoError(t, err)
+
+	first := storagetest.NewShipment(tenantID)
+	require.NoError(t, shipmentStorage.StoreShipment(ctx, first))
+
+	snapshotted := storagetest.NewTrackingEvent(tenantID, first.ID, time.Now())
+	require.NoError(t, shipmentStorage.AppendTrackingEvent(ctx, snapshotted))
+
+	snapshottedQuote := storagetest.NewQuote(tenantID)
+	require.NoError(t, shipmentStorage.StoreQuote(ctx, snapshottedQuote))
+
+	snapshottedRateCard := storagetest.NewRateCard(tenantID, "1", time.Now().UTC())
+	require.NoError(t, shipmentStorage.StoreRateCard(ctx, snapshottedRateCard))
+	require.NoError(t, shipmentStorage.Snapshot(ctx))
+
+	second := storagetest.NewShipment(tenantID)
+	require.NoError(t, shipmentStorage.StoreShipment(ctx, second))
+
+	first, err = shipmentStorage.UpdateShipmentStatus(ctx, tenantID, first.ID, first.Version, storage.StatusUpdate{Status: "booked"})
+	require.NoError(t, err)
+
+	logged := storagetest.NewTrackingEvent(tenantID, first.ID, time.Now())
+	require.NoError(t, shipmentStorage.AppendTrackingEvent(ctx, logged))
+
+	loggedQuote := storagetest.NewQuote(tenantID)
+	require.NoError(t, shipmentStorage.StoreQuote(ctx, loggedQuote))
+
+	loggedRateCard := storagetest.NewRateCard(tenantID, "2", time.Now().UTC())
+	require.NoError(t, shipmentStorage.StoreRateCard(ctx, loggedRateCard))
+
+	// Not closing the storage simulates a crash, the first shipment and its
+	// first tracking event are in the snapshot and the rest of the writes
+	// only in the write-ahead log.
+	shipmentStorage, err = memdb.NewShipmentStorage(memdb.WithDurability(dataDir, 0))
+	require.NoError(t, err)
+
+	assertStored(t, shipmentStorage, first, second)
+
+	events, err := shipmentStorage.ListTrackingEvents(ctx, tenantID, first.ID)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []storage.TrackingEvent{snapshotted, logged}, events)
+
+	for _, quote := range []storage.Quote{snapshottedQuote, loggedQuote} {
+		actual, err := shipmentStorage.GetQuote(ctx, tenantID, quote.ID)
+		require.NoError(t, err)
+		assert.Equal(t, quote, actual)
+	}
+
+	rateCards, err := shipmentStorage.ListRateCards(ctx, tenantID)
+	require.NoError(t, err)
+	assert.Equal(t, []storage.RateCard{snapshottedRateCard, loggedRateCard}, rateCards)
+
+	require.NoError(t, shipmentStorage.Close(ctx))
+}
+
+func Test_Durability_TruncatesTornLogTail(t *testing.T) {
+	ctx := context.Background()
+	dataDir := t.TempDir()
+	tenantID := uuid.New().String()
+
+	shipmentStorage, err := memdb.NewShipmentStorage(memdb.WithDurability(dataDir, 0))
+	require.NoError(t, err)
+
+	first := storagetest.NewShipment(tenantID)
+	require.NoError(t, shipmentStorage.StoreShipment(ctx, first))
+
+	walPath := filepath.Join(dataDir, "shipments.wal")
+
+	info, err := os.Stat(walPath)
+	require.NoError(t, err)
+
+	// A record header promising a payload that was never written.
+	wal, err := os.OpenFile(walPath, os.O_APPEND|os.O_WRONLY, 0o600)
+	require.NoError(t, err)
+	_, err = wal.Write([]byte{0, 0, 1, 0, 1, 2, 3, 4, '{', '"'})
+	require.NoError(t, err)
+	require.NoError(t, wal.Close())
+
+	shipmentStorage, err = memdb.NewShipmentStorage(memdb.WithDurability(dataDir, 0))
+	require.NoError(t, err)
+
+	truncated, err := os.Stat(walPath)
+	require.NoError(t, err)
+	assert.Equal(t, info.Size(), truncated.Size())
+
+	second := storagetest.NewShipment(tenantID)
+	require.NoError(t, shipmentStorage.StoreShipment(ctx, second))
+
+	shipmentStorage, err = memdb.NewShipmentStorage(memdb.WithDurability(dataDir, 0))
+	require.NoError(t, err)
+
+	assertStored(t, shipmentStorage, first, second)
+}
+
+func Test_Durability_UpgradesLegacyFormats(t *testing.T) {
+	ctx := context.Background()
+	dataDir := t.TempDir()
+	tenantID := uuid.New().String()
+
+	// An unversioned snapshot and write-ahead log have the prices in whole SEK and the weights in kg.
+	shipment := storagetest.NewShipment(tenantID)
+	shipment.Package = storage.Package{Weight: 10, Price: 135}
+	shipment.Cancellation = &storage.Cancellation{Fee: 20, Refund: 115}
+	shipment.PriceBreakdown = &storage.PriceBreakdown{
+		BasePrice: 150, Price: 135, Adjustments: []storage.PriceAdjustment{{Name: "discount", Amount: -15}},
+	}
+
+	snapshot, err := json.Marshal(map[string]interface{}{"shipments": []storage.Shipment{shipment}})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dataDir, "shipments.snapshot"), snapshot, 0o600))
+
+	quote := storagetest.NewQuote(tenantID)
+	quote.Package = storage.Package{Weight: 5, Price: 100}
+	quote.PriceBreakdown = nil
+
+	writeRecord(t, dataDir, map[string]interface{}{"op": "store_quote", "quote": quote})
+
+	// A record of version 1 is already in the minor units of its currency, but has the weights in kg.
+	versioned := storagetest.NewQuote(tenantID)
+	versioned.Package = storage.Package{Weight: 5, Price: 875, Currency: "EUR"}
+	versioned.PriceBreakdown.VolumetricWeight = 3
+	versioned.PriceBreakdown.ChargeableWeight = 5
+
+	writeRecord(t, dataDir, map[string]interface{}{"version": 1, "op": "store_quote", "quote": versioned})
+
+	shipmentStorage, err := memdb.NewShipmentStorage(memdb.WithDurability(dataDir, 0))
+	require.NoError(t, err)
+
+	actual, err := shipmentStorage.GetShipment(ctx, tenantID, shipment.ID)
+	require.NoError(t, err)
+	assert.Equal(t, storage.Package{Weight: 10000, Price: 13500, Currency: "SEK"}, actual.Package)
+	assert.Equal(t, &storage.Cancellation{Fee: 2000, Refund: 11500}, actual.Cancellation)
+	assert.Equal(t, &storage.PriceBreakdown{
+		Currency: "SEK", BasePrice: 15000, Price: 13500, Adjustments: []storage.PriceAdjustment{{Name: "discount", Amount: -1500}},
+	}, actual.PriceBreakdown)
+
+	actualQuote, err := shipmentStorage.GetQuote(ctx, tenantID, quote.ID)
+	require.NoError(t, err)
+	assert.Equal(t, storage.Package{Weight: 5000, Price: 10000, Currency: "SEK"}, actualQuote.Package)
+
+	actualQuote, err = shipmentStorage.GetQuote(ctx, tenantID, versioned.ID)
+	require.NoError(t, err)
+	assert.Equal(t, storage.Package{Weight: 5000, Price: 875, Currency: "EUR"}, actualQuote.Package)
+	assert.Equal(t, 3000, actualQuote.PriceBreakdown.VolumetricWeight)
+	assert.Equal(t, 5000, actualQuote.PriceBreakdown.ChargeableWeight)
+
+	// The snapshot is written with the upgraded prices and weights and isn't upgraded again.
+	require.NoError(t, shipmentStorage.Close(ctx))
+
+	shipmentStorage, err = memdb.NewShipmentStorage(memdb.WithDurability(dataDir, 0))
+	require.NoError(t, err)
+
+	actual, err = shipmentStorage.GetShipment(ctx, tenantID, shipment.ID)
+	require.NoError(t, err)
+	assert.Equal(t, storage.Package{Weight: 10000, Price: 13500, Currency: "SEK"}, actual.Package)
+	require.NoError(t, shipmentStorage.Close(ctx))
+
+	// A record of a newer format version is refused.
+	writeRecord(t, dataDir, map[string]interface{}{"version": 3, "op": "store_quote", "quote": quote})
+
+	_, err = memdb.NewShipmentStorage(memdb.WithDurability(dataDir, 0))
+	assert.Error(t, err)
+}
+
+func Test_Durability_ReplaysWeightsInGrams(t *testing.T) {
+	ctx := context.Background()
+	dataDir := t.TempDir()
+	tenantID := uuid.New().String()
+
+	// A snapshot and write-ahead log of version 2 already have the weights in grams.
+	shipment := storagetest.NewShipment(tenantID)
+	shipment.Package.Weight = 10500
+	shipment.PriceBreakdown.VolumetricWeight = 4800
+	shipment.PriceBreakdown.ChargeableWeight = 10500
+
+	snapshot, err := json.Marshal(map[string]interface{}{"version": 2, "shipments": []storage.Shipment{shipment}})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dataDir, "shipments.snapshot"), snapshot, 0o600))
+
+	quote := storagetest.NewQuote(tenantID)
+	quote.Package.Weight = 250
+
+	writeRecord(t, dataDir, map[string]interface{}{"version": 2, "op": "store_quote", "quote": quote})
+
+	updated := shipment
+	updated.Version = 2
+	updated.Package.Weight = 20500
+
+	writeRecord(t, dataDir, map[string]interface{}{"version": 2, "op": "update_shipment", "shipment": updated})
+
+	shipmentStorage, err := memdb.NewShipmentStorage(memdb.WithDurability(dataDir, 0))
+	require.NoError(t, err)
+
+	actual, err := shipmentStorage.GetShipment(ctx, tenantID, shipment.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 20500, actual.Package.Weight)
+	assert.Equal(t, 4800, actual.PriceBreakdown.VolumetricWeight)
+	assert.Equal(t, 10500, actual.PriceBreakdown.ChargeableWeight)
+
+	actualQuote, err := shipmentStorage.GetQuote(ctx, tenantID, quote.ID)
+	require.NoError(t, err)
+	assert.Equal(t, quote, actualQuote)
+}
+
+// writeRecord will append the record to the write-ahead log in dataDir.
+func writeRecord(t *testing.T, dataDir string, record interface{}) {
+	t.Helper()
+
+	payload, err := json.Marshal(record)
+	require.NoError(t, err)
+
+	header := make([]byte, 8)
+	binary.BigEndian.PutUint32(header[0:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(header[4:8], crc32.ChecksumIEEE(payload))
+
+	wal, err := os.OpenFile(filepath.Join(dataDir, "shipments.wal"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	require.NoError(t, err)
+
+	_, err = wal.Write(append(header, payload...))
+	require.NoError(t, err)
+	require.NoError(t, wal.Close())
+}
+
+func assertStored(t *testing.T, shipmentStorage storage.ShipmentStorage, expected ...storage.Shipment) {
+	t.Helper()
+
+	for _, shipment := range expected {
+		actual, err := shipmentStorage.GetShipment(context.Background(), shipment.TenantID, shipment.ID)
+		require.NoError(t, err)
+		assert.Equal(t, shipment, actual)
+
+		// The restored shipments must be searchable.
+		query := storage.SearchShipmentsQuery{Text: shipment.Receiver.Name, Limit: len(expected)}
+
+		page, err := shipmentStorage.SearchShipments(context.Background(), shipment.TenantID, query)
+		require.NoError(t, err)
+		assert.Contains(t, page.Shipments, shipment)
+	}
+}
@@ -0,0 +1,427 @@
+package memdb
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/hashicorp/go-memdb"
+
+	"github.com/lonnblad/shipment-service-backend/storage"
+)
+
+// The lowest and highest values of the creation time and the ID, used to
+// position an iterator at the first or last shipment with a value.
+const (
+	minUUID = "00000000-0000-0000-0000-000000000000"
+	maxUUID = "ffffffff-ffff-ffff-ffff-ffffffffffff"
+)
+
+var (
+	minTime = time.Unix(0, math.MinInt64)
+	maxTime = time.Unix(0, math.MaxInt64)
+)
+
+type shipmentIterator interface {
+	Next() interface{}
+}
+
+// indexScan is a scan of the tenant's shipments in an index, optionally
+// bounded by the lower and upper key of a range of the filter.
+type indexScan struct {
+	index  string
+	prefix []interface{}
+
+	// key will return the arguments after the prefix,
+	// which positions the scan at the cursor.
+	key func(storage.Cursor) []interface{}
+
+	// inScope is false for shipments outside of the prefix.
+	inScope func(storage.Shipment) bool
+
+	lower, upper []interface{}
+	belowLower   func(storage.Shipment) bool
+	aboveUpper   func(storage.Shipment) bool
+}
+
+// iterator will return an iterator over the index, positioned at the cursor when
+// there is one and in descending order when descending is true. It stops at the
+// end of the scope or the bound in the direction of the scan.
+func (s indexScan) iterator(txn *memdb.Txn, cursor *storage.Cursor, descending bool) (_ shipmentIterator, err error) {
+	args := func(values ...interface{}) []interface{} {
+		return append(append([]interface{}{}, s.prefix...), values...)
+	}
+
+	var it memdb.ResultIterator
+
+	switch {
+	case cursor != nil && !cursor.IsLastPage() && descending:
+		it, err = txn.ReverseLowerBound(tableShipments, s.index, args(s.key(*cursor)...)...)
+	case cursor != nil && !cursor.IsLastPage():
+		it, err = txn.LowerBound(tableShipments, s.index, args(s.key(*cursor)...)...)
+	case descending && s.upper != nil:
+		it, err = txn.ReverseLowerBound(tableShipments, s.index, args(s.upper...)...)
+	case descending:
+		it, err = txn.GetReverse(tableShipments, s.index+prefixSuffix, s.prefix...)
+	case s.lower != nil:
+		it, err = txn.LowerBound(tableShipments, s.index, args(s.lower...)...)
+	default:
+		it, err = txn.Get(tableShipments, s.index+prefixSuffix, s.prefix...)
+	}
+
+	if err != nil {
+		return
+	}
+
+	outOfBounds := s.aboveUpper
+	if descending {
+		outOfBounds = s.belowLower
+	}
+
+	return &boundedIterator{it: it, inScope: s.inScope, outOfBounds: outOfBounds}, nil
+}
+
+func createdAtKey(cursor storage.Cursor) []interface{} {
+	return []interface{}{cursor.CreatedAt, cursor.ID}
+}
+
+func valueKey(cursor storage.Cursor) []interface{} {
+	return []interface{}{cursor.Value, cursor.CreatedAt, cursor.ID}
+}
+
+// createdAtScan will scan the tenant's shipments in order of creation time, or
+// only the ones with the country when a country index is provided.
+func createdAtScan(tenantID string, filter storage.ShipmentsFilter, countryIndex, country string) indexScan {
+	scan := indexScan{
+		index:   tableShipmentsIndexKeyCreatedAt,
+		prefix:  []interface{}{tenantID},
+		key:     createdAtKey,
+		inScope: func(s storage.Shipment) bool { return s.TenantID == tenantID },
+	}
+
+	if countryIndex != "" {
+		countryValue := countryValues[countryIndex]
+		scan.index = countryIndex
+		scan.prefix = append(scan.prefix, country)
+		scan.inScope = func(s storage.Shipment) bool { return s.TenantID == tenantID && countryValue(s) == country }
+	}
+
+	if !filter.CreatedFrom.IsZero() {
+		scan.lower = []interface{}{filter.CreatedFrom, minUUID}
+		scan.belowLower = func(s storage.Shipment) bool { return s.CreatedAt.Before(filter.CreatedFrom) }
+	}
+
+	if !filter.CreatedTo.IsZero() {
+		scan.upper = []interface{}{filter.CreatedTo, minUUID}
+		scan.aboveUpper = func(s storage.Shipment) bool { return !s.CreatedAt.Before(filter.CreatedTo) }
+	}
+
+	return scan
+}
+
+// valueScan will scan the tenant's shipments in order of the value of the
+// index, where the range of an int value is bounded by min and max.
+func valueScan(tenantID, index string, min, max *int) indexScan {
+	scan := indexScan{
+		index:   index,
+		prefix:  []interface{}{tenantID},
+		key:     valueKey,
+		inScope: func(s storage.Shipment) bool { return s.TenantID == tenantID },
+	}
+
+	value := intValues[index]
+
+	if min != nil {
+		scan.lower = []interface{}{*min, minTime, minUUID}
+		scan.belowLower = func(s storage.Shipment) bool { return value(s) < *min }
+	}
+
+	if max != nil {
+		scan.upper = []interface{}{*max, maxTime, maxUUID}
+		scan.aboveUpper = func(s storage.Shipment) bool { return value(s) > *max }
+	}
+
+	return scan
+}
+
+var countryValues = map[string]func(storage.Shipment) string{
+	tableShipmentsIndexKeySenderCountry:   func(s storage.Shipment) string { return s.Sender.CountryCode },
+	tableShipmentsIndexKeyReceiverCountry: func(s storage.Shipment) string { return s.Receiver.CountryCode },
+}
+
+var intValues = map[string]func(storage.Shipment) int{
+	tableShipmentsIndexKeyWeight: func(s storage.Shipment) int { return s.Package.Weight },
+	tableShipmentsIndexKeyPrice:  func(s storage.Shipment) int { return s.Package.Price },
+}
+
+// sortScan will return a scan of the index in the order of the sort, and
+// true if the scan is bounded by the filter.
+func sortScan(tenantID string, filter storage.ShipmentsFilter, field storage.SortField) (indexScan, bool) {
+	switch field {
+	case storage.SortByPackagePrice:
+		return valueScan(tenantID, tableShipmentsIndexKeyPrice, filter.MinPrice, filter.MaxPrice),
+			filter.MinPrice != nil || filter.MaxPrice != nil
+	case storage.SortByPackageWeight:
+		return valueScan(tenantID, tableShipmentsIndexKeyWeight, filter.MinWeight, filter.MaxWeight),
+			filter.MinWeight != nil || filter.MaxWeight != nil
+	case storage.SortBySenderCountryCode:
+		if filter.SenderCountryCode == "" {
+			return valueScan(tenantID, tableShipmentsIndexKeySenderCountry, nil, nil), false
+		}
+
+		// Within a country, the order is the same as for the creation time.
+		return createdAtScan(tenantID, filter, tableShipmentsIndexKeySenderCountry, filter.SenderCountryCode), true
+	case storage.SortByReceiverCountryCode:
+		if filter.ReceiverCountryCode == "" {
+			return valueScan(tenantID, tableShipmentsIndexKeyReceiverCountry, nil, nil), false
+		}
+
+		return createdAtScan(tenantID, filter, tableShipmentsIndexKeyReceiverCountry, filter.ReceiverCountryCode), true
+	default:
+		if filter.SenderCountryCode != "" {
+			return createdAtScan(tenantID, filter, tableShipmentsIndexKeySenderCountry, filter.SenderCountryCode), true
+		}
+
+		if filter.ReceiverCountryCode != "" {
+			return createdAtScan(tenantID, filter, tableShipmentsIndexKeyReceiverCountry, filter.ReceiverCountryCode), true
+		}
+
+		return createdAtScan(tenantID, filter, "", ""), !filter.CreatedFrom.IsZero() || !filter.CreatedTo.IsZero()
+	}
+}
+
+// filterScan will return a scan of the index which is bounded by the filter,
+// and false if the filter doesn't bound any index.
+func filterScan(tenantID string, filter storage.ShipmentsFilter) (indexScan, bool) {
+	switch {
+	case filter.SenderCountryCode != "":
+		return createdAtScan(tenantID, filter, tableShipmentsIndexKeySenderCountry, filter.SenderCountryCode), true
+	case filter.ReceiverCountryCode != "":
+		return createdAtScan(tenantID, filter, tableShipmentsIndexKeyReceiverCountry, filter.ReceiverCountryCode), true
+	case !filter.CreatedFrom.IsZero() || !filter.CreatedTo.IsZero():
+		return createdAtScan(tenantID, filter, "", ""), true
+	case filter.MinWeight != nil || filter.MaxWeight != nil:
+		return valueScan(tenantID, tableShipmentsIndexKeyWeight, filter.MinWeight, filter.MaxWeight), true
+	case filter.MinPrice != nil || filter.MaxPrice != nil:
+		return valueScan(tenantID, tableShipmentsIndexKeyPrice, filter.MinPrice, filter.MaxPrice), true
+	default:
+		return indexScan{}, false
+	}
+}
+
+// shipmentsPlan decides how a listing of the tenant's shipments is read,
+// based on the filter and the sort of the listing.
+//
+// When the filter bounds the index of the sort, or doesn't bound any
+// index, the index of the sort is read in order. Otherwise, the shipments
+// are selected from the index bounded by the filter and sorted before
+// they are listed.
+type shipmentsPlan struct {
+	filter storage.ShipmentsFilter
+	sort   storage.Sort
+
+	scan     indexScan
+	selected []storage.Shipment
+	inMemory bool
+}
+
+func planShipments(tenantID string, filter storage.ShipmentsFilter, sort storage.Sort) *shipmentsPlan {
+	plan := &shipmentsPlan{filter: filter, sort: sort}
+
+	scan, bounded := sortScan(tenantID, filter, sort.Field)
+	plan.scan = scan
+
+	if !bounded {
+		if scan, ok := filterScan(tenantID, filter); ok {
+			plan.scan, plan.inMemory = scan, true
+		}
+	}
+
+	return plan
+}
+
+// iterator will return an iterator over the shipments selected by the filter,
+// from the cursor in its direction, or from the beginning without a cursor.
+func (p *shipmentsPlan) iterator(txn *memdb.Txn, cursor *storage.Cursor) (shipmentIterator, error) {
+	if p.inMemory {
+		shipments, err := p.selectAndSort(txn)
+		if err != nil {
+			return nil, err
+		}
+
+		return newSliceIterator(shipments, cursor), nil
+	}
+
+	backward := cursor != nil && cursor.Backward
+
+	it, err := p.scan.iterator(txn, cursor, p.sort.Descending != backward)
+	if err != nil {
+		return nil, err
+	}
+
+	return &filterIterator{it: it, filter: p.filter}, nil
+}
+
+// read will return up to limit of the shipments selected by the filter, in the order
+// they are read from the cursor, and if there are more shipments to read. The
+// offset is only used without a cursor.
+func (p *shipmentsPlan) read(
+	txn *memdb.Txn, cursor *storage.Cursor, offset, limit int,
+) (shipments []storage.Shipment, hasMore bool, err error) {
+	it, err := p.iterator(txn, cursor)
+	if err != nil {
+		return
+	}
+
+	var offsetCounter = 0
+
+	for obj := it.Next(); obj != nil; obj = it.Next() {
+		shipment := obj.(storage.Shipment)
+
+		if cursor != nil && !cursor.Continues(shipment) {
+			continue
+		}
+
+		if cursor == nil {
+			if offsetCounter++; offsetCounter <= offset {
+				continue
+			}
+		}
+
+		if len(shipments) == limit {
+			return shipments, true, nil
+		}
+
+		shipments = append(shipments, shipment)
+	}
+
+	return shipments, false, nil
+}
+
+func (p *shipmentsPlan) count(txn *memdb.Txn) (count int, err error) {
+	it, err := p.iterator(txn, nil)
+	if err != nil {
+		return
+	}
+
+	for obj := it.Next(); obj != nil; obj = it.Next() {
+		count++
+	}
+
+	return count, nil
+}
+
+// has will return true if any shipment selected by the
+// filter is continued with by a listing from the cursor.
+func (p *shipmentsPlan) has(txn *memdb.Txn, cursor storage.Cursor) (bool, error) {
+	it, err := p.iterator(txn, &cursor)
+	if err != nil {
+		return false, err
+	}
+
+	for obj := it.Next(); obj != nil; obj = it.Next() {
+		if cursor.Continues(obj.(storage.Shipment)) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// selectAndSort will return the shipments selected by the filter, in the
+// order of the sort, they are only read once per plan.
+func (p *shipmentsPlan) selectAndSort(txn *memdb.Txn) ([]storage.Shipment, error) {
+	if p.selected != nil {
+		return p.selected, nil
+	}
+
+	it, err := p.scan.iterator(txn, nil, false)
+	if err != nil {
+		return nil, err
+	}
+
+	shipments := []storage.Shipment{}
+
+	for obj := it.Next(); obj != nil; obj = it.Next() {
+		if shipment := obj.(storage.Shipment); p.filter.Matches(shipment) {
+			shipments = append(shipments, shipment)
+		}
+	}
+
+	sort.Slice(shipments, func(i, j int) bool {
+		return p.sort.Less(shipments[i], shipments[j])
+	})
+
+	p.selected = shipments
+
+	return shipments, nil
+}
+
+// boundedIterator stops at the first shipment outside
+// of the scope or the bounds of an index scan.
+type boundedIterator struct {
+	it          memdb.ResultIterator
+	inScope     func(storage.Shipment) bool
+	outOfBounds func(storage.Shipment) bool
+}
+
+func (b *boundedIterator) Next() interface{} {
+	obj := b.it.Next()
+	if obj == nil {
+		return nil
+	}
+
+	shipment := obj.(storage.Shipment)
+
+	if !b.inScope(shipment) || (b.outOfBounds != nil && b.outOfBounds(shipment)) {
+		return nil
+	}
+
+	return shipment
+}
+
+// filterIterator skips the shipments which aren't selected by the filter.
+type filterIterator struct {
+	it     shipmentIterator
+	filter storage.ShipmentsFilter
+}
+
+func (f *filterIterator) Next() interface{} {
+	for obj := f.it.Next(); obj != nil; obj = f.it.Next() {
+		if f.filter.Matches(obj.(storage.Shipment)) {
+			return obj
+		}
+	}
+
+	return nil
+}
+
+// sliceIterator iterates over sorted shipments, from the cursor in its direction.
+type sliceIterator struct {
+	shipments []storage.Shipment
+	idx, step int
+}
+
+func newSliceIterator(shipments []storage.Shipment, cursor *storage.Cursor) *sliceIterator {
+	switch {
+	case cursor == nil:
+		return &sliceIterator{shipments: shipments, idx: 0, step: 1}
+	case cursor.Backward:
+		idx := sort.Search(len(shipments), func(i int) bool { return !cursor.Before(shipments[i]) })
+		return &sliceIterator{shipments: shipments, idx: idx - 1, step: -1}
+	default:
+		idx := sort.Search(len(shipments), func(i int) bool { return cursor.After(shipments[i]) })
+		return &sliceIterator{shipments: shipments, idx: idx, step: 1}
+	}
+}
+
+func (s *sliceIterator) Next() interface{} {
+	if s.idx < 0 || s.idx >= len(s.shipments) {
+		return nil
+	}
+
+	shipment := s.shipments[s.idx]
+	s.idx += s.step
+
+	return shipment
+}
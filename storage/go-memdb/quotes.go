@@ -0,0 +1,72 @@
+package memdb
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/lonnblad/shipment-service-backend/storage"
+	"github.com/lonnblad/shipment-service-backend/trace"
+)
+
+func (s *ShipmentStorage) StoreQuote(ctx context.Context, quote storage.Quote) error {
+	_, span := trace.Tracer().Start(ctx, "memdb.StoreQuote")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("quote.tenant_id", quote.TenantID),
+		attribute.String("quote.id", quote.ID),
+	)
+
+	txn := s.db.Txn(writeMode)
+	defer txn.Abort()
+
+	existing, err := txn.First(tableQuotes, tableQuotesIndexKeyQuote, quote.TenantID, quote.ID)
+	if err != nil {
+		return fmt.Errorf("could not look up quote: %w", err)
+	}
+
+	if existing != nil {
+		return fmt.Errorf("quote: %s already exists: %w", quote.ID, storage.ErrConflict)
+	}
+
+	if err = txn.Insert(tableQuotes, quote); err != nil {
+		return fmt.Errorf("failed to insert quote: %w", err)
+	}
+
+	if s.wal != nil {
+		if err = s.wal.append(walRecord{Op: walOpStoreQuote, Quote: &quote}); err != nil {
+			return fmt.Errorf("failed to log quote: %s: %w", err.Error(), storage.ErrUnavailable)
+		}
+	}
+
+	txn.Commit()
+
+	return nil
+}
+
+func (s *ShipmentStorage) GetQuote(ctx context.Context, tenantID, quoteID string) (_ storage.Quote, err error) {
+	_, span := trace.Tracer().Start(ctx, "memdb.GetQuote")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant_id", tenantID),
+		attribute.String("quote_id", quoteID),
+	)
+
+	txn := s.db.Txn(readMode)
+
+	obj, err := txn.First(tableQuotes, tableQuotesIndexKeyQuote, tenantID, quoteID)
+	if err != nil {
+		err = fmt.Errorf("could not look up quote: %w", err)
+		return
+	}
+
+	if obj == nil {
+		err = fmt.Errorf("could not find quote: %s: %w", quoteID, storage.ErrNotFound)
+		return
+	}
+
+	return obj.(storage.Quote), nil
+}
@@ -0,0 +1,71 @@
+package memdb
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/lonnblad/shipment-service-backend/storage"
+	"github.com/lonnblad/shipment-service-backend/trace"
+)
+
+func (s *ShipmentStorage) StoreRateCard(ctx context.Context, rateCard storage.RateCard) error {
+	_, span := trace.Tracer().Start(ctx, "memdb.StoreRateCard")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("rate_card.tenant_id", rateCard.TenantID),
+		attribute.String("rate_card.version", rateCard.Version),
+	)
+
+	txn := s.db.Txn(writeMode)
+	defer txn.Abort()
+
+	existing, err := txn.First(tableRateCards, tableRateCardsIndexKeyVersion, rateCard.TenantID, rateCard.Version)
+	if err != nil {
+		return fmt.Errorf("could not look up rate card: %w", err)
+	}
+
+	if existing != nil {
+		return fmt.Errorf("rate card: %s already exists: %w", rateCard.Version, storage.ErrConflict)
+	}
+
+	if err = txn.Insert(tableRateCards, rateCard); err != nil {
+		return fmt.Errorf("failed to insert rate card: %w", err)
+	}
+
+	if s.wal != nil {
+		if err = s.wal.append(walRecord{Op: walOpStoreRateCard, RateCard: &rateCard}); err != nil {
+			return fmt.Errorf("failed to log rate card: %s: %w", err.Error(), storage.ErrUnavailable)
+		}
+	}
+
+	txn.Commit()
+
+	return nil
+}
+
+func (s *ShipmentStorage) ListRateCards(ctx context.Context, tenantID string) (_ []storage.RateCard, err error) {
+	_, span := trace.Tracer().Start(ctx, "memdb.ListRateCards")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant_id", tenantID),
+	)
+
+	txn := s.db.Txn(readMode)
+
+	it, err := txn.Get(tableRateCards, tableRateCardsIndexKeyUploadedAt+prefixSuffix, tenantID)
+	if err != nil {
+		err = fmt.Errorf("could not look up rate cards: %w", err)
+		return
+	}
+
+	rateCards := []storage.RateCard{}
+	for obj := it.Next(); obj != nil; obj = it.Next() {
+		rateCards = append(rateCards, obj.(storage.RateCard))
+	}
+
+	return rateCards, nil
+}
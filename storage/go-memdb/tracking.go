@@ -0,0 +1,102 @@
+package memdb
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/lonnblad/shipment-service-backend/storage"
+	"github.com/lonnblad/shipment-service-backend/trace"
+)
+
+func (s *ShipmentStorage) AppendTrackingEvent(ctx context.Context, event storage.TrackingEvent) error {
+	_, span := trace.Tracer().Start(ctx, "memdb.AppendTrackingEvent")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tracking_event.tenant_id", event.TenantID),
+		attribute.String("tracking_event.shipment_id", event.ShipmentID),
+		attribute.String("tracking_event.id", event.ID),
+	)
+
+	txn := s.db.Txn(writeMode)
+	defer txn.Abort()
+
+	existing, err := txn.First(tableTrackingEvents, tableTrackingEventsIndexKeyEvent, event.TenantID, event.ShipmentID, event.ID)
+	if err != nil {
+		return fmt.Errorf("could not look up tracking event: %w", err)
+	}
+
+	if existing != nil {
+		return fmt.Errorf("tracking event: %s already exists: %w", event.ID, storage.ErrConflict)
+	}
+
+	if err = txn.Insert(tableTrackingEvents, event); err != nil {
+		return fmt.Errorf("failed to insert tracking event: %w", err)
+	}
+
+	if s.wal != nil {
+		if err = s.wal.append(walRecord{Op: walOpAppendTrackingEvent, TrackingEvent: &event}); err != nil {
+			return fmt.Errorf("failed to log tracking event: %s: %w", err.Error(), storage.ErrUnavailable)
+		}
+	}
+
+	txn.Commit()
+
+	return nil
+}
+
+func (s *ShipmentStorage) ListTrackingEvents(
+	ctx context.Context, tenantID, shipmentID string,
+) (_ []storage.TrackingEvent, err error) {
+	_, span := trace.Tracer().Start(ctx, "memdb.ListTrackingEvents")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant_id", tenantID),
+		attribute.String("shipment_id", shipmentID),
+	)
+
+	txn := s.db.Txn(readMode)
+
+	it, err := txn.Get(tableTrackingEvents, tableTrackingEventsIndexKeyOccurredAt+prefixSuffix, tenantID, shipmentID)
+	if err != nil {
+		err = fmt.Errorf("could not look up tracking events: %w", err)
+		return
+	}
+
+	events := []storage.TrackingEvent{}
+	for obj := it.Next(); obj != nil; obj = it.Next() {
+		events = append(events, obj.(storage.TrackingEvent))
+	}
+
+	return events, nil
+}
+
+func (s *ShipmentStorage) LatestTrackingEvent(
+	ctx context.Context, tenantID, shipmentID string,
+) (_ storage.TrackingEvent, err error) {
+	_, span := trace.Tracer().Start(ctx, "memdb.LatestTrackingEvent")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant_id", tenantID),
+		attribute.String("shipment_id", shipmentID),
+	)
+
+	txn := s.db.Txn(readMode)
+
+	obj, err := txn.Last(tableTrackingEvents, tableTrackingEventsIndexKeyOccurredAt+prefixSuffix, tenantID, shipmentID)
+	if err != nil {
+		err = fmt.Errorf("could not look up tracking event: %w", err)
+		return
+	}
+
+	if obj == nil {
+		err = fmt.Errorf("could not find a tracking event of shipment: %s: %w", shipmentID, storage.ErrNotFound)
+		return
+	}
+
+	return obj.(storage.TrackingEvent), nil
+}
@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Validate will return ErrInvalidCursor if the cursor
+// of the query was created for another sort.
+func (q ListShipmentsQuery) Validate() error {
+	if q.Cursor != nil && q.Cursor.Sort != q.Sort {
+		return fmt.Errorf("%w: the cursor was created for another sort", ErrInvalidCursor)
+	}
+
+	return nil
+}
+
+// Paginate will put the shipments read from the cursor of the query in order
+// and set the Prev and Next cursors of the page, hasMore tells if there were
+// more shipments to read from the cursor than the ones in the page and exists
+// is used to look up if there are shipments in the opposite direction.
+func (p *ShipmentsPage) Paginate(query ListShipmentsQuery, hasMore bool, exists func(Cursor) (bool, error)) error {
+	backward := query.Cursor != nil && query.Cursor.Backward
+	if backward {
+		for i, j := 0, len(p.Shipments)-1; i < j; i, j = i+1, j-1 {
+			p.Shipments[i], p.Shipments[j] = p.Shipments[j], p.Shipments[i]
+		}
+	}
+
+	if len(p.Shipments) == 0 {
+		return nil
+	}
+
+	first, last := p.Shipments[0], p.Shipments[len(p.Shipments)-1]
+
+	var err error
+
+	hasPrev, hasNext := hasMore, hasMore
+	if backward {
+		hasNext, err = exists(NewCursor(query.Sort, last))
+	} else {
+		hasPrev, err = exists(NewBackwardCursor(query.Sort, first))
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if hasPrev {
+		prev := NewBackwardCursor(query.Sort, first)
+		p.Prev = &prev
+	}
+
+	if hasNext {
+		next := NewCursor(query.Sort, last)
+		p.Next = &next
+	}
+
+	return nil
+}
+
+// PaginateSorted will return the page of the shipments selected by the limit,
+// cursor and offset of the query, where the shipments are already filtered
+// and sorted by the sort of the query.
+func PaginateSorted(shipments []Shipment, query ListShipmentsQuery) (_ ShipmentsPage, err error) {
+	if err = query.Validate(); err != nil {
+		return
+	}
+
+	page := ShipmentsPage{Shipments: make([]Shipment, 0, query.Limit), Total: len(shipments)}
+
+	if query.Limit == 0 {
+		return page, nil
+	}
+
+	// The shipments are read in the direction of the cursor.
+	idx, step := query.Offset, 1
+
+	switch {
+	case query.Cursor == nil:
+	case query.Cursor.Backward:
+		idx = sort.Search(len(shipments), func(i int) bool { return !query.Cursor.Before(shipments[i]) }) - 1
+		step = -1
+	default:
+		idx = sort.Search(len(shipments), func(i int) bool { return query.Cursor.After(shipments[i]) })
+	}
+
+	for ; idx >= 0 && idx < len(shipments) && len(page.Shipments) < query.Limit; idx += step {
+		page.Shipments = append(page.Shipments, shipments[idx])
+	}
+
+	hasMore := idx >= 0 && idx < len(shipments)
+
+	exists := func(cursor Cursor) (bool, error) {
+		for _, shipment := range shipments {
+			if cursor.Continues(shipment) {
+				return true, nil
+			}
+		}
+
+		return false, nil
+	}
+
+	if err = page.Paginate(query, hasMore, exists); err != nil {
+		return
+	}
+
+	return page, nil
+}
@@ -0,0 +1,193 @@
+// search is a package with an in-process inverted index of the
+// names, emails and addresses of the senders and receivers of
+// shipments, which supports case-insensitive prefix matching.
+package search
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/lonnblad/shipment-service-backend/storage"
+)
+
+// Index is an inverted index from the tokens of the searchable
+// fields to the IDs of the shipments, partitioned by tenant.
+//
+// An Index is safe for concurrent use.
+type Index struct {
+	mutex   sync.RWMutex
+	tenants map[string]*tenantIndex
+}
+
+type tenantIndex struct {
+	// tokens is sorted, to find all tokens with a prefix.
+	tokens   []string
+	postings map[string]map[string]struct{}
+
+	// shipments are the tokens of every shipment, to
+	// remove them when the shipment is indexed again.
+	shipments map[string][]string
+}
+
+// NewIndex will return a pointer to a new and empty Index.
+func NewIndex() *Index {
+	return &Index{tenants: map[string]*tenantIndex{}}
+}
+
+// Add will index the searchable fields of the shipment, replacing
+// what was indexed if the shipment has been indexed before.
+func (i *Index) Add(shipment storage.Shipment) {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	tenant, ok := i.tenants[shipment.TenantID]
+	if !ok {
+		tenant = &tenantIndex{postings: map[string]map[string]struct{}{}, shipments: map[string][]string{}}
+		i.tenants[shipment.TenantID] = tenant
+	}
+
+	for _, token := range tenant.shipments[shipment.ID] {
+		tenant.remove(token, shipment.ID)
+	}
+
+	tokens := shipmentTokens(shipment)
+	for _, token := range tokens {
+		tenant.add(token, shipment.ID)
+	}
+
+	tenant.shipments[shipment.ID] = tokens
+}
+
+// Search will return the IDs of the tenant's shipments where every
+// term of the text is the prefix of a token of a searchable field.
+func (i *Index) Search(tenantID, text string) []string {
+	terms := Tokenize(text)
+	if len(terms) == 0 {
+		return nil
+	}
+
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+
+	tenant, ok := i.tenants[tenantID]
+	if !ok {
+		return nil
+	}
+
+	matches := tenant.prefixMatches(terms[0])
+
+	for _, term := range terms[1:] {
+		termMatches := tenant.prefixMatches(term)
+
+		for id := range matches {
+			if _, ok := termMatches[id]; !ok {
+				delete(matches, id)
+			}
+		}
+	}
+
+	ids := make([]string, 0, len(matches))
+	for id := range matches {
+		ids = append(ids, id)
+	}
+
+	sort.Strings(ids)
+
+	return ids
+}
+
+func (t *tenantIndex) add(token, id string) {
+	ids, ok := t.postings[token]
+	if !ok {
+		ids = map[string]struct{}{}
+		t.postings[token] = ids
+
+		idx := sort.SearchStrings(t.tokens, token)
+		t.tokens = append(t.tokens, "")
+		copy(t.tokens[idx+1:], t.tokens[idx:])
+		t.tokens[idx] = token
+	}
+
+	ids[id] = struct{}{}
+}
+
+func (t *tenantIndex) remove(token, id string) {
+	ids, ok := t.postings[token]
+	if !ok {
+		return
+	}
+
+	delete(ids, id)
+
+	if len(ids) > 0 {
+		return
+	}
+
+	delete(t.postings, token)
+
+	idx := sort.SearchStrings(t.tokens, token)
+	t.tokens = append(t.tokens[:idx], t.tokens[idx+1:]...)
+}
+
+func (t *tenantIndex) prefixMatches(prefix string) map[string]struct{} {
+	matches := map[string]struct{}{}
+
+	for idx := sort.SearchStrings(t.tokens, prefix); idx < len(t.tokens); idx++ {
+		if !strings.HasPrefix(t.tokens[idx], prefix) {
+			break
+		}
+
+		for id := range t.postings[t.tokens[idx]] {
+			matches[id] = struct{}{}
+		}
+	}
+
+	return matches
+}
+
+// Match will return true if every term of the text is the prefix
+// of a token of a searchable field of the shipment, in the same
+// way as the shipments that are returned by Search.
+func Match(shipment storage.Shipment, text string) bool {
+	terms := Tokenize(text)
+	if len(terms) == 0 {
+		return false
+	}
+
+	tokens := shipmentTokens(shipment)
+
+	for _, term := range terms {
+		if !hasPrefix(tokens, term) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func hasPrefix(tokens []string, prefix string) bool {
+	for _, token := range tokens {
+		if strings.HasPrefix(token, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func shipmentTokens(shipment storage.Shipment) []string {
+	return Tokenize(strings.Join([]string{
+		shipment.Sender.Name, shipment.Sender.Email, shipment.Sender.Address,
+		shipment.Receiver.Name, shipment.Receiver.Email, shipment.Receiver.Address,
+	}, " "))
+}
+
+// Tokenize will split the text into lower case tokens of letters
+// and digits, where any other character separates two tokens.
+func Tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
@@ -0,0 +1,87 @@
+package search_test
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lonnblad/shipment-service-backend/storage"
+	"github.com/lonnblad/shipment-service-backend/storage/search"
+	"github.com/lonnblad/shipment-service-backend/storage/storagetest"
+)
+
+func Test_Index_Search(t *testing.T) {
+	tenantID := uuid.New().String()
+	index := search.NewIndex()
+
+	anna := storagetest.NewShipment(tenantID)
+	anna.Receiver.Name = "Anna Svensson"
+	anna.Receiver.Email = "anna.svensson@example.com"
+	index.Add(anna)
+
+	annika := storagetest.NewShipment(tenantID)
+	annika.Sender.Address = "Annikas väg 12, Malmö"
+	index.Add(annika)
+
+	index.Add(storagetest.NewShipment(uuid.New().String()))
+
+	both := []string{anna.ID, annika.ID}
+	if both[0] > both[1] {
+		both[0], both[1] = both[1], both[0]
+	}
+
+	tcs := []struct {
+		text     string
+		expected []string
+	}{
+		{text: "ANN", expected: both},
+		{text: "anna sv", expected: []string{anna.ID}},
+		{text: "svensson@example", expected: []string{anna.ID}},
+		{text: "MALMÖ", expected: []string{annika.ID}},
+		{text: "nna", expected: nil},
+		{text: "anna malmö", expected: nil},
+		{text: "  ", expected: nil},
+	}
+
+	for _, tc := range tcs {
+		// Match agrees with the index.
+		for _, shipment := range []storage.Shipment{anna, annika} {
+			assert.Equal(t, contains(tc.expected, shipment.ID), search.Match(shipment, tc.text), tc.text)
+		}
+
+		actual := index.Search(tenantID, tc.text)
+		if len(tc.expected) == 0 {
+			assert.Empty(t, actual, tc.text)
+			continue
+		}
+
+		assert.Equal(t, tc.expected, actual, tc.text)
+	}
+}
+
+func Test_Index_AddReplaces(t *testing.T) {
+	tenantID := uuid.New().String()
+	index := search.NewIndex()
+
+	shipment := storagetest.NewShipment(tenantID)
+	shipment.Receiver.Name = "Anna Svensson"
+	index.Add(shipment)
+
+	shipment.Receiver.Name = "Annika Berg"
+	index.Add(shipment)
+
+	assert.Empty(t, index.Search(tenantID, "svensson"))
+	assert.Equal(t, []string{shipment.ID}, index.Search(tenantID, "berg"))
+	assert.Equal(t, []string{shipment.ID}, index.Search(tenantID, "ann"))
+}
+
+func contains(ids []string, id string) bool {
+	for _, other := range ids {
+		if other == id {
+			return true
+		}
+	}
+
+	return false
+}
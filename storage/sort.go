@@ -0,0 +1,108 @@
+package storage
+
+// SortField is a field that shipments can be sorted by, shipments with the
+// same value are sorted by creation time and then by ID.
+type SortField string
+
+const (
+	// SortByCreatedAt is the zero value and the default sort field.
+	SortByCreatedAt           SortField = ""
+	SortByPackagePrice        SortField = "package.price"
+	SortByPackageWeight       SortField = "package.weight"
+	SortBySenderCountryCode   SortField = "sender.countryCode"
+	SortByReceiverCountryCode SortField = "receiver.countryCode"
+)
+
+// Valid will return true if the sort field is supported.
+func (f SortField) Valid() bool {
+	switch f {
+	case SortByCreatedAt, SortByPackagePrice, SortByPackageWeight, SortBySenderCountryCode, SortByReceiverCountryCode:
+		return true
+	default:
+		return false
+	}
+}
+
+// Sort is the order of a listing, the zero value
+// sorts by creation time in ascending order.
+type Sort struct {
+	Field      SortField
+	Descending bool
+}
+
+// Value will return the value of the sort field for the shipment, which
+// is an int or a string, or nil when sorting by creation time.
+func (s Sort) Value(shipment Shipment) interface{} {
+	switch s.Field {
+	case SortByPackagePrice:
+		return shipment.Package.Price
+	case SortByPackageWeight:
+		return shipment.Package.Weight
+	case SortBySenderCountryCode:
+		return shipment.Sender.CountryCode
+	case SortByReceiverCountryCode:
+		return shipment.Receiver.CountryCode
+	default:
+		return nil
+	}
+}
+
+// Less will return true if shipment a is sorted before shipment b.
+func (s Sort) Less(a, b Shipment) bool {
+	return NewCursor(s, a).After(b)
+}
+
+// compare will return the order of the shipment relative
+// to the position of the cursor in the sort of the cursor.
+func (s Sort) compare(shipment Shipment, c Cursor) int {
+	order := compareValues(s.Value(shipment), c.Value)
+
+	if order == 0 {
+		order = compareInt64s(shipment.CreatedAt.UnixNano(), c.CreatedAt.UnixNano())
+	}
+
+	if order == 0 {
+		order = compareStrings(shipment.ID, c.ID)
+	}
+
+	if s.Descending {
+		return -order
+	}
+
+	return order
+}
+
+func compareValues(a, b interface{}) int {
+	switch a := a.(type) {
+	case int:
+		b, _ := b.(int)
+		return compareInt64s(int64(a), int64(b))
+	case string:
+		b, _ := b.(string)
+		return compareStrings(a, b)
+	default:
+		return 0
+	}
+}
+
+func compareInt64s(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func compareStrings(a, b string) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
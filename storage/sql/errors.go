@@ -0,0 +1,66 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/lib/pq"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+
+	"github.com/lonnblad/shipment-service-backend/storage"
+)
+
+const (
+	pqUniqueViolation = "23505"
+
+	// The primary result code is the least significant
+	// byte of an extended SQLite result code.
+	sqlitePrimaryResultCodeMask = 0xff
+)
+
+// wrapError will wrap err with the storage error it corresponds to,
+// or return err as is if it doesn't correspond to any of them.
+func wrapError(err error) error {
+	switch {
+	case isUniqueViolation(err):
+		return fmt.Errorf("%w: %s", storage.ErrConflict, err.Error())
+	case isUnavailable(err):
+		return fmt.Errorf("%w: %s", storage.ErrUnavailable, err.Error())
+	default:
+		return err
+	}
+}
+
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code == pqUniqueViolation
+	}
+
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY ||
+			sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE
+	}
+
+	return false
+}
+
+func isUnavailable(err error) bool {
+	var netErr net.Error
+
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) && sqliteErr.Code()&sqlitePrimaryResultCodeMask == sqlite3.SQLITE_BUSY {
+		return true
+	}
+
+	return errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, sql.ErrConnDone) ||
+		errors.Is(err, context.DeadlineExceeded) ||
+		errors.As(err, &netErr)
+}
@@ -0,0 +1,38 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+)
+
+// MigrateTo will apply the migrations up to and including the version,
+// which makes it possible to store data the way that it was stored then.
+func MigrateTo(ctx context.Context, db *sql.DB, version int) error {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return err
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer conn.Close()
+
+	if _, err = conn.ExecContext(ctx, createMigrationsTable); err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		if m.version > version {
+			break
+		}
+
+		if err = m.apply(ctx, conn); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,175 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// migrationFiles holds the versioned schema migrations, each file is named
+// <version>_<description>.sql and the versions are applied in ascending order.
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+const (
+	migrationsDir      = "migrations"
+	migrationNameParts = 2
+
+	createMigrationsTable = `CREATE TABLE IF NOT EXISTS schema_migrations (
+    version    INTEGER NOT NULL PRIMARY KEY,
+    name       TEXT    NOT NULL,
+    applied_at BIGINT  NOT NULL
+)`
+	selectCurrentVersion = `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`
+	insertMigration      = `INSERT INTO schema_migrations (version, name, applied_at) VALUES ($1, $2, $3)`
+
+	// migrationLockKey is the key of the advisory lock that is held while
+	// PostgreSQL is migrated, which makes instances that start at the same
+	// time wait for each other instead of racing on the schema.
+	migrationLockKey = 7241365203
+	lockMigrations   = `SELECT pg_advisory_lock($1)`
+	unlockMigrations = `SELECT pg_advisory_unlock($1)`
+)
+
+type migration struct {
+	version    int
+	name       string
+	statements string
+	// upgrade is applied after the statements in the same transaction,
+	// it migrates the data that can't be migrated with plain SQL.
+	upgrade upgradeFunc
+}
+
+type upgradeFunc func(ctx context.Context, tx *sql.Tx) error
+
+// upgrades are the data migrations that are written in Go,
+// by the version of the migration that they are applied with.
+var upgrades = map[int]upgradeFunc{
+	13: upgradePriceBreakdownsToMinorUnits,
+	16: upgradePriceBreakdownWeightsToGrams,
+}
+
+// migrate will apply all migrations with a version greater than
+// the current version of the schema, each in its own transaction.
+// The migrations are applied on a single connection, which holds an
+// advisory lock for the whole run when the database is PostgreSQL.
+func migrate(ctx context.Context, db *sql.DB, driverName string) error {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return err
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get a connection: %w", err)
+	}
+
+	defer conn.Close()
+
+	if driverName == DriverPostgres {
+		if _, err = conn.ExecContext(ctx, lockMigrations, migrationLockKey); err != nil {
+			return fmt.Errorf("failed to lock the migrations: %w", err)
+		}
+
+		// The lock is released with the session if the unlock fails.
+		defer conn.ExecContext(context.Background(), unlockMigrations, migrationLockKey) //nolint:errcheck
+	}
+
+	if _, err = conn.ExecContext(ctx, createMigrationsTable); err != nil {
+		return fmt.Errorf("failed to create the migrations table: %w", err)
+	}
+
+	var currentVersion int
+	if err = conn.QueryRowContext(ctx, selectCurrentVersion).Scan(&currentVersion); err != nil {
+		return fmt.Errorf("failed to read the current schema version: %w", err)
+	}
+
+	for _, m := range migrations {
+		if m.version <= currentVersion {
+			continue
+		}
+
+		if err = m.apply(ctx, conn); err != nil {
+			return fmt.Errorf("failed to apply migration: %d_%s: %w", m.version, m.name, err)
+		}
+	}
+
+	return nil
+}
+
+func (m migration) apply(ctx context.Context, conn *sql.Conn) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	// Rollback is a no-op once the transaction has been committed.
+	defer tx.Rollback()
+
+	if _, err = tx.ExecContext(ctx, m.statements); err != nil {
+		return err
+	}
+
+	if m.upgrade != nil {
+		if err = m.upgrade(ctx, tx); err != nil {
+			return err
+		}
+	}
+
+	if _, err = tx.ExecContext(ctx, insertMigration, m.version, m.name, time.Now().UnixNano()); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func loadMigrations() (_ []migration, err error) {
+	entries, err := migrationFiles.ReadDir(migrationsDir)
+	if err != nil {
+		err = fmt.Errorf("failed to read the migrations: %w", err)
+		return
+	}
+
+	migrations := make([]migration, 0, len(entries))
+
+	for _, entry := range entries {
+		filename := entry.Name()
+
+		parts := strings.SplitN(strings.TrimSuffix(filename, ".sql"), "_", migrationNameParts)
+		if len(parts) != migrationNameParts {
+			err = fmt.Errorf("migration: %s is not named <version>_<name>.sql", filename)
+			return
+		}
+
+		var m migration
+
+		if m.version, err = strconv.Atoi(parts[0]); err != nil {
+			err = fmt.Errorf("migration: %s doesn't have a numeric version: %w", filename, err)
+			return
+		}
+
+		m.name = parts[1]
+		m.upgrade = upgrades[m.version]
+
+		var statements []byte
+		if statements, err = migrationFiles.ReadFile(path.Join(migrationsDir, filename)); err != nil {
+			err = fmt.Errorf("failed to read migration: %s: %w", filename, err)
+			return
+		}
+
+		m.statements = string(statements)
+		migrations = append(migrations, m)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].version < migrations[j].version
+	})
+
+	return migrations, nil
+}
@@ -0,0 +1 @@
+CREATE INDEX shipments_tenant_created_at ON shipments (tenant_id, created_at, id);
@@ -0,0 +1,5 @@
+CREATE INDEX shipments_tenant_sender_country_code_created_at
+    ON shipments (tenant_id, sender_country_code, created_at, id);
+
+CREATE INDEX shipments_tenant_receiver_country_code_created_at
+    ON shipments (tenant_id, receiver_country_code, created_at, id);
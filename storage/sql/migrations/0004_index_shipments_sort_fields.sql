@@ -0,0 +1,5 @@
+CREATE INDEX shipments_tenant_package_price_created_at
+    ON shipments (tenant_id, package_price, created_at, id);
+
+CREATE INDEX shipments_tenant_package_weight_created_at
+    ON shipments (tenant_id, package_weight, created_at, id);
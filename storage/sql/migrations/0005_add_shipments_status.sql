@@ -0,0 +1 @@
+ALTER TABLE shipments ADD COLUMN status TEXT NOT NULL DEFAULT 'created';
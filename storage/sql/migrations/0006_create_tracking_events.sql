@@ -0,0 +1,13 @@
+CREATE TABLE tracking_events (
+    tenant_id   TEXT   NOT NULL,
+    shipment_id TEXT   NOT NULL,
+    id          TEXT   NOT NULL,
+    occurred_at BIGINT NOT NULL,
+    location    TEXT   NOT NULL,
+    description TEXT   NOT NULL,
+    source      TEXT   NOT NULL,
+    PRIMARY KEY (tenant_id, shipment_id, id)
+);
+
+CREATE INDEX tracking_events_tenant_shipment_occurred_at
+    ON tracking_events (tenant_id, shipment_id, occurred_at, id);
@@ -0,0 +1,5 @@
+ALTER TABLE shipments ADD COLUMN cancelled_by TEXT;
+ALTER TABLE shipments ADD COLUMN cancelled_at BIGINT;
+ALTER TABLE shipments ADD COLUMN cancellation_reason TEXT;
+ALTER TABLE shipments ADD COLUMN cancellation_fee INTEGER;
+ALTER TABLE shipments ADD COLUMN cancellation_refund INTEGER;
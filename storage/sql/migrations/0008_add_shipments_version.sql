@@ -0,0 +1 @@
+ALTER TABLE shipments ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
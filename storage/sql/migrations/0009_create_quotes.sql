@@ -0,0 +1,19 @@
+CREATE TABLE quotes (
+    tenant_id             TEXT    NOT NULL,
+    id                    TEXT    NOT NULL,
+    created_at            BIGINT  NOT NULL,
+    expires_at            BIGINT  NOT NULL,
+    sender_name           TEXT    NOT NULL,
+    sender_email          TEXT    NOT NULL,
+    sender_address        TEXT    NOT NULL,
+    sender_country_code   TEXT    NOT NULL,
+    receiver_name         TEXT    NOT NULL,
+    receiver_email        TEXT    NOT NULL,
+    receiver_address      TEXT    NOT NULL,
+    receiver_country_code TEXT    NOT NULL,
+    package_weight        INTEGER NOT NULL,
+    package_price         INTEGER NOT NULL,
+    PRIMARY KEY (tenant_id, id)
+);
+
+ALTER TABLE shipments ADD COLUMN quote_id TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+ALTER TABLE shipments ADD COLUMN price_breakdown TEXT;
+ALTER TABLE quotes ADD COLUMN price_breakdown TEXT;
@@ -0,0 +1,11 @@
+CREATE TABLE rate_cards (
+    tenant_id      TEXT   NOT NULL,
+    version        TEXT   NOT NULL,
+    effective_from BIGINT NOT NULL,
+    uploaded_at    BIGINT NOT NULL,
+    document       TEXT   NOT NULL,
+    PRIMARY KEY (tenant_id, version)
+);
+
+CREATE INDEX rate_cards_tenant_uploaded_at
+    ON rate_cards (tenant_id, uploaded_at, version);
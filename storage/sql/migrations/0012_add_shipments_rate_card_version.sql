@@ -0,0 +1 @@
+ALTER TABLE shipments ADD COLUMN rate_card_version TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,7 @@
+ALTER TABLE shipments ADD COLUMN package_currency TEXT NOT NULL DEFAULT '';
+ALTER TABLE shipments ADD COLUMN exchange_rate TEXT;
+ALTER TABLE quotes ADD COLUMN package_currency TEXT NOT NULL DEFAULT '';
+ALTER TABLE quotes ADD COLUMN exchange_rate TEXT;
+UPDATE shipments SET package_currency = 'SEK', package_price = package_price * 100,
+    cancellation_fee = cancellation_fee * 100, cancellation_refund = cancellation_refund * 100;
+UPDATE quotes SET package_currency = 'SEK', package_price = package_price * 100;
@@ -0,0 +1,4 @@
+ALTER TABLE shipments ADD COLUMN sender_vat_number TEXT NOT NULL DEFAULT '';
+ALTER TABLE shipments ADD COLUMN tax TEXT;
+ALTER TABLE quotes ADD COLUMN sender_vat_number TEXT NOT NULL DEFAULT '';
+ALTER TABLE quotes ADD COLUMN tax TEXT;
@@ -0,0 +1,6 @@
+ALTER TABLE shipments ADD COLUMN package_length INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE shipments ADD COLUMN package_width INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE shipments ADD COLUMN package_height INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE quotes ADD COLUMN package_length INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE quotes ADD COLUMN package_width INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE quotes ADD COLUMN package_height INTEGER NOT NULL DEFAULT 0;
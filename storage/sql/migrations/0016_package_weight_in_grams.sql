@@ -0,0 +1,2 @@
+UPDATE shipments SET package_weight = package_weight * 1000;
+UPDATE quotes SET package_weight = package_weight * 1000;
@@ -0,0 +1,2 @@
+ALTER TABLE shipments ADD COLUMN parcels TEXT;
+ALTER TABLE quotes ADD COLUMN parcels TEXT;
@@ -0,0 +1,156 @@
+package sql
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/lonnblad/shipment-service-backend/storage"
+)
+
+// whereClause builds the conditions of a query, with
+// numbered placeholders in the order of the arguments.
+type whereClause struct {
+	conditions []string
+	args       []interface{}
+}
+
+// add will add a condition, where every ? is replaced
+// with a placeholder for the next argument.
+func (w *whereClause) add(condition string, args ...interface{}) {
+	for _, arg := range args {
+		w.args = append(w.args, arg)
+		condition = strings.Replace(condition, "?", w.placeholder(), 1)
+	}
+
+	w.conditions = append(w.conditions, condition)
+}
+
+// placeholder will return the placeholder of the latest argument.
+func (w *whereClause) placeholder() string {
+	return "$" + strconv.Itoa(len(w.args))
+}
+
+func (w *whereClause) String() string {
+	return strings.Join(w.conditions, " AND ")
+}
+
+// searchColumns are the columns of the names, emails
+// and addresses of the senders and receivers.
+var searchColumns = []string{
+	"sender_name", "sender_email", "sender_address",
+	"receiver_name", "receiver_email", "receiver_address",
+}
+
+// addSearchTerms will select the rows where every lower case term is in
+// any of the search columns, the terms are only letters and digits, which
+// means that they don't have to be escaped in a LIKE pattern.
+func (w *whereClause) addSearchTerms(terms []string) {
+	conditions := make([]string, len(searchColumns))
+
+	for idx, column := range searchColumns {
+		conditions[idx] = "LOWER(" + column + ") LIKE ?"
+	}
+
+	condition := "(" + strings.Join(conditions, " OR ") + ")"
+
+	for _, term := range terms {
+		args := make([]interface{}, len(searchColumns))
+
+		for idx := range args {
+			args[idx] = "%" + term + "%"
+		}
+
+		w.add(condition, args...)
+	}
+}
+
+func (w *whereClause) addFilter(filter storage.ShipmentsFilter) {
+	if filter.SenderCountryCode != "" {
+		w.add("sender_country_code = ?", filter.SenderCountryCode)
+	}
+
+	if filter.ReceiverCountryCode != "" {
+		w.add("receiver_country_code = ?", filter.ReceiverCountryCode)
+	}
+
+	if !filter.CreatedFrom.IsZero() {
+		w.add("created_at >= ?", filter.CreatedFrom.UnixNano())
+	}
+
+	if !filter.CreatedTo.IsZero() {
+		w.add("created_at < ?", filter.CreatedTo.UnixNano())
+	}
+
+	if filter.Currency != "" {
+		w.add("package_currency = ?", filter.Currency)
+	}
+
+	w.addRange("package_weight", filter.MinWeight, filter.MaxWeight)
+	w.addRange("package_price", filter.MinPrice, filter.MaxPrice)
+}
+
+func (w *whereClause) addRange(column string, min, max *int) {
+	if min != nil {
+		w.add(column+" >= ?", *min)
+	}
+
+	if max != nil {
+		w.add(column+" <= ?", *max)
+	}
+}
+
+// addCursor will select the shipments that a listing from the cursor continues with.
+func (w *whereClause) addCursor(cursor *storage.Cursor) {
+	if cursor == nil || cursor.IsLastPage() {
+		return
+	}
+
+	// The order is reversed when either the sort is descending or
+	// the cursor is backward, but not when both are.
+	operator := ">"
+	if cursor.Sort.Descending != cursor.Backward {
+		operator = "<"
+	}
+
+	if column := sortColumn(cursor.Sort.Field); column != columnCreatedAt {
+		w.add("("+column+", created_at, id) "+operator+" (?, ?, ?)", cursor.Value, cursor.CreatedAt.UnixNano(), cursor.ID)
+		return
+	}
+
+	w.add("(created_at, id) "+operator+" (?, ?)", cursor.CreatedAt.UnixNano(), cursor.ID)
+}
+
+const columnCreatedAt = "created_at"
+
+// sortColumn will return the column of the sort field.
+func sortColumn(field storage.SortField) string {
+	switch field {
+	case storage.SortByPackagePrice:
+		return "package_price"
+	case storage.SortByPackageWeight:
+		return "package_weight"
+	case storage.SortBySenderCountryCode:
+		return "sender_country_code"
+	case storage.SortByReceiverCountryCode:
+		return "receiver_country_code"
+	default:
+		return columnCreatedAt
+	}
+}
+
+// orderBy will return the order of the sort, or the reverse
+// order of the sort when reading backward from a cursor.
+func orderBy(sort storage.Sort, backward bool) string {
+	direction := ""
+	if sort.Descending != backward {
+		direction = " DESC"
+	}
+
+	columns := []string{columnCreatedAt + direction, "id" + direction}
+
+	if column := sortColumn(sort.Field); column != columnCreatedAt {
+		columns = append([]string{column + direction}, columns...)
+	}
+
+	return " ORDER BY " + strings.Join(columns, ", ")
+}
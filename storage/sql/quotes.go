@@ -0,0 +1,142 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/lonnblad/shipment-service-backend/storage"
+	"github.com/lonnblad/shipment-service-backend/trace"
+)
+
+var _ storage.QuoteStorage = &ShipmentStorage{}
+
+const (
+	quoteColumns = `id, tenant_id, created_at, expires_at,
+    sender_name, sender_email, sender_address, sender_country_code,
+    receiver_name, receiver_email, receiver_address, receiver_country_code,
+    package_weight, package_price, price_breakdown, package_currency, exchange_rate,
+    sender_vat_number, tax, package_length, package_width, package_height, parcels`
+
+	insertQuote = `INSERT INTO quotes (` + quoteColumns + `)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23)`
+
+	selectQuote = `SELECT ` + quoteColumns + `
+FROM quotes
+WHERE tenant_id = $1 AND id = $2`
+)
+
+func (s *ShipmentStorage) StoreQuote(ctx context.Context, quote storage.Quote) error {
+	ctx, span := trace.Tracer().Start(ctx, "sql.StoreQuote")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("quote.tenant_id", quote.TenantID),
+		attribute.String("quote.id", quote.ID),
+	)
+
+	breakdown, err := priceBreakdownArg(quote.PriceBreakdown)
+	if err != nil {
+		return err
+	}
+
+	exchangeRate, err := exchangeRateArg(quote.ExchangeRate)
+	if err != nil {
+		return err
+	}
+
+	tax, err := taxArg(quote.Tax)
+	if err != nil {
+		return err
+	}
+
+	parcels, err := parcelsArg(quote.Parcels)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(ctx, insertQuote,
+		quote.ID, quote.TenantID, quote.CreatedAt.UnixNano(), quote.ExpiresAt.UnixNano(),
+		quote.Sender.Name, quote.Sender.Email, quote.Sender.Address, quote.Sender.CountryCode,
+		quote.Receiver.Name, quote.Receiver.Email, quote.Receiver.Address, quote.Receiver.CountryCode,
+		quote.Package.Weight, quote.Package.Price, breakdown, quote.Package.Currency, exchangeRate,
+		quote.Sender.VATNumber, tax, quote.Package.Length, quote.Package.Width, quote.Package.Height, parcels,
+	)
+	if isUniqueViolation(err) {
+		return fmt.Errorf("quote: %s already exists: %w", quote.ID, storage.ErrConflict)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to insert quote: %w", wrapError(err))
+	}
+
+	return nil
+}
+
+func (s *ShipmentStorage) GetQuote(ctx context.Context, tenantID, quoteID string) (_ storage.Quote, err error) {
+	ctx, span := trace.Tracer().Start(ctx, "sql.GetQuote")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant_id", tenantID),
+		attribute.String("quote_id", quoteID),
+	)
+
+	row := s.db.QueryRowContext(ctx, selectQuote, tenantID, quoteID)
+
+	quote, err := scanQuote(row)
+	if err == sql.ErrNoRows {
+		err = fmt.Errorf("could not find quote: %s: %w", quoteID, storage.ErrNotFound)
+		return
+	}
+
+	if err != nil {
+		err = fmt.Errorf("could not look up quote: %w", wrapError(err))
+		return
+	}
+
+	return quote, nil
+}
+
+func scanQuote(row scanner) (quote storage.Quote, err error) {
+	var (
+		createdAt, expiresAt         int64
+		breakdown, exchangeRate, tax sql.NullString
+		parcels                      sql.NullString
+	)
+
+	err = row.Scan(
+		&quote.ID, &quote.TenantID, &createdAt, &expiresAt,
+		&quote.Sender.Name, &quote.Sender.Email, &quote.Sender.Address, &quote.Sender.CountryCode,
+		&quote.Receiver.Name, &quote.Receiver.Email, &quote.Receiver.Address, &quote.Receiver.CountryCode,
+		&quote.Package.Weight, &quote.Package.Price, &breakdown, &quote.Package.Currency, &exchangeRate,
+		&quote.Sender.VATNumber, &tax, &quote.Package.Length, &quote.Package.Width, &quote.Package.Height, &parcels,
+	)
+	if err != nil {
+		return
+	}
+
+	quote.CreatedAt = time.Unix(0, createdAt).UTC()
+	quote.ExpiresAt = time.Unix(0, expiresAt).UTC()
+
+	if quote.PriceBreakdown, err = scanPriceBreakdown(breakdown); err != nil {
+		return
+	}
+
+	if quote.ExchangeRate, err = scanExchangeRate(exchangeRate); err != nil {
+		return
+	}
+
+	if quote.Tax, err = scanTax(tax); err != nil {
+		return
+	}
+
+	if quote.Parcels, err = scanParcels(parcels); err != nil {
+		return
+	}
+
+	return quote, nil
+}
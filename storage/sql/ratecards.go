@@ -0,0 +1,105 @@
+package sql
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/lonnblad/shipment-service-backend/storage"
+	"github.com/lonnblad/shipment-service-backend/trace"
+)
+
+var _ storage.RateCardStorage = &ShipmentStorage{}
+
+const (
+	rateCardColumns = `tenant_id, version, effective_from, uploaded_at, document`
+
+	insertRateCard = `INSERT INTO rate_cards (` + rateCardColumns + `)
+VALUES ($1, $2, $3, $4, $5)`
+
+	selectRateCards = `SELECT ` + rateCardColumns + `
+FROM rate_cards
+WHERE tenant_id = $1
+ORDER BY uploaded_at, version`
+)
+
+func (s *ShipmentStorage) StoreRateCard(ctx context.Context, rateCard storage.RateCard) error {
+	ctx, span := trace.Tracer().Start(ctx, "sql.StoreRateCard")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("rate_card.tenant_id", rateCard.TenantID),
+		attribute.String("rate_card.version", rateCard.Version),
+	)
+
+	_, err := s.db.ExecContext(ctx, insertRateCard,
+		rateCard.TenantID, rateCard.Version, rateCard.EffectiveFrom.UnixNano(), rateCard.UploadedAt.UnixNano(),
+		string(rateCard.Document),
+	)
+	if isUniqueViolation(err) {
+		return fmt.Errorf("rate card: %s already exists: %w", rateCard.Version, storage.ErrConflict)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to insert rate card: %w", wrapError(err))
+	}
+
+	return nil
+}
+
+func (s *ShipmentStorage) ListRateCards(ctx context.Context, tenantID string) (_ []storage.RateCard, err error) {
+	ctx, span := trace.Tracer().Start(ctx, "sql.ListRateCards")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant_id", tenantID),
+	)
+
+	rows, err := s.db.QueryContext(ctx, selectRateCards, tenantID)
+	if err != nil {
+		err = fmt.Errorf("could not look up rate cards: %w", wrapError(err))
+		return
+	}
+
+	defer rows.Close()
+
+	rateCards := []storage.RateCard{}
+
+	for rows.Next() {
+		var rateCard storage.RateCard
+
+		if rateCard, err = scanRateCard(rows); err != nil {
+			err = fmt.Errorf("could not read rate card: %w", wrapError(err))
+			return
+		}
+
+		rateCards = append(rateCards, rateCard)
+	}
+
+	if err = rows.Err(); err != nil {
+		err = fmt.Errorf("could not read rate cards: %w", wrapError(err))
+		return
+	}
+
+	return rateCards, nil
+}
+
+func scanRateCard(row scanner) (rateCard storage.RateCard, err error) {
+	var (
+		effectiveFrom, uploadedAt int64
+		document                  string
+	)
+
+	err = row.Scan(&rateCard.TenantID, &rateCard.Version, &effectiveFrom, &uploadedAt, &document)
+	if err != nil {
+		return
+	}
+
+	rateCard.EffectiveFrom = time.Unix(0, effectiveFrom).UTC()
+	rateCard.UploadedAt = time.Unix(0, uploadedAt).UTC()
+	rateCard.Document = []byte(document)
+
+	return rateCard, nil
+}
@@ -0,0 +1,635 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	// The drivers for the supported databases.
+	_ "github.com/lib/pq"
+	_ "modernc.org/sqlite"
+
+	"github.com/lonnblad/shipment-service-backend/storage"
+	"github.com/lonnblad/shipment-service-backend/storage/search"
+	"github.com/lonnblad/shipment-service-backend/trace"
+)
+
+var _ storage.ShipmentStorage = &ShipmentStorage{}
+
+const (
+	// DriverSQLite is the driver name of the embedded SQLite database.
+	DriverSQLite = "sqlite"
+	// DriverPostgres is the driver name of PostgreSQL.
+	DriverPostgres = "postgres"
+)
+
+const (
+	shipmentColumns = `id, tenant_id, created_at, status, version,
+    sender_name, sender_email, sender_address, sender_country_code,
+    receiver_name, receiver_email, receiver_address, receiver_country_code,
+    package_weight, package_price,
+    cancelled_by, cancelled_at, cancellation_reason, cancellation_fee, cancellation_refund,
+    quote_id, price_breakdown, rate_card_version, package_currency, exchange_rate,
+    sender_vat_number, tax, package_length, package_width, package_height, parcels`
+
+	insertShipment = `INSERT INTO shipments (` + shipmentColumns + `)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15,
+    $16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27, $28, $29, $30, $31)`
+
+	updateShipment = `UPDATE shipments SET
+    sender_name = $1, sender_email = $2, sender_address = $3, sender_country_code = $4,
+    receiver_name = $5, receiver_email = $6, receiver_address = $7, receiver_country_code = $8,
+    package_weight = $9, package_price = $10, quote_id = $11, price_breakdown = $12,
+    rate_card_version = $13, package_currency = $14, exchange_rate = $15,
+    sender_vat_number = $16, tax = $17,
+    package_length = $18, package_width = $19, package_height = $20, parcels = $21, version = version + 1
+WHERE tenant_id = $22 AND id = $23 AND version = $24
+RETURNING ` + shipmentColumns
+
+	// The cancellation is only set when it is given, an existing one is kept.
+	updateShipmentStatus = `UPDATE shipments SET status = $1, version = version + 1,
+    cancelled_by = COALESCE($2, cancelled_by),
+    cancelled_at = COALESCE($3, cancelled_at),
+    cancellation_reason = COALESCE($4, cancellation_reason),
+    cancellation_fee = COALESCE($5, cancellation_fee),
+    cancellation_refund = COALESCE($6, cancellation_refund)
+WHERE tenant_id = $7 AND id = $8 AND version = $9
+RETURNING ` + shipmentColumns
+
+	selectShipment = `SELECT ` + shipmentColumns + `
+FROM shipments
+WHERE tenant_id = $1 AND id = $2`
+
+	selectShipments = `SELECT ` + shipmentColumns + `
+FROM shipments
+WHERE `
+
+	countShipments = `SELECT COUNT(*) FROM shipments WHERE `
+)
+
+// ShipmentStorage implements storage.ShipmentStorage,
+// storage.TrackingEventStorage and storage.QuoteStorage
+type ShipmentStorage struct {
+	db *sql.DB
+}
+
+// NewShipmentStorage will open a database using the provided driver and
+// data source name, migrate the schema to the latest version and return
+// a pointer to a new ShipmentStorage.
+func NewShipmentStorage(ctx context.Context, driverName, dataSourceName string) (_ *ShipmentStorage, err error) {
+	db, err := sql.Open(driverName, dataSourceName)
+	if err != nil {
+		err = fmt.Errorf("failed to open the %s database: %w", driverName, err)
+		return
+	}
+
+	if driverName == DriverSQLite {
+		// SQLite only allows one writer at a time, serializing the
+		// connections avoids busy errors on concurrent writes.
+		db.SetMaxOpenConns(1)
+	}
+
+	if err = db.PingContext(ctx); err != nil {
+		db.Close()
+
+		err = fmt.Errorf("failed to connect to the %s database: %w", driverName, err)
+
+		return
+	}
+
+	if err = migrate(ctx, db, driverName); err != nil {
+		db.Close()
+
+		err = fmt.Errorf("failed to migrate the %s database: %w", driverName, err)
+
+		return
+	}
+
+	return &ShipmentStorage{db: db}, nil
+}
+
+// Close will close the underlying database.
+func (s *ShipmentStorage) Close() error {
+	return s.db.Close()
+}
+
+func (s *ShipmentStorage) StoreShipment(ctx context.Context, shipment storage.Shipment) error {
+	ctx, span := trace.Tracer().Start(ctx, "sql.StoreShipment")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("shipment.tenant_id", shipment.TenantID),
+		attribute.String("shipment.id", shipment.ID),
+	)
+
+	args := []interface{}{
+		shipment.ID, shipment.TenantID, shipment.CreatedAt.UnixNano(), shipment.Status, shipment.Version,
+		shipment.Sender.Name, shipment.Sender.Email, shipment.Sender.Address, shipment.Sender.CountryCode,
+		shipment.Receiver.Name, shipment.Receiver.Email, shipment.Receiver.Address, shipment.Receiver.CountryCode,
+		shipment.Package.Weight, shipment.Package.Price,
+	}
+
+	breakdown, err := priceBreakdownArg(shipment.PriceBreakdown)
+	if err != nil {
+		return err
+	}
+
+	exchangeRate, err := exchangeRateArg(shipment.ExchangeRate)
+	if err != nil {
+		return err
+	}
+
+	tax, err := taxArg(shipment.Tax)
+	if err != nil {
+		return err
+	}
+
+	parcels, err := parcelsArg(shipment.Parcels)
+	if err != nil {
+		return err
+	}
+
+	args = append(args, cancellationArgs(shipment.Cancellation)...)
+	args = append(args, shipment.QuoteID, breakdown, shipment.RateCardVersion, shipment.Package.Currency, exchangeRate)
+	args = append(args, shipment.Sender.VATNumber, tax)
+	args = append(args, shipment.Package.Length, shipment.Package.Width, shipment.Package.Height, parcels)
+
+	_, err = s.db.ExecContext(ctx, insertShipment, args...)
+	if isUniqueViolation(err) {
+		return fmt.Errorf("shipment: %s already exists: %w", shipment.ID, storage.ErrConflict)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to insert shipment: %w", wrapError(err))
+	}
+
+	return nil
+}
+
+func (s *ShipmentStorage) GetShipment(ctx context.Context, tenantID, shipmentID string) (_ storage.Shipment, err error) {
+	ctx, span := trace.Tracer().Start(ctx, "sql.GetShipment")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant_id", tenantID),
+		attribute.String("shipment_id", shipmentID),
+	)
+
+	row := s.db.QueryRowContext(ctx, selectShipment, tenantID, shipmentID)
+
+	shipment, err := scanShipment(row)
+	if err == sql.ErrNoRows {
+		err = fmt.Errorf("could not find shipment: %s: %w", shipmentID, storage.ErrNotFound)
+		return
+	}
+
+	if err != nil {
+		err = fmt.Errorf("could not look up shipment: %w", wrapError(err))
+		return
+	}
+
+	return shipment, nil
+}
+
+func (s *ShipmentStorage) UpdateShipment(ctx context.Context, update storage.Shipment) (_ storage.Shipment, err error) {
+	ctx, span := trace.Tracer().Start(ctx, "sql.UpdateShipment")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("shipment.tenant_id", update.TenantID),
+		attribute.String("shipment.id", update.ID),
+	)
+
+	breakdown, err := priceBreakdownArg(update.PriceBreakdown)
+	if err != nil {
+		return
+	}
+
+	exchangeRate, err := exchangeRateArg(update.ExchangeRate)
+	if err != nil {
+		return
+	}
+
+	tax, err := taxArg(update.Tax)
+	if err != nil {
+		return
+	}
+
+	parcels, err := parcelsArg(update.Parcels)
+	if err != nil {
+		return
+	}
+
+	row := s.db.QueryRowContext(ctx, updateShipment,
+		update.Sender.Name, update.Sender.Email, update.Sender.Address, update.Sender.CountryCode,
+		update.Receiver.Name, update.Receiver.Email, update.Receiver.Address, update.Receiver.CountryCode,
+		update.Package.Weight, update.Package.Price, update.QuoteID, breakdown, update.RateCardVersion,
+		update.Package.Currency, exchangeRate, update.Sender.VATNumber, tax,
+		update.Package.Length, update.Package.Width, update.Package.Height, parcels,
+		update.TenantID, update.ID, update.Version,
+	)
+
+	shipment, err := scanShipment(row)
+	if err == sql.ErrNoRows {
+		err = s.notUpdatedError(ctx, update.TenantID, update.ID, update.Version)
+		return
+	}
+
+	if err != nil {
+		err = fmt.Errorf("failed to update shipment: %w", wrapError(err))
+		return
+	}
+
+	return shipment, nil
+}
+
+func (s *ShipmentStorage) UpdateShipmentStatus(
+	ctx context.Context, tenantID, shipmentID string, version int, update storage.StatusUpdate,
+) (_ storage.Shipment, err error) {
+	ctx, span := trace.Tracer().Start(ctx, "sql.UpdateShipmentStatus")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant_id", tenantID),
+		attribute.String("shipment_id", shipmentID),
+		attribute.Int("version", version),
+		attribute.String("status.to", update.Status),
+	)
+
+	args := append([]interface{}{update.Status}, cancellationArgs(update.Cancellation)...)
+	args = append(args, tenantID, shipmentID, version)
+
+	row := s.db.QueryRowContext(ctx, updateShipmentStatus, args...)
+
+	shipment, err := scanShipment(row)
+	if err == sql.ErrNoRows {
+		err = s.notUpdatedError(ctx, tenantID, shipmentID, version)
+		return
+	}
+
+	if err != nil {
+		err = fmt.Errorf("failed to update shipment: %w", wrapError(err))
+		return
+	}
+
+	return shipment, nil
+}
+
+// notUpdatedError will return the error of an update that didn't update any row,
+// either the shipment doesn't exist or it doesn't have the expected version.
+func (s *ShipmentStorage) notUpdatedError(ctx context.Context, tenantID, shipmentID string, version int) error {
+	if _, err := s.GetShipment(ctx, tenantID, shipmentID); err != nil {
+		return err
+	}
+
+	return fmt.Errorf("shipment: %s doesn't have version: %d: %w", shipmentID, version, storage.ErrConflict)
+}
+
+func (s *ShipmentStorage) ListShipments(
+	ctx context.Context, tenantID string, query storage.ListShipmentsQuery,
+) (_ storage.ShipmentsPage, err error) {
+	ctx, span := trace.Tracer().Start(ctx, "sql.ListShipments")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant_id", tenantID),
+		attribute.Int("limit", query.Limit),
+		attribute.Int("offset", query.Offset),
+		attribute.String("sort", string(query.Sort.Field)),
+		attribute.Bool("sort.descending", query.Sort.Descending),
+	)
+
+	if err = query.Validate(); err != nil {
+		return
+	}
+
+	page := storage.ShipmentsPage{Shipments: make([]storage.Shipment, 0, query.Limit)}
+
+	where := whereClause{}
+	where.add("tenant_id = ?", tenantID)
+	where.addFilter(query.Filter)
+
+	if err = s.db.QueryRowContext(ctx, countShipments+where.String(), where.args...).Scan(&page.Total); err != nil {
+		err = fmt.Errorf("could not count shipments: %w", wrapError(err))
+		return
+	}
+
+	if query.Limit == 0 {
+		return page, nil
+	}
+
+	// One more shipment than the limit is read to know if there is another page.
+	shipments, err := s.queryShipments(ctx, where, query.Sort, query.Cursor, query.Offset, query.Limit+1)
+	if err != nil {
+		return
+	}
+
+	hasMore := len(shipments) > query.Limit
+	if hasMore {
+		shipments = shipments[:query.Limit]
+	}
+
+	page.Shipments = append(page.Shipments, shipments...)
+
+	exists := func(cursor storage.Cursor) (bool, error) { return s.hasShipment(ctx, where, query.Sort, cursor) }
+
+	if err = page.Paginate(query, hasMore, exists); err != nil {
+		return
+	}
+
+	return page, nil
+}
+
+// queryShipments will return up to limit of the shipments selected by the where clause
+// that a listing from the cursor continues with, in the order they are read from the cursor.
+func (s *ShipmentStorage) queryShipments(
+	ctx context.Context, where whereClause, sort storage.Sort, cursor *storage.Cursor, offset, limit int,
+) (shipments []storage.Shipment, err error) {
+	// The clause is copied, as the slices are shared with the caller.
+	where = whereClause{
+		conditions: append([]string{}, where.conditions...),
+		args:       append([]interface{}{}, where.args...),
+	}
+	where.addCursor(cursor)
+
+	order := orderBy(sort, cursor != nil && cursor.Backward)
+
+	where.args = append(where.args, limit)
+	query := selectShipments + where.String() + order + " LIMIT " + where.placeholder()
+
+	if cursor == nil {
+		where.args = append(where.args, offset)
+		query += " OFFSET " + where.placeholder()
+	}
+
+	return s.readShipments(ctx, query, where.args...)
+}
+
+// readShipments will return all shipments that are selected by the query.
+func (s *ShipmentStorage) readShipments(
+	ctx context.Context, query string, args ...interface{},
+) (shipments []storage.Shipment, err error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		err = fmt.Errorf("could not look up shipments: %w", wrapError(err))
+		return
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var shipment storage.Shipment
+
+		if shipment, err = scanShipment(rows); err != nil {
+			err = fmt.Errorf("could not read shipment: %w", wrapError(err))
+			return
+		}
+
+		shipments = append(shipments, shipment)
+	}
+
+	if err = rows.Err(); err != nil {
+		err = fmt.Errorf("could not read shipments: %w", wrapError(err))
+		return
+	}
+
+	return shipments, nil
+}
+
+// hasShipment will return true if any shipment selected by the where
+// clause is continued with by a listing from the cursor.
+func (s *ShipmentStorage) hasShipment(
+	ctx context.Context, where whereClause, sort storage.Sort, cursor storage.Cursor,
+) (bool, error) {
+	shipments, err := s.queryShipments(ctx, where, sort, &cursor, 0, 1)
+
+	return len(shipments) > 0, err
+}
+
+func (s *ShipmentStorage) SearchShipments(
+	ctx context.Context, tenantID string, query storage.SearchShipmentsQuery,
+) (_ storage.ShipmentsPage, err error) {
+	ctx, span := trace.Tracer().Start(ctx, "sql.SearchShipments")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant_id", tenantID),
+		attribute.Int("limit", query.Limit),
+	)
+
+	shipments := []storage.Shipment{}
+
+	terms := search.Tokenize(query.Text)
+	if len(terms) > 0 {
+		// The database narrows the shipments down to the ones where every
+		// term is in a searchable column, which are then matched by token.
+		where := whereClause{}
+		where.add("tenant_id = ?", tenantID)
+		where.addSearchTerms(terms)
+
+		var candidates []storage.Shipment
+
+		selectCandidates := selectShipments + where.String() + orderBy(storage.Sort{}, false)
+		if candidates, err = s.readShipments(ctx, selectCandidates, where.args...); err != nil {
+			return
+		}
+
+		for _, shipment := range candidates {
+			if search.Match(shipment, query.Text) {
+				shipments = append(shipments, shipment)
+			}
+		}
+	}
+
+	span.SetAttributes(attribute.Int("matches", len(shipments)))
+
+	return storage.PaginateSorted(shipments, storage.ListShipmentsQuery{Limit: query.Limit, Cursor: query.Cursor})
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanShipment(row scanner) (shipment storage.Shipment, err error) {
+	var (
+		createdAt                       int64
+		cancelledBy, cancellationReason sql.NullString
+		cancelledAt, fee, refund        sql.NullInt64
+		breakdown, exchangeRate, tax    sql.NullString
+		parcels                         sql.NullString
+	)
+
+	err = row.Scan(
+		&shipment.ID, &shipment.TenantID, &createdAt, &shipment.Status, &shipment.Version,
+		&shipment.Sender.Name, &shipment.Sender.Email, &shipment.Sender.Address, &shipment.Sender.CountryCode,
+		&shipment.Receiver.Name, &shipment.Receiver.Email, &shipment.Receiver.Address, &shipment.Receiver.CountryCode,
+		&shipment.Package.Weight, &shipment.Package.Price,
+		&cancelledBy, &cancelledAt, &cancellationReason, &fee, &refund,
+		&shipment.QuoteID, &breakdown, &shipment.RateCardVersion, &shipment.Package.Currency, &exchangeRate,
+		&shipment.Sender.VATNumber, &tax, &shipment.Package.Length, &shipment.Package.Width, &shipment.Package.Height,
+		&parcels,
+	)
+	if err != nil {
+		return
+	}
+
+	shipment.CreatedAt = time.Unix(0, createdAt).UTC()
+
+	if shipment.PriceBreakdown, err = scanPriceBreakdown(breakdown); err != nil {
+		return
+	}
+
+	if shipment.ExchangeRate, err = scanExchangeRate(exchangeRate); err != nil {
+		return
+	}
+
+	if shipment.Tax, err = scanTax(tax); err != nil {
+		return
+	}
+
+	if shipment.Parcels, err = scanParcels(parcels); err != nil {
+		return
+	}
+
+	if cancelledAt.Valid {
+		shipment.Cancellation = &storage.Cancellation{
+			CancelledBy: cancelledBy.String,
+			CancelledAt: time.Unix(0, cancelledAt.Int64).UTC(),
+			Reason:      cancellationReason.String,
+			Fee:         int(fee.Int64),
+			Refund:      int(refund.Int64),
+		}
+	}
+
+	return shipment, nil
+}
+
+// cancellationArgs will return the values of the cancellation columns,
+// which are all NULL when there is no cancellation.
+func cancellationArgs(cancellation *storage.Cancellation) []interface{} {
+	if cancellation == nil {
+		return []interface{}{nil, nil, nil, nil, nil}
+	}
+
+	return []interface{}{
+		cancellation.CancelledBy, cancellation.CancelledAt.UnixNano(), cancellation.Reason,
+		cancellation.Fee, cancellation.Refund,
+	}
+}
+
+// priceBreakdownArg will return the price breakdown encoded as
+// JSON, or NULL when there is no price breakdown.
+func priceBreakdownArg(breakdown *storage.PriceBreakdown) (interface{}, error) {
+	if breakdown == nil {
+		return nil, nil
+	}
+
+	encoded, err := json.Marshal(breakdown)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode price breakdown: %w", err)
+	}
+
+	return string(encoded), nil
+}
+
+func scanPriceBreakdown(column sql.NullString) (_ *storage.PriceBreakdown, err error) {
+	if !column.Valid {
+		return
+	}
+
+	var breakdown storage.PriceBreakdown
+
+	if err = json.Unmarshal([]byte(column.String), &breakdown); err != nil {
+		err = fmt.Errorf("failed to decode price breakdown: %w", err)
+		return
+	}
+
+	return &breakdown, nil
+}
+
+func exchangeRateArg(exchangeRate *storage.ExchangeRate) (interface{}, error) {
+	if exchangeRate == nil {
+		return nil, nil
+	}
+
+	encoded, err := json.Marshal(exchangeRate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode exchange rate: %w", err)
+	}
+
+	return string(encoded), nil
+}
+
+func scanExchangeRate(column sql.NullString) (_ *storage.ExchangeRate, err error) {
+	if !column.Valid {
+		return
+	}
+
+	var exchangeRate storage.ExchangeRate
+
+	if err = json.Unmarshal([]byte(column.String), &exchangeRate); err != nil {
+		err = fmt.Errorf("failed to decode exchange rate: %w", err)
+		return
+	}
+
+	return &exchangeRate, nil
+}
+
+func taxArg(tax *storage.Tax) (interface{}, error) {
+	if tax == nil {
+		return nil, nil
+	}
+
+	encoded, err := json.Marshal(tax)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode tax: %w", err)
+	}
+
+	return string(encoded), nil
+}
+
+func scanTax(column sql.NullString) (_ *storage.Tax, err error) {
+	if !column.Valid {
+		return
+	}
+
+	var tax storage.Tax
+
+	if err = json.Unmarshal([]byte(column.String), &tax); err != nil {
+		err = fmt.Errorf("failed to decode tax: %w", err)
+		return
+	}
+
+	return &tax, nil
+}
+
+// parcelsArg will return the parcels encoded as JSON, or NULL when there are no parcels.
+func parcelsArg(parcels []storage.Parcel) (interface{}, error) {
+	if len(parcels) == 0 {
+		return nil, nil
+	}
+
+	encoded, err := json.Marshal(parcels)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode parcels: %w", err)
+	}
+
+	return string(encoded), nil
+}
+
+func scanParcels(column sql.NullString) (parcels []storage.Parcel, err error) {
+	if !column.Valid {
+		return
+	}
+
+	if err = json.Unmarshal([]byte(column.String), &parcels); err != nil {
+		err = fmt.Errorf("failed to decode parcels: %w", err)
+		return
+	}
+
+	return parcels, nil
+}
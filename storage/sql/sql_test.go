@@ -0,0 +1,169 @@
+package sql_test
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lonnblad/shipment-service-backend/storage"
+	sqlstorage "github.com/lonnblad/shipment-service-backend/storage/sql"
+	"github.com/lonnblad/shipment-service-backend/storage/storagetest"
+)
+
+func Test_Conformance_SQLite(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storage.ShipmentStorage {
+		return newSQLiteStorage(t, filepath.Join(t.TempDir(), "shipments.db"))
+	})
+}
+
+func Test_Conformance_SQLite_TrackingEvents(t *testing.T) {
+	storagetest.RunTrackingEvents(t, func(t *testing.T) storage.TrackingEventStorage {
+		return newSQLiteStorage(t, filepath.Join(t.TempDir(), "shipments.db"))
+	})
+}
+
+func Test_Conformance_SQLite_Quotes(t *testing.T) {
+	storagetest.RunQuotes(t, func(t *testing.T) storage.QuoteStorage {
+		return newSQLiteStorage(t, filepath.Join(t.TempDir(), "shipments.db"))
+	})
+}
+
+func Test_Conformance_SQLite_RateCards(t *testing.T) {
+	storagetest.RunRateCards(t, func(t *testing.T) storage.RateCardStorage {
+		return newSQLiteStorage(t, filepath.Join(t.TempDir(), "shipments.db"))
+	})
+}
+
+func Test_SQLite_Reopen(t *testing.T) {
+	ctx := context.Background()
+	dsn := filepath.Join(t.TempDir(), "shipments.db")
+
+	shipmentStorage := newSQLiteStorage(t, dsn)
+
+	expected := storagetest.NewShipment(uuid.New().String())
+	require.NoError(t, shipmentStorage.StoreShipment(ctx, expected))
+
+	// Reopening the database should keep the data and not re-apply the migrations.
+	shipmentStorage = newSQLiteStorage(t, dsn)
+
+	actual, err := shipmentStorage.GetShipment(ctx, expected.TenantID, expected.ID)
+	require.NoError(t, err)
+	assert.Equal(t, expected, actual)
+
+	query := storage.SearchShipmentsQuery{Text: expected.Receiver.Name, Limit: 1}
+
+	page, err := shipmentStorage.SearchShipments(ctx, expected.TenantID, query)
+	require.NoError(t, err)
+	assert.Equal(t, []storage.Shipment{expected}, page.Shipments)
+}
+
+func Test_SQLite_SearchAcrossInstances(t *testing.T) {
+	ctx := context.Background()
+	dsn := filepath.Join(t.TempDir(), "shipments.db")
+
+	first, second := newSQLiteStorage(t, dsn), newSQLiteStorage(t, dsn)
+
+	// A shipment stored by one instance is searchable by the other right away.
+	expected := storagetest.NewShipment(uuid.New().String())
+	expected.Receiver.Name = "Anna Svensson"
+	require.NoError(t, first.StoreShipment(ctx, expected))
+
+	page, err := second.SearchShipments(ctx, expected.TenantID, storage.SearchShipmentsQuery{Text: "svens", Limit: 1})
+	require.NoError(t, err)
+	assert.Equal(t, []storage.Shipment{expected}, page.Shipments)
+	assert.Equal(t, 1, page.Total)
+}
+
+func Test_SQLite_MigratesLegacyPrices(t *testing.T) {
+	ctx := context.Background()
+	dsn := filepath.Join(t.TempDir(), "shipments.db")
+
+	db, err := sql.Open(sqlstorage.DriverSQLite, dsn)
+	require.NoError(t, err)
+
+	// Shipments written before the currency was stored are priced in whole SEK.
+	require.NoError(t, sqlstorage.MigrateTo(ctx, db, 12))
+
+	tenantID, shipmentID := uuid.New().String(), uuid.New().String()
+
+	_, err = db.ExecContext(ctx, `INSERT INTO shipments (
+    tenant_id, id, created_at, sender_name, sender_email, sender_address, sender_country_code,
+    receiver_name, receiver_email, receiver_address, receiver_country_code, package_weight, package_price,
+    status, cancelled_by, cancelled_at, cancellation_reason, cancellation_fee, cancellation_refund, price_breakdown
+) VALUES ($1, $2, 1, 'Sender', 'sender@example.com', 'Street 1', 'SE', 'Receiver', 'receiver@example.com', 'Street 2', 'DK',
+    10, 135, 'cancelled', 'sender', 2, 'Changed plans', 20, 115,
+    $3)`,
+		tenantID, shipmentID, `{"weight_class":"small","base_price":150,"region":"nordic","region_multiplier":10,`+
+			`"adjustments":[{"name":"discount","amount":-15}],"price":135}`)
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	// Opening the storage applies the rest of the migrations.
+	shipmentStorage := newSQLiteStorage(t, dsn)
+
+	actual, err := shipmentStorage.GetShipment(ctx, tenantID, shipmentID)
+	require.NoError(t, err)
+	assert.Equal(t, storage.Package{Weight: 10000, Price: 13500, Currency: "SEK"}, actual.Package)
+	assert.Equal(t, 2000, actual.Cancellation.Fee)
+	assert.Equal(t, 11500, actual.Cancellation.Refund)
+	assert.Equal(t, &storage.PriceBreakdown{
+		Currency:         "SEK",
+		WeightClass:      "small",
+		BasePrice:        15000,
+		Region:           "nordic",
+		RegionMultiplier: 10,
+		Adjustments:      []storage.PriceAdjustment{{Name: "discount", Amount: -1500}},
+		Price:            13500,
+	}, actual.PriceBreakdown)
+}
+
+func Test_SQLite_MigratesWeightsToGrams(t *testing.T) {
+	ctx := context.Background()
+	dsn := filepath.Join(t.TempDir(), "shipments.db")
+
+	db, err := sql.Open(sqlstorage.DriverSQLite, dsn)
+	require.NoError(t, err)
+
+	// Shipments written before the weights were stored in grams are weighed in kg.
+	require.NoError(t, sqlstorage.MigrateTo(ctx, db, 15))
+
+	tenantID, shipmentID := uuid.New().String(), uuid.New().String()
+
+	_, err = db.ExecContext(ctx, `INSERT INTO shipments (
+    tenant_id, id, created_at, sender_name, sender_email, sender_address, sender_country_code,
+    receiver_name, receiver_email, receiver_address, receiver_country_code, package_weight, package_price,
+    package_currency, package_length, package_width, package_height, status, price_breakdown
+) VALUES ($1, $2, 1, 'Sender', 'sender@example.com', 'Street 1', 'SE', 'Receiver', 'receiver@example.com', 'Street 2', 'DK',
+    10, 15000, 'SEK', 40, 30, 20, 'created', $3)`,
+		tenantID, shipmentID, `{"currency":"SEK","volumetric_weight":5,"chargeable_weight":10,"weight_class":"small",`+
+			`"base_price":15000,"region":"nordic","region_multiplier":10,"price":15000}`)
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	shipmentStorage := newSQLiteStorage(t, dsn)
+
+	actual, err := shipmentStorage.GetShipment(ctx, tenantID, shipmentID)
+	require.NoError(t, err)
+	assert.Equal(t, storage.Package{Weight: 10000, Length: 40, Width: 30, Height: 20, Price: 15000, Currency: "SEK"}, actual.Package)
+	assert.Equal(t, 5000, actual.PriceBreakdown.VolumetricWeight)
+	assert.Equal(t, 10000, actual.PriceBreakdown.ChargeableWeight)
+	assert.Equal(t, 15000, actual.PriceBreakdown.Price)
+}
+
+func newSQLiteStorage(t *testing.T, dsn string) *sqlstorage.ShipmentStorage {
+	t.Helper()
+
+	shipmentStorage, err := sqlstorage.NewShipmentStorage(context.Background(), sqlstorage.DriverSQLite, dsn)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		assert.NoError(t, shipmentStorage.Close())
+	})
+
+	return shipmentStorage
+}
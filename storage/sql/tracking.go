@@ -0,0 +1,141 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/lonnblad/shipment-service-backend/storage"
+	"github.com/lonnblad/shipment-service-backend/trace"
+)
+
+var _ storage.TrackingEventStorage = &ShipmentStorage{}
+
+const (
+	trackingEventColumns = `id, tenant_id, shipment_id, occurred_at, location, description, source`
+
+	insertTrackingEvent = `INSERT INTO tracking_events (` + trackingEventColumns + `)
+VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	selectTrackingEvents = `SELECT ` + trackingEventColumns + `
+FROM tracking_events
+WHERE tenant_id = $1 AND shipment_id = $2
+ORDER BY occurred_at, id`
+
+	selectLatestTrackingEvent = `SELECT ` + trackingEventColumns + `
+FROM tracking_events
+WHERE tenant_id = $1 AND shipment_id = $2
+ORDER BY occurred_at DESC, id DESC
+LIMIT 1`
+)
+
+func (s *ShipmentStorage) AppendTrackingEvent(ctx context.Context, event storage.TrackingEvent) error {
+	ctx, span := trace.Tracer().Start(ctx, "sql.AppendTrackingEvent")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tracking_event.tenant_id", event.TenantID),
+		attribute.String("tracking_event.shipment_id", event.ShipmentID),
+		attribute.String("tracking_event.id", event.ID),
+	)
+
+	_, err := s.db.ExecContext(ctx, insertTrackingEvent,
+		event.ID, event.TenantID, event.ShipmentID, event.OccurredAt.UnixNano(),
+		event.Location, event.Description, event.Source,
+	)
+	if isUniqueViolation(err) {
+		return fmt.Errorf("tracking event: %s already exists: %w", event.ID, storage.ErrConflict)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to insert tracking event: %w", wrapError(err))
+	}
+
+	return nil
+}
+
+func (s *ShipmentStorage) ListTrackingEvents(
+	ctx context.Context, tenantID, shipmentID string,
+) (_ []storage.TrackingEvent, err error) {
+	ctx, span := trace.Tracer().Start(ctx, "sql.ListTrackingEvents")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant_id", tenantID),
+		attribute.String("shipment_id", shipmentID),
+	)
+
+	rows, err := s.db.QueryContext(ctx, selectTrackingEvents, tenantID, shipmentID)
+	if err != nil {
+		err = fmt.Errorf("could not look up tracking events: %w", wrapError(err))
+		return
+	}
+
+	defer rows.Close()
+
+	events := []storage.TrackingEvent{}
+
+	for rows.Next() {
+		var event storage.TrackingEvent
+
+		if event, err = scanTrackingEvent(rows); err != nil {
+			err = fmt.Errorf("could not read tracking event: %w", wrapError(err))
+			return
+		}
+
+		events = append(events, event)
+	}
+
+	if err = rows.Err(); err != nil {
+		err = fmt.Errorf("could not read tracking events: %w", wrapError(err))
+		return
+	}
+
+	return events, nil
+}
+
+func (s *ShipmentStorage) LatestTrackingEvent(
+	ctx context.Context, tenantID, shipmentID string,
+) (_ storage.TrackingEvent, err error) {
+	ctx, span := trace.Tracer().Start(ctx, "sql.LatestTrackingEvent")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("tenant_id", tenantID),
+		attribute.String("shipment_id", shipmentID),
+	)
+
+	row := s.db.QueryRowContext(ctx, selectLatestTrackingEvent, tenantID, shipmentID)
+
+	event, err := scanTrackingEvent(row)
+	if err == sql.ErrNoRows {
+		err = fmt.Errorf("could not find a tracking event of shipment: %s: %w", shipmentID, storage.ErrNotFound)
+		return
+	}
+
+	if err != nil {
+		err = fmt.Errorf("could not look up tracking event: %w", wrapError(err))
+		return
+	}
+
+	return event, nil
+}
+
+func scanTrackingEvent(row scanner) (event storage.TrackingEvent, err error) {
+	var occurredAt int64
+
+	err = row.Scan(
+		&event.ID, &event.TenantID, &event.ShipmentID, &occurredAt,
+		&event.Location, &event.Description, &event.Source,
+	)
+	if err != nil {
+		return
+	}
+
+	event.OccurredAt = time.Unix(0, occurredAt).UTC()
+
+	return event, nil
+}
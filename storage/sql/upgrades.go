@@ -0,0 +1,110 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/lonnblad/shipment-service-backend/storage"
+)
+
+// The prices that were stored before the currency was stored are in whole
+// SEK, they are migrated to öre, and the weights that were stored before
+// they were stored in grams are in kg.
+const (
+	legacyCurrency   = "SEK"
+	legacyMinorUnits = 100
+	legacyGramsPerKg = 1000
+)
+
+// priceBreakdownTables are the tables with a price_breakdown column.
+var priceBreakdownTables = []string{"shipments", "quotes"}
+
+// upgradePriceBreakdownsToMinorUnits will convert the amounts of the price
+// breakdowns from whole SEK to the minor units of SEK, which is done in Go
+// as the breakdowns are stored as JSON.
+func upgradePriceBreakdownsToMinorUnits(ctx context.Context, tx *sql.Tx) error {
+	return upgradePriceBreakdowns(ctx, tx, func(breakdown *storage.PriceBreakdown) {
+		breakdown.Currency = legacyCurrency
+		breakdown.BasePrice *= legacyMinorUnits
+		breakdown.Price *= legacyMinorUnits
+
+		for idx := range breakdown.Adjustments {
+			breakdown.Adjustments[idx].Amount *= legacyMinorUnits
+		}
+	})
+}
+
+// upgradePriceBreakdownWeightsToGrams will convert the
+// weights of the price breakdowns from kg to grams.
+func upgradePriceBreakdownWeightsToGrams(ctx context.Context, tx *sql.Tx) error {
+	return upgradePriceBreakdowns(ctx, tx, func(breakdown *storage.PriceBreakdown) {
+		breakdown.VolumetricWeight *= legacyGramsPerKg
+		breakdown.ChargeableWeight *= legacyGramsPerKg
+	})
+}
+
+type storedPriceBreakdown struct {
+	tenantID, id string
+	breakdown    *storage.PriceBreakdown
+}
+
+// upgradePriceBreakdowns will apply the upgrade to every stored price
+// breakdown, the breakdowns are read before they are updated as the
+// transaction can't be used by an update while the rows are read.
+func upgradePriceBreakdowns(ctx context.Context, tx *sql.Tx, upgrade func(*storage.PriceBreakdown)) error {
+	for _, table := range priceBreakdownTables {
+		stored, err := selectPriceBreakdowns(ctx, tx, table)
+		if err != nil {
+			return err
+		}
+
+		update := `UPDATE ` + table + ` SET price_breakdown = $1 WHERE tenant_id = $2 AND id = $3`
+
+		for _, s := range stored {
+			upgrade(s.breakdown)
+
+			breakdown, err := priceBreakdownArg(s.breakdown)
+			if err != nil {
+				return err
+			}
+
+			if _, err = tx.ExecContext(ctx, update, breakdown, s.tenantID, s.id); err != nil {
+				return fmt.Errorf("failed to update the price breakdown of: %s: %w", table, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func selectPriceBreakdowns(ctx context.Context, tx *sql.Tx, table string) (stored []storedPriceBreakdown, err error) {
+	query := `SELECT tenant_id, id, price_breakdown FROM ` + table + ` WHERE price_breakdown IS NOT NULL`
+
+	rows, err := tx.QueryContext(ctx, query)
+	if err != nil {
+		err = fmt.Errorf("failed to select the price breakdowns of: %s: %w", table, err)
+		return
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			s      storedPriceBreakdown
+			column sql.NullString
+		)
+
+		if err = rows.Scan(&s.tenantID, &s.id, &column); err != nil {
+			return
+		}
+
+		if s.breakdown, err = scanPriceBreakdown(column); err != nil {
+			return
+		}
+
+		stored = append(stored, s)
+	}
+
+	return stored, rows.Err()
+}
@@ -9,23 +9,228 @@ import (
 type ShipmentStorage interface {
 	StoreShipment(context.Context, Shipment) error
 	GetShipment(_ context.Context, tenantID, shipmentID string) (Shipment, error)
-	ListShipments(_ context.Context, tenantID string, limit, offset int) ([]Shipment, error)
+	ListShipments(_ context.Context, tenantID string, query ListShipmentsQuery) (ShipmentsPage, error)
+	SearchShipments(_ context.Context, tenantID string, query SearchShipmentsQuery) (ShipmentsPage, error)
+
+	// UpdateShipment will update the sender, receiver, package, quote and
+	// price breakdown of the stored shipment, if its version is still the version of the shipment,
+	// and return the updated shipment with the next version. ErrConflict is
+	// returned if the version has changed.
+	UpdateShipment(context.Context, Shipment) (Shipment, error)
+
+	// UpdateShipmentStatus will apply the update to the shipment, if its
+	// version is still version, and return the updated shipment with the
+	// next version. ErrConflict is returned if the version has changed.
+	UpdateShipmentStatus(_ context.Context, tenantID, shipmentID string, version int, update StatusUpdate) (Shipment, error)
+}
+
+// TrackingEventStorage is an interface for managing storage
+// of the tracking events of shipments
+type TrackingEventStorage interface {
+	AppendTrackingEvent(context.Context, TrackingEvent) error
+
+	// ListTrackingEvents will return the tracking events of the
+	// shipment, ordered by when they occurred.
+	ListTrackingEvents(_ context.Context, tenantID, shipmentID string) ([]TrackingEvent, error)
+
+	// LatestTrackingEvent will return the tracking event of the shipment
+	// that occurred last, or ErrNotFound if there are no tracking events.
+	LatestTrackingEvent(_ context.Context, tenantID, shipmentID string) (TrackingEvent, error)
+}
+
+// QuoteStorage is an interface for managing storage of price quotes
+type QuoteStorage interface {
+	StoreQuote(context.Context, Quote) error
+	GetQuote(_ context.Context, tenantID, quoteID string) (Quote, error)
+}
+
+// RateCardStorage is an interface for managing storage of the rate cards of tenants
+type RateCardStorage interface {
+	// StoreRateCard will store the rate card, ErrConflict is returned
+	// if the tenant already has a rate card with the same version.
+	StoreRateCard(context.Context, RateCard) error
+
+	// ListRateCards will return the rate cards of the
+	// tenant, ordered by when they were uploaded.
+	ListRateCards(_ context.Context, tenantID string) ([]RateCard, error)
+}
+
+// ListShipmentsQuery selects a page of shipments, ordered by the sort.
+type ListShipmentsQuery struct {
+	Limit  int
+	Filter ShipmentsFilter
+	Sort   Sort
+
+	// Cursor is the position to continue listing from, nil starts from
+	// the beginning. It must have been created for the same Sort.
+	Cursor *Cursor
+
+	// Offset is the number of shipments to skip, it is only used
+	// when there is no Cursor.
+	//
+	// Deprecated: skipping is O(n), use Cursor instead.
+	Offset int
+}
+
+// SearchShipmentsQuery selects a page of the shipments where every
+// term of the text is the prefix of a word in the name, email or
+// address of the sender or receiver, ordered by creation time.
+type SearchShipmentsQuery struct {
+	Text   string
+	Limit  int
+	Cursor *Cursor
+}
+
+// ShipmentsPage is a page of shipments.
+type ShipmentsPage struct {
+	Shipments []Shipment
+
+	// Total is the number of the tenant's shipments selected by the filter.
+	Total int
+
+	// Prev is the cursor to the preceding page,
+	// it is nil when this is the first page.
+	Prev *Cursor
+
+	// Next is the cursor to the following page,
+	// it is nil when this is the last page.
+	Next *Cursor
+}
+
+// StatusUpdate is the new status of a shipment, and the
+// cancellation when the shipment is cancelled.
+type StatusUpdate struct {
+	Status       string
+	Cancellation *Cancellation
 }
 
 type Shipment struct {
 	ID        string
 	TenantID  string
 	CreatedAt time.Time
+	Status    string
+
+	// Version is incremented by every update of the shipment, an update
+	// is only applied if the shipment still has the version it was read with.
+	Version int
+
+	Sender       Sender
+	Receiver     Receiver
+	Package      Package
+	Cancellation *Cancellation
+
+	// QuoteID is the quote that the price is taken
+	// from, it is empty if there wasn't a quote.
+	QuoteID string
+
+	// PriceBreakdown is how the price was calculated, it is nil for
+	// shipments stored before the breakdown was stored.
+	PriceBreakdown *PriceBreakdown
+
+	// RateCardVersion is the version of the price rules that priced the
+	// shipment, it is empty for shipments stored before it was stored.
+	RateCardVersion string
+
+	// ExchangeRate is the rate that the price was converted with, it is
+	// nil when the price is in the currency of the rate card.
+	ExchangeRate *ExchangeRate
+
+	// Tax is the VAT of the price, it is nil for
+	// shipments stored before the tax was stored.
+	Tax *Tax
+
+	// Parcels are the parcels of the shipment, the Package is their total
+	// weight and price. It is empty for shipments stored before parcels.
+	Parcels []Parcel
+}
+
+// Quote is the price of a shipment, which is honoured when the
+// shipment is created with the quote before it expires.
+type Quote struct {
+	ID        string
+	TenantID  string
+	CreatedAt time.Time
+	ExpiresAt time.Time
 	Sender    Sender
 	Receiver  Receiver
 	Package   Package
+
+	// PriceBreakdown is how the price was calculated, it is nil for
+	// quotes stored before the breakdown was stored.
+	PriceBreakdown *PriceBreakdown
+
+	// ExchangeRate is the rate that the price was converted with, it is
+	// nil when the price is in the currency of the rate card.
+	ExchangeRate *ExchangeRate
+
+	// Tax is the VAT of the price, it is nil for
+	// quotes stored before the tax was stored.
+	Tax *Tax
+
+	// Parcels are the parcels of the quote, it is
+	// empty for quotes stored before parcels.
+	Parcels []Parcel
+}
+
+// RateCard is the rate card of a tenant, the Document is
+// the YAML or JSON rate card file as it was uploaded.
+type RateCard struct {
+	TenantID      string
+	Version       string
+	EffectiveFrom time.Time
+	UploadedAt    time.Time
+	Document      []byte
 }
 
+// PriceBreakdown is how the price of a package was calculated, it is
+// stored as JSON by the sql implementation. The amounts are in the minor
+// units of the currency of the rate card and the weights are in grams.
+type PriceBreakdown struct {
+	RateCardVersion  string            `json:"rate_card_version,omitempty"`
+	Currency         string            `json:"currency,omitempty"`
+	VolumetricWeight int               `json:"volumetric_weight,omitempty"`
+	ChargeableWeight int               `json:"chargeable_weight,omitempty"`
+	WeightClass      string            `json:"weight_class"`
+	BasePrice        int               `json:"base_price"`
+	Region           string            `json:"region"`
+	RegionMultiplier int               `json:"region_multiplier"`
+	Adjustments      []PriceAdjustment `json:"adjustments,omitempty"`
+	Price            int               `json:"price"`
+}
+
+// ExchangeRate is the rate that an amount was converted from one currency
+// to another with, it is stored as JSON by the sql implementation.
+type ExchangeRate struct {
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Rate     int64  `json:"rate"`
+	Rounding string `json:"rounding"`
+}
+
+// Tax is the VAT of a price, it is stored as JSON by the sql implementation.
+type Tax struct {
+	Rule        string `json:"rule"`
+	CountryCode string `json:"country_code,omitempty"`
+	Rate        int    `json:"rate"`
+	Net         int    `json:"net"`
+	Amount      int    `json:"amount"`
+	Gross       int    `json:"gross"`
+}
+
+// PriceAdjustment is a surcharge, or a discount when the amount is negative.
+type PriceAdjustment struct {
+	Name   string `json:"name"`
+	Amount int    `json:"amount"`
+}
+
+// Sender is who sends a shipment, the VATNumber is empty
+// when the sender isn't a business registered for VAT.
 type Sender struct {
 	Name        string
 	Email       string
 	Address     string
 	CountryCode string
+	VATNumber   string
 }
 
 type Receiver struct {
@@ -35,7 +240,50 @@ type Receiver struct {
 	CountryCode string
 }
 
+// Package is the weight in grams, dimensions and price of a shipment, the
+// dimensions are zero for packages stored without them. The price is in the
+// minor units of the currency. The prices of shipments stored before the
+// currency was stored are migrated to the minor units of SEK.
 type Package struct {
-	Weight int
-	Price  int
+	Weight   int
+	Length   int
+	Width    int
+	Height   int
+	Price    int
+	Currency string
+}
+
+// Parcel is one of the packages of a shipment, the weight is in grams, the
+// dimensions are in cm and the price is in the minor units of the currency
+// of the shipment. It is stored as JSON by the sql implementation.
+type Parcel struct {
+	ID             string          `json:"id"`
+	Weight         int             `json:"weight_grams"`
+	Length         int             `json:"length,omitempty"`
+	Width          int             `json:"width,omitempty"`
+	Height         int             `json:"height,omitempty"`
+	Price          int             `json:"price"`
+	PriceBreakdown *PriceBreakdown `json:"price_breakdown,omitempty"`
+}
+
+// Cancellation is who cancelled a shipment, when and why, and the
+// fee that was kept and the amount refunded of the price.
+type Cancellation struct {
+	CancelledBy string
+	CancelledAt time.Time
+	Reason      string
+	Fee         int
+	Refund      int
+}
+
+// TrackingEvent is an event in the tracking timeline of a shipment,
+// events that occurred at the same time are ordered by their ID.
+type TrackingEvent struct {
+	ID          string
+	TenantID    string
+	ShipmentID  string
+	OccurredAt  time.Time
+	Location    string
+	Description string
+	Source      string
 }
@@ -0,0 +1,96 @@
+package storagetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lonnblad/shipment-service-backend/storage"
+)
+
+// QuoteConstructor returns a new and empty QuoteStorage,
+// it is called once for every test case.
+type QuoteConstructor func(t *testing.T) storage.QuoteStorage
+
+type quoteTestCase struct {
+	name string
+	test func(t *testing.T, s storage.QuoteStorage)
+}
+
+// RunQuotes will run the conformance test suite against
+// the QuoteStorage returned by newStorage.
+func RunQuotes(t *testing.T, newStorage QuoteConstructor) {
+	testCases := []quoteTestCase{
+		{name: "StoreAndGet", test: testStoreAndGetQuote},
+		{name: "NotFound", test: testQuoteNotFound},
+		{name: "DuplicateID", test: testDuplicateQuoteID},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			tc.test(t, newStorage(t))
+		})
+	}
+}
+
+// NewQuote returns a quote with a new ID for the tenant, which expires in an hour.
+func NewQuote(tenantID string) storage.Quote {
+	shipment := NewShipment(tenantID)
+
+	return storage.Quote{
+		ID:        uuid.New().String(),
+		TenantID:  tenantID,
+		CreatedAt: shipment.CreatedAt,
+		ExpiresAt: shipment.CreatedAt.Add(time.Hour),
+		Sender:    shipment.Sender,
+		Receiver:  shipment.Receiver,
+		Package:   shipment.Package,
+
+		PriceBreakdown: shipment.PriceBreakdown,
+		ExchangeRate:   shipment.ExchangeRate,
+		Tax:            shipment.Tax,
+		Parcels:        shipment.Parcels,
+	}
+}
+
+func testStoreAndGetQuote(t *testing.T, s storage.QuoteStorage) {
+	ctx := context.Background()
+	expected := NewQuote(uuid.New().String())
+
+	require.NoError(t, s.StoreQuote(ctx, expected))
+
+	actual, err := s.GetQuote(ctx, expected.TenantID, expected.ID)
+	require.NoError(t, err)
+	assert.Equal(t, expected, actual)
+}
+
+func testQuoteNotFound(t *testing.T, s storage.QuoteStorage) {
+	ctx := context.Background()
+	quote := NewQuote(uuid.New().String())
+
+	require.NoError(t, s.StoreQuote(ctx, quote))
+
+	_, err := s.GetQuote(ctx, quote.TenantID, uuid.New().String())
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+
+	_, err = s.GetQuote(ctx, uuid.New().String(), quote.ID)
+	assert.ErrorIs(t, err, storage.ErrNotFound, "a quote must not be readable by another tenant")
+}
+
+func testDuplicateQuoteID(t *testing.T, s storage.QuoteStorage) {
+	ctx := context.Background()
+	original := NewQuote(uuid.New().String())
+
+	require.NoError(t, s.StoreQuote(ctx, original))
+
+	duplicate := NewQuote(original.TenantID)
+	duplicate.ID = original.ID
+
+	assert.ErrorIs(t, s.StoreQuote(ctx, duplicate), storage.ErrConflict)
+}
@@ -0,0 +1,89 @@
+package storagetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lonnblad/shipment-service-backend/storage"
+)
+
+// RateCardConstructor returns a new and empty RateCardStorage,
+// it is called once for every test case.
+type RateCardConstructor func(t *testing.T) storage.RateCardStorage
+
+type rateCardTestCase struct {
+	name string
+	test func(t *testing.T, s storage.RateCardStorage)
+}
+
+// RunRateCards will run the conformance test suite against
+// the RateCardStorage returned by newStorage.
+func RunRateCards(t *testing.T, newStorage RateCardConstructor) {
+	testCases := []rateCardTestCase{
+		{name: "StoreAndList", test: testStoreAndListRateCards},
+		{name: "Empty", test: testListNoRateCards},
+		{name: "DuplicateVersion", test: testDuplicateRateCardVersion},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			tc.test(t, newStorage(t))
+		})
+	}
+}
+
+// NewRateCard returns a rate card with the version for
+// the tenant, which was uploaded at the time.
+func NewRateCard(tenantID, version string, uploadedAt time.Time) storage.RateCard {
+	return storage.RateCard{
+		TenantID:      tenantID,
+		Version:       version,
+		EffectiveFrom: uploadedAt.Truncate(time.Hour),
+		UploadedAt:    uploadedAt,
+		Document:      []byte("version: " + version + "\n"),
+	}
+}
+
+func testStoreAndListRateCards(t *testing.T, s storage.RateCardStorage) {
+	ctx := context.Background()
+	tenantID := uuid.New().String()
+	now := time.Now().UTC()
+
+	// The rate cards are stored out of order.
+	second := NewRateCard(tenantID, "b", now)
+	first := NewRateCard(tenantID, "c", now.Add(-time.Hour))
+	third := NewRateCard(tenantID, "a", now.Add(time.Hour))
+	other := NewRateCard(uuid.New().String(), "a", now)
+
+	for _, rateCard := range []storage.RateCard{second, first, third, other} {
+		require.NoError(t, s.StoreRateCard(ctx, rateCard))
+	}
+
+	rateCards, err := s.ListRateCards(ctx, tenantID)
+	require.NoError(t, err)
+	assert.Equal(t, []storage.RateCard{first, second, third}, rateCards)
+}
+
+func testListNoRateCards(t *testing.T, s storage.RateCardStorage) {
+	rateCards, err := s.ListRateCards(context.Background(), uuid.New().String())
+	require.NoError(t, err)
+	assert.Empty(t, rateCards)
+	assert.NotNil(t, rateCards)
+}
+
+func testDuplicateRateCardVersion(t *testing.T, s storage.RateCardStorage) {
+	ctx := context.Background()
+	original := NewRateCard(uuid.New().String(), "a", time.Now().UTC())
+
+	require.NoError(t, s.StoreRateCard(ctx, original))
+
+	duplicate := NewRateCard(original.TenantID, original.Version, original.UploadedAt.Add(time.Minute))
+	assert.ErrorIs(t, s.StoreRateCard(ctx, duplicate), storage.ErrConflict)
+}
@@ -0,0 +1,814 @@
+// storagetest is a package with a conformance test suite, which all
+// implementations of storage.ShipmentStorage are expected to pass.
+package storagetest
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lonnblad/shipment-service-backend/storage"
+)
+
+// Constructor returns a new and empty ShipmentStorage, it is
+// called once for every test case.
+type Constructor func(t *testing.T) storage.ShipmentStorage
+
+type testCase struct {
+	name string
+	test func(t *testing.T, s storage.ShipmentStorage)
+}
+
+// Run will run the conformance test suite against
+// the ShipmentStorage returned by newStorage.
+func Run(t *testing.T, newStorage Constructor) {
+	testCases := []testCase{
+		{name: "StoreAndGet", test: testStoreAndGet},
+		{name: "NotFound", test: testNotFound},
+		{name: "DuplicateID", test: testDuplicateID},
+		{name: "TenantIsolation", test: testTenantIsolation},
+		{name: "UpdateShipment", test: testUpdateShipment},
+		{name: "UpdateStatus", test: testUpdateStatus},
+		{name: "Cancel", test: testCancel},
+		{name: "LimitAndOffset", test: testLimitAndOffset},
+		{name: "Ordering", test: testOrdering},
+		{name: "Cursor", test: testCursor},
+		{name: "CursorIsStableOnInserts", test: testCursorIsStableOnInserts},
+		{name: "BackwardCursor", test: testBackwardCursor},
+		{name: "PrevAndNext", test: testPrevAndNext},
+		{name: "Total", test: testTotal},
+		{name: "Filter", test: testFilter},
+		{name: "Sort", test: testSort},
+		{name: "SortWithFilter", test: testSortWithFilter},
+		{name: "CursorOfAnotherSort", test: testCursorOfAnotherSort},
+		{name: "Search", test: testSearch},
+		{name: "SearchPagination", test: testSearchPagination},
+		{name: "ConcurrentWriters", test: testConcurrentWriters},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			tc.test(t, newStorage(t))
+		})
+	}
+}
+
+// NewShipment returns a valid shipment with a new ID for the tenant.
+func NewShipment(tenantID string) storage.Shipment {
+	return storage.Shipment{
+		ID:        uuid.New().String(),
+		TenantID:  tenantID,
+		CreatedAt: time.Now().UTC(),
+		Status:    "created",
+		Version:   1,
+		Sender: storage.Sender{
+			Name:        "User Example A",
+			Email:       "user@example.com",
+			Address:     "Apt. Example 1A",
+			CountryCode: "SE",
+			VATNumber:   "SE556677889901",
+		},
+		Receiver: storage.Receiver{
+			Name:        "User Example B",
+			Email:       "user@example.com",
+			Address:     "Apt. Example 1B",
+			CountryCode: "DE",
+		},
+		Package: storage.Package{Weight: 10000, Length: 40, Width: 30, Height: 20, Price: 875, Currency: "EUR"},
+		PriceBreakdown: &storage.PriceBreakdown{
+			RateCardVersion:  "2021-01-01",
+			Currency:         "SEK",
+			VolumetricWeight: 4800,
+			ChargeableWeight: 10000,
+			WeightClass:      "small",
+			BasePrice:        10000,
+			Region:           "nordic",
+			RegionMultiplier: 10,
+			Price:            10000,
+		},
+		RateCardVersion: "2021-01-01",
+		ExchangeRate:    &storage.ExchangeRate{From: "SEK", To: "EUR", Rate: 87500, Rounding: "half-up"},
+		Tax:             &storage.Tax{Rule: "intra-eu-b2b", Rate: 0, Net: 875, Amount: 0, Gross: 875},
+		Parcels: []storage.Parcel{
+			{ID: uuid.New().String(), Weight: 6000, Length: 40, Width: 30, Height: 20, Price: 438},
+			{ID: uuid.New().String(), Weight: 4000, Price: 437, PriceBreakdown: &storage.PriceBreakdown{
+				RateCardVersion: "2021-01-01", Currency: "SEK", WeightClass: "small", BasePrice: 5000, RegionMultiplier: 10, Price: 5000,
+			}},
+		},
+	}
+}
+
+func testStoreAndGet(t *testing.T, s storage.ShipmentStorage) {
+	ctx := context.Background()
+	expected := NewShipment(uuid.New().String())
+
+	require.NoError(t, s.StoreShipment(ctx, expected))
+
+	actual, err := s.GetShipment(ctx, expected.TenantID, expected.ID)
+	require.NoError(t, err)
+	assert.Equal(t, expected, actual)
+
+	quoted := NewShipment(expected.TenantID)
+	quoted.QuoteID = uuid.New().String()
+
+	require.NoError(t, s.StoreShipment(ctx, quoted))
+
+	actual, err = s.GetShipment(ctx, quoted.TenantID, quoted.ID)
+	require.NoError(t, err)
+	assert.Equal(t, quoted, actual)
+}
+
+func testNotFound(t *testing.T, s storage.ShipmentStorage) {
+	ctx := context.Background()
+	tenantID := uuid.New().String()
+
+	_, err := s.GetShipment(ctx, tenantID, uuid.New().String())
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+
+	page, err := s.ListShipments(ctx, tenantID, storage.ListShipmentsQuery{Limit: 10})
+	require.NoError(t, err)
+	assert.Empty(t, page.Shipments)
+	assert.Zero(t, page.Total)
+	assert.Nil(t, page.Prev)
+	assert.Nil(t, page.Next)
+}
+
+func testDuplicateID(t *testing.T, s storage.ShipmentStorage) {
+	ctx := context.Background()
+	original := NewShipment(uuid.New().String())
+
+	require.NoError(t, s.StoreShipment(ctx, original))
+
+	duplicate := NewShipment(original.TenantID)
+	duplicate.ID = original.ID
+	duplicate.Package.Weight = 20000
+
+	assert.ErrorIs(t, s.StoreShipment(ctx, duplicate), storage.ErrConflict)
+
+	actual, err := s.GetShipment(ctx, original.TenantID, original.ID)
+	require.NoError(t, err)
+	assert.Equal(t, original, actual, "a duplicate must not overwrite the stored shipment")
+
+	// The same ID is allowed for another tenant.
+	otherTenant := NewShipment(uuid.New().String())
+	otherTenant.ID = original.ID
+
+	assert.NoError(t, s.StoreShipment(ctx, otherTenant))
+}
+
+func testUpdateShipment(t *testing.T, s storage.ShipmentStorage) {
+	ctx := context.Background()
+	shipment := NewShipment(uuid.New().String())
+	shipment.QuoteID = uuid.New().String()
+
+	require.NoError(t, s.StoreShipment(ctx, shipment))
+
+	update := shipment
+	update.CreatedAt = update.CreatedAt.Add(time.Hour)
+	update.QuoteID = ""
+	update.Receiver.Name = "Karin Lund"
+	update.Receiver.Address = "Storgatan 3"
+	update.Receiver.CountryCode = "NO"
+	update.Package.Weight = 20500
+	update.Package.Height = 60
+	update.Package.Price = 250
+	update.RateCardVersion = "2021-06-01"
+	update.ExchangeRate = &storage.ExchangeRate{From: "SEK", To: "EUR", Rate: 90000, Rounding: "half-up"}
+	update.Tax = &storage.Tax{Rule: "export", Rate: 0, Net: 250, Amount: 0, Gross: 250}
+	update.Parcels = []storage.Parcel{update.Parcels[0], {ID: uuid.New().String(), Weight: 14500, Price: 125}}
+
+	updated, err := s.UpdateShipment(ctx, update)
+	require.NoError(t, err)
+
+	// Only the sender, receiver, package, quote, price rules, exchange rate, tax and parcels are updated.
+	shipment.Version = 2
+	shipment.Receiver = update.Receiver
+	shipment.Package = update.Package
+	shipment.QuoteID = ""
+	shipment.RateCardVersion = update.RateCardVersion
+	shipment.ExchangeRate = update.ExchangeRate
+	shipment.Tax = update.Tax
+	shipment.Parcels = update.Parcels
+	assert.Equal(t, shipment, updated)
+
+	actual, err := s.GetShipment(ctx, shipment.TenantID, shipment.ID)
+	require.NoError(t, err)
+	assert.Equal(t, shipment, actual)
+
+	page, err := s.SearchShipments(ctx, shipment.TenantID, storage.SearchShipmentsQuery{Text: "karin", Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, []storage.Shipment{shipment}, page.Shipments)
+
+	// The previous address of the receiver is no longer searchable.
+	page, err = s.SearchShipments(ctx, shipment.TenantID, storage.SearchShipmentsQuery{Text: "1b", Limit: 10})
+	require.NoError(t, err)
+	assert.Empty(t, page.Shipments)
+
+	// The shipment is only updated if it still has the version it was read with.
+	_, err = s.UpdateShipment(ctx, update)
+	assert.ErrorIs(t, err, storage.ErrConflict)
+
+	actual, err = s.GetShipment(ctx, shipment.TenantID, shipment.ID)
+	require.NoError(t, err)
+	assert.Equal(t, shipment, actual)
+
+	update.TenantID = uuid.New().String()
+	_, err = s.UpdateShipment(ctx, update)
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+}
+
+func testUpdateStatus(t *testing.T, s storage.ShipmentStorage) {
+	ctx := context.Background()
+	shipment := NewShipment(uuid.New().String())
+
+	require.NoError(t, s.StoreShipment(ctx, shipment))
+
+	updated, err := s.UpdateShipmentStatus(ctx, shipment.TenantID, shipment.ID, 1, storage.StatusUpdate{Status: "booked"})
+	require.NoError(t, err)
+
+	shipment.Status = "booked"
+	shipment.Version = 2
+	assert.Equal(t, shipment, updated)
+
+	actual, err := s.GetShipment(ctx, shipment.TenantID, shipment.ID)
+	require.NoError(t, err)
+	assert.Equal(t, shipment, actual)
+
+	// The status is only updated if the shipment still has the version it was read with.
+	_, err = s.UpdateShipmentStatus(ctx, shipment.TenantID, shipment.ID, 1, storage.StatusUpdate{Status: "cancelled"})
+	assert.ErrorIs(t, err, storage.ErrConflict)
+
+	actual, err = s.GetShipment(ctx, shipment.TenantID, shipment.ID)
+	require.NoError(t, err)
+	assert.Equal(t, shipment, actual)
+
+	_, err = s.UpdateShipmentStatus(ctx, uuid.New().String(), shipment.ID, 2, storage.StatusUpdate{Status: "picked_up"})
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+}
+
+func testCancel(t *testing.T, s storage.ShipmentStorage) {
+	ctx := context.Background()
+	shipment := NewShipment(uuid.New().String())
+
+	require.NoError(t, s.StoreShipment(ctx, shipment))
+
+	cancellation := storage.Cancellation{
+		CancelledBy: "user@example.com",
+		CancelledAt: time.Now().UTC(),
+		Reason:      "Ordered twice",
+		Fee:         10,
+		Refund:      90,
+	}
+
+	update := storage.StatusUpdate{Status: "cancelled", Cancellation: &cancellation}
+
+	updated, err := s.UpdateShipmentStatus(ctx, shipment.TenantID, shipment.ID, 1, update)
+	require.NoError(t, err)
+
+	shipment.Status = "cancelled"
+	shipment.Version = 2
+	shipment.Cancellation = &cancellation
+	assert.Equal(t, shipment, updated)
+
+	actual, err := s.GetShipment(ctx, shipment.TenantID, shipment.ID)
+	require.NoError(t, err)
+	assert.Equal(t, shipment, actual)
+
+	// An update without a cancellation keeps the cancellation.
+	updated, err = s.UpdateShipmentStatus(ctx, shipment.TenantID, shipment.ID, 2, storage.StatusUpdate{Status: "returned"})
+	require.NoError(t, err)
+	assert.Equal(t, &cancellation, updated.Cancellation)
+}
+
+func testTenantIsolation(t *testing.T, s storage.ShipmentStorage) {
+	ctx := context.Background()
+	tenantA, tenantB := uuid.New().String(), uuid.New().String()
+
+	shipmentA := NewShipment(tenantA)
+	shipmentB := NewShipment(tenantB)
+
+	require.NoError(t, s.StoreShipment(ctx, shipmentA))
+	require.NoError(t, s.StoreShipment(ctx, shipmentB))
+
+	_, err := s.GetShipment(ctx, tenantB, shipmentA.ID)
+	assert.ErrorIs(t, err, storage.ErrNotFound, "a shipment must not be readable by another tenant")
+
+	page, err := s.ListShipments(ctx, tenantA, storage.ListShipmentsQuery{Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, []storage.Shipment{shipmentA}, page.Shipments)
+
+	page, err = s.ListShipments(ctx, tenantB, storage.ListShipmentsQuery{Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, []storage.Shipment{shipmentB}, page.Shipments)
+
+	// A cursor from one tenant must not leak shipments from another.
+	cursor := storage.Cursor{CreatedAt: shipmentA.CreatedAt.Add(-time.Hour), ID: shipmentA.ID}
+
+	page, err = s.ListShipments(ctx, tenantB, storage.ListShipmentsQuery{Limit: 10, Cursor: &cursor})
+	require.NoError(t, err)
+	assert.Equal(t, []storage.Shipment{shipmentB}, page.Shipments)
+}
+
+func testLimitAndOffset(t *testing.T, s storage.ShipmentStorage) {
+	ctx := context.Background()
+	tenantID := uuid.New().String()
+	expected := storeShipments(t, s, tenantID, 5)
+
+	for _, tc := range []struct {
+		name          string
+		limit, offset int
+		expected      []storage.Shipment
+	}{
+		{name: "ZeroLimit", limit: 0, offset: 0, expected: []storage.Shipment{}},
+		{name: "LimitBelowTotal", limit: 2, offset: 0, expected: expected[:2]},
+		{name: "LimitEqualToTotal", limit: 5, offset: 0, expected: expected},
+		{name: "LimitAboveTotal", limit: 10, offset: 0, expected: expected},
+		{name: "Offset", limit: 2, offset: 2, expected: expected[2:4]},
+		{name: "OffsetOnLastPage", limit: 2, offset: 4, expected: expected[4:]},
+		{name: "OffsetEqualToTotal", limit: 2, offset: 5, expected: []storage.Shipment{}},
+		{name: "OffsetAboveTotal", limit: 2, offset: 10, expected: []storage.Shipment{}},
+	} {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			page, err := s.ListShipments(ctx, tenantID, storage.ListShipmentsQuery{Limit: tc.limit, Offset: tc.offset})
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, page.Shipments)
+		})
+	}
+}
+
+func testOrdering(t *testing.T, s storage.ShipmentStorage) {
+	ctx := context.Background()
+	tenantID := uuid.New().String()
+	expected := storeShipments(t, s, tenantID, 7)
+
+	var actual []storage.Shipment
+
+	for offset := 0; offset < len(expected); offset += 3 {
+		page, err := s.ListShipments(ctx, tenantID, storage.ListShipmentsQuery{Limit: 3, Offset: offset})
+		require.NoError(t, err)
+
+		actual = append(actual, page.Shipments...)
+	}
+
+	assert.Equal(t, expected, actual, "pages must be disjoint, complete and ordered by creation time and ID")
+}
+
+func testCursor(t *testing.T, s storage.ShipmentStorage) {
+	tenantID := uuid.New().String()
+	expected := storeShipments(t, s, tenantID, 7)
+
+	for _, limit := range []int{1, 3, 7, 10} {
+		actual := listAll(t, s, tenantID, storage.ListShipmentsQuery{Limit: limit})
+		assert.Equal(t, expected, actual, "limit: %d", limit)
+	}
+}
+
+func testCursorIsStableOnInserts(t *testing.T, s storage.ShipmentStorage) {
+	ctx := context.Background()
+	tenantID := uuid.New().String()
+	expected := storeShipments(t, s, tenantID, 4)
+
+	first, err := s.ListShipments(ctx, tenantID, storage.ListShipmentsQuery{Limit: 2})
+	require.NoError(t, err)
+	require.NotNil(t, first.Next)
+
+	// A shipment created while paging ends up last
+	// instead of shifting the following pages.
+	created := NewShipment(tenantID)
+	created.CreatedAt = expected[len(expected)-1].CreatedAt.Add(time.Second)
+	require.NoError(t, s.StoreShipment(ctx, created))
+
+	actual := append(first.Shipments, listAll(t, s, tenantID, storage.ListShipmentsQuery{Limit: 2, Cursor: first.Next})...)
+	assert.Equal(t, append(expected, created), actual)
+}
+
+func testBackwardCursor(t *testing.T, s storage.ShipmentStorage) {
+	tenantID := uuid.New().String()
+	expected := storeShipments(t, s, tenantID, 7)
+
+	for _, limit := range []int{1, 3, 7, 10} {
+		actual := listAllBackward(t, s, tenantID, storage.ListShipmentsQuery{Limit: limit})
+		assert.Equal(t, expected, actual, "limit: %d", limit)
+	}
+}
+
+func testPrevAndNext(t *testing.T, s storage.ShipmentStorage) {
+	ctx := context.Background()
+	tenantID := uuid.New().String()
+	expected := storeShipments(t, s, tenantID, 5)
+
+	first, err := s.ListShipments(ctx, tenantID, storage.ListShipmentsQuery{Limit: 2})
+	require.NoError(t, err)
+	assert.Equal(t, expected[:2], first.Shipments)
+	assert.Nil(t, first.Prev, "the first page has no previous page")
+	require.NotNil(t, first.Next)
+
+	second, err := s.ListShipments(ctx, tenantID, storage.ListShipmentsQuery{Limit: 2, Cursor: first.Next})
+	require.NoError(t, err)
+	assert.Equal(t, expected[2:4], second.Shipments)
+	require.NotNil(t, second.Prev)
+	require.NotNil(t, second.Next)
+
+	back, err := s.ListShipments(ctx, tenantID, storage.ListShipmentsQuery{Limit: 2, Cursor: second.Prev})
+	require.NoError(t, err)
+	assert.Equal(t, first.Shipments, back.Shipments)
+	assert.Nil(t, back.Prev)
+	assert.Equal(t, first.Next, back.Next)
+
+	third, err := s.ListShipments(ctx, tenantID, storage.ListShipmentsQuery{Limit: 2, Cursor: second.Next})
+	require.NoError(t, err)
+	assert.Equal(t, expected[4:], third.Shipments)
+	assert.NotNil(t, third.Prev)
+	assert.Nil(t, third.Next, "the last page has no next page")
+
+	cursor := storage.LastPageCursor(storage.Sort{})
+
+	last, err := s.ListShipments(ctx, tenantID, storage.ListShipmentsQuery{Limit: 2, Cursor: &cursor})
+	require.NoError(t, err)
+	assert.Equal(t, expected[3:], last.Shipments)
+	assert.NotNil(t, last.Prev)
+	assert.Nil(t, last.Next)
+
+	offset, err := s.ListShipments(ctx, tenantID, storage.ListShipmentsQuery{Limit: 2, Offset: 1})
+	require.NoError(t, err)
+	assert.Equal(t, expected[1:3], offset.Shipments)
+	assert.NotNil(t, offset.Prev, "a page after an offset has a previous page")
+	assert.NotNil(t, offset.Next)
+}
+
+func testTotal(t *testing.T, s storage.ShipmentStorage) {
+	ctx := context.Background()
+	tenantID := uuid.New().String()
+
+	storeShipments(t, s, tenantID, 5)
+	storeShipments(t, s, uuid.New().String(), 2)
+
+	for _, query := range []storage.ListShipmentsQuery{
+		{Limit: 0},
+		{Limit: 2},
+		{Limit: 2, Offset: 4},
+		{Limit: 10},
+	} {
+		page, err := s.ListShipments(ctx, tenantID, query)
+		require.NoError(t, err)
+		assert.Equal(t, 5, page.Total, "query: %+v", query)
+	}
+}
+
+func testFilter(t *testing.T, s storage.ShipmentStorage) {
+	ctx := context.Background()
+	tenantID := uuid.New().String()
+	shipments := storeShipments(t, s, tenantID, 12)
+
+	storeVariedShipments(t, s, shipments)
+
+	other := NewShipment(uuid.New().String())
+	require.NoError(t, s.StoreShipment(ctx, other))
+
+	all := listAll(t, s, tenantID, storage.ListShipmentsQuery{Limit: 100})
+	createdAt := all[len(all)/2].CreatedAt
+
+	intPtr := func(i int) *int { return &i }
+
+	for _, tc := range []struct {
+		name   string
+		filter storage.ShipmentsFilter
+	}{
+		{name: "None", filter: storage.ShipmentsFilter{}},
+		{name: "SenderCountryCode", filter: storage.ShipmentsFilter{SenderCountryCode: "DK"}},
+		{name: "ReceiverCountryCode", filter: storage.ShipmentsFilter{ReceiverCountryCode: "US"}},
+		{name: "Countries", filter: storage.ShipmentsFilter{SenderCountryCode: "SE", ReceiverCountryCode: "US"}},
+		{name: "UnknownCountryCode", filter: storage.ShipmentsFilter{SenderCountryCode: "FI"}},
+		{name: "CreatedFrom", filter: storage.ShipmentsFilter{CreatedFrom: createdAt}},
+		{name: "CreatedTo", filter: storage.ShipmentsFilter{CreatedTo: createdAt}},
+		{name: "CreatedFromTo", filter: storage.ShipmentsFilter{CreatedFrom: all[2].CreatedAt, CreatedTo: createdAt}},
+		{name: "MinWeight", filter: storage.ShipmentsFilter{MinWeight: intPtr(25)}},
+		{name: "MaxWeight", filter: storage.ShipmentsFilter{MaxWeight: intPtr(25)}},
+		{name: "WeightRange", filter: storage.ShipmentsFilter{MinWeight: intPtr(10), MaxWeight: intPtr(40)}},
+		{name: "PriceRange", filter: storage.ShipmentsFilter{MinPrice: intPtr(600), MaxPrice: intPtr(900)}},
+		{name: "Currency", filter: storage.ShipmentsFilter{Currency: "SEK"}},
+		{name: "PriceRangeInCurrency", filter: storage.ShipmentsFilter{Currency: "EUR", MinPrice: intPtr(600), MaxPrice: intPtr(900)}},
+		{name: "Combined", filter: storage.ShipmentsFilter{
+			SenderCountryCode: "SE",
+			CreatedTo:         all[len(all)-1].CreatedAt,
+			MinWeight:         intPtr(5),
+			MaxPrice:          intPtr(950),
+		}},
+	} {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			expected := []storage.Shipment{}
+
+			for _, shipment := range all {
+				if tc.filter.Matches(shipment) {
+					expected = append(expected, shipment)
+				}
+			}
+
+			page, err := s.ListShipments(ctx, tenantID, storage.ListShipmentsQuery{Limit: 1, Filter: tc.filter})
+			require.NoError(t, err)
+			assert.Equal(t, len(expected), page.Total)
+
+			for _, limit := range []int{1, 3, 100} {
+				actual := append([]storage.Shipment{}, listAll(t, s, tenantID, storage.ListShipmentsQuery{Limit: limit, Filter: tc.filter})...)
+				assert.Equal(t, expected, actual, "limit: %d", limit)
+
+				actual = append([]storage.Shipment{}, listAllBackward(t, s, tenantID, storage.ListShipmentsQuery{Limit: limit, Filter: tc.filter})...)
+				assert.Equal(t, expected, actual, "backward, limit: %d", limit)
+			}
+		})
+	}
+}
+
+func testSort(t *testing.T, s storage.ShipmentStorage) {
+	tenantID := uuid.New().String()
+	storeVariedShipments(t, s, storeShipments(t, s, tenantID, 12))
+
+	for _, field := range []storage.SortField{
+		storage.SortByCreatedAt,
+		storage.SortByPackagePrice,
+		storage.SortByPackageWeight,
+		storage.SortBySenderCountryCode,
+		storage.SortByReceiverCountryCode,
+	} {
+		for _, descending := range []bool{false, true} {
+			order := storage.Sort{Field: field, Descending: descending}
+			assertSorted(t, s, tenantID, storage.ShipmentsFilter{}, order)
+		}
+	}
+}
+
+func testSortWithFilter(t *testing.T, s storage.ShipmentStorage) {
+	tenantID := uuid.New().String()
+	shipments := storeShipments(t, s, tenantID, 12)
+	storeVariedShipments(t, s, shipments)
+
+	intPtr := func(i int) *int { return &i }
+
+	for _, filter := range []storage.ShipmentsFilter{
+		{SenderCountryCode: "SE"},
+		{ReceiverCountryCode: "US"},
+		{CreatedFrom: shipments[3].CreatedAt},
+		{MinWeight: intPtr(10), MaxWeight: intPtr(40)},
+		{MinPrice: intPtr(600)},
+		{SenderCountryCode: "DK", MaxPrice: intPtr(900)},
+		{Currency: "SEK"},
+		{Currency: "EUR", MinPrice: intPtr(600)},
+	} {
+		for _, order := range []storage.Sort{
+			{Field: storage.SortByCreatedAt, Descending: true},
+			{Field: storage.SortByPackagePrice},
+			{Field: storage.SortByPackageWeight, Descending: true},
+			{Field: storage.SortBySenderCountryCode},
+			{Field: storage.SortByReceiverCountryCode, Descending: true},
+		} {
+			assertSorted(t, s, tenantID, filter, order)
+		}
+	}
+}
+
+func testCursorOfAnotherSort(t *testing.T, s storage.ShipmentStorage) {
+	ctx := context.Background()
+	tenantID := uuid.New().String()
+	storeShipments(t, s, tenantID, 3)
+
+	page, err := s.ListShipments(ctx, tenantID, storage.ListShipmentsQuery{Limit: 1})
+	require.NoError(t, err)
+	require.NotNil(t, page.Next)
+
+	query := storage.ListShipmentsQuery{Limit: 1, Cursor: page.Next, Sort: storage.Sort{Field: storage.SortByPackagePrice}}
+
+	_, err = s.ListShipments(ctx, tenantID, query)
+	assert.ErrorIs(t, err, storage.ErrInvalidCursor)
+}
+
+func testSearch(t *testing.T, s storage.ShipmentStorage) {
+	ctx := context.Background()
+	tenantID := uuid.New().String()
+
+	anna := NewShipment(tenantID)
+	anna.Receiver.Name = "Anna Svensson"
+	anna.Receiver.Email = "anna.svensson@example.se"
+
+	annika := NewShipment(tenantID)
+	annika.CreatedAt = anna.CreatedAt.Add(time.Millisecond)
+	annika.Sender.Name = "Annika Berg"
+	annika.Sender.Address = "Storgatan 12, Göteborg"
+
+	otherTenant := NewShipment(uuid.New().String())
+	otherTenant.Receiver.Name = "Anna Svensson"
+
+	for _, shipment := range []storage.Shipment{anna, annika, otherTenant} {
+		require.NoError(t, s.StoreShipment(ctx, shipment))
+	}
+
+	for _, tc := range []struct {
+		text     string
+		expected []storage.Shipment
+	}{
+		{text: "anna svensson", expected: []storage.Shipment{anna}},
+		{text: "ANNA SV", expected: []storage.Shipment{anna}},
+		{text: "ann", expected: []storage.Shipment{anna, annika}},
+		{text: "svensson@example", expected: []storage.Shipment{anna}},
+		{text: "storgatan göte", expected: []storage.Shipment{annika}},
+		{text: "annika svensson", expected: []storage.Shipment{}},
+		{text: "nna", expected: []storage.Shipment{}},
+	} {
+		page, err := s.SearchShipments(ctx, tenantID, storage.SearchShipmentsQuery{Text: tc.text, Limit: 10})
+		require.NoError(t, err)
+		assert.Equal(t, tc.expected, page.Shipments, "text: %s", tc.text)
+		assert.Equal(t, len(tc.expected), page.Total, "text: %s", tc.text)
+	}
+}
+
+func testSearchPagination(t *testing.T, s storage.ShipmentStorage) {
+	ctx := context.Background()
+	tenantID := uuid.New().String()
+	expected := storeShipments(t, s, tenantID, 5)
+
+	var actual []storage.Shipment
+
+	query := storage.SearchShipmentsQuery{Text: "example", Limit: 2}
+
+	for {
+		page, err := s.SearchShipments(ctx, tenantID, query)
+		require.NoError(t, err)
+		assert.Equal(t, len(expected), page.Total)
+
+		actual = append(actual, page.Shipments...)
+
+		if page.Next == nil {
+			break
+		}
+
+		query.Cursor = page.Next
+	}
+
+	assert.Equal(t, expected, actual)
+
+	cursor := storage.LastPageCursor(storage.Sort{})
+
+	page, err := s.SearchShipments(ctx, tenantID, storage.SearchShipmentsQuery{Text: "example", Limit: 2, Cursor: &cursor})
+	require.NoError(t, err)
+	assert.Equal(t, expected[3:], page.Shipments)
+	assert.NotNil(t, page.Prev)
+	assert.Nil(t, page.Next)
+}
+
+func testConcurrentWriters(t *testing.T, s storage.ShipmentStorage) {
+	const writers, writesPerWriter = 8, 10
+
+	ctx := context.Background()
+	tenantID := uuid.New().String()
+
+	var wg sync.WaitGroup
+
+	errs := make(chan error, writers*writesPerWriter)
+
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for i := 0; i < writesPerWriter; i++ {
+				errs <- s.StoreShipment(ctx, NewShipment(tenantID))
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	page, err := s.ListShipments(ctx, tenantID, storage.ListShipmentsQuery{Limit: writers*writesPerWriter + 1})
+	require.NoError(t, err)
+	assert.Len(t, page.Shipments, writers*writesPerWriter)
+}
+
+// storeShipments will store count new shipments for the tenant and
+// return them in the order they are expected to be listed in.
+//
+// Every other shipment shares its creation time with the previous
+// one, to cover that the ID decides the order between them.
+func storeShipments(t *testing.T, s storage.ShipmentStorage, tenantID string, count int) []storage.Shipment {
+	t.Helper()
+
+	createdAt := time.Now().UTC()
+	shipments := make([]storage.Shipment, count)
+
+	for idx := range shipments {
+		shipments[idx] = NewShipment(tenantID)
+		shipments[idx].CreatedAt = createdAt.Add(time.Duration(idx/2) * time.Millisecond)
+
+		require.NoError(t, s.StoreShipment(context.Background(), shipments[idx]))
+	}
+
+	sort.Slice(shipments, func(i, j int) bool {
+		return storage.Sort{}.Less(shipments[i], shipments[j])
+	})
+
+	return shipments
+}
+
+// listAll will follow the cursors from the one of the query until the last page.
+func listAll(t *testing.T, s storage.ShipmentStorage, tenantID string, query storage.ListShipmentsQuery) []storage.Shipment {
+	t.Helper()
+
+	var shipments []storage.Shipment
+
+	for {
+		page, err := s.ListShipments(context.Background(), tenantID, query)
+		require.NoError(t, err)
+		require.LessOrEqual(t, len(page.Shipments), query.Limit)
+
+		shipments = append(shipments, page.Shipments...)
+
+		if page.Next == nil {
+			return shipments
+		}
+
+		query.Cursor = page.Next
+	}
+}
+
+// listAllBackward will follow the previous cursors from the last page until the first page.
+func listAllBackward(t *testing.T, s storage.ShipmentStorage, tenantID string, query storage.ListShipmentsQuery) []storage.Shipment {
+	t.Helper()
+
+	var shipments []storage.Shipment
+
+	cursor := storage.LastPageCursor(query.Sort)
+	query.Cursor = &cursor
+
+	for {
+		page, err := s.ListShipments(context.Background(), tenantID, query)
+		require.NoError(t, err)
+		require.LessOrEqual(t, len(page.Shipments), query.Limit)
+
+		shipments = append(page.Shipments, shipments...)
+
+		if page.Prev == nil {
+			return shipments
+		}
+
+		query.Cursor = page.Prev
+	}
+}
+
+// storeVariedShipments will store every shipment again with a new ID and
+// varying fields, to be selected and sorted by different fields.
+func storeVariedShipments(t *testing.T, s storage.ShipmentStorage, shipments []storage.Shipment) {
+	t.Helper()
+
+	for idx := range shipments {
+		shipments[idx].ID = uuid.New().String()
+		shipments[idx].Sender.CountryCode = []string{"SE", "DK", "NO"}[idx%3]
+		shipments[idx].Receiver.CountryCode = []string{"DE", "US"}[idx%2]
+		shipments[idx].Package.Weight = idx * 5
+		shipments[idx].Package.Price = 1000 - (idx/2)*100
+		shipments[idx].Package.Currency = []string{"EUR", "SEK", "SEK", "EUR"}[idx%4]
+
+		require.NoError(t, s.StoreShipment(context.Background(), shipments[idx]))
+	}
+}
+
+// assertSorted will assert that paging through the shipments selected by the
+// filter, both forward and backward, lists them in the order of the sort.
+func assertSorted(t *testing.T, s storage.ShipmentStorage, tenantID string, filter storage.ShipmentsFilter, order storage.Sort) {
+	t.Helper()
+
+	expected := []storage.Shipment{}
+
+	for _, shipment := range listAll(t, s, tenantID, storage.ListShipmentsQuery{Limit: 100}) {
+		if filter.Matches(shipment) {
+			expected = append(expected, shipment)
+		}
+	}
+
+	sort.Slice(expected, func(i, j int) bool { return order.Less(expected[i], expected[j]) })
+
+	for _, limit := range []int{1, 4, 100} {
+		query := storage.ListShipmentsQuery{Limit: limit, Filter: filter, Sort: order}
+
+		actual := append([]storage.Shipment{}, listAll(t, s, tenantID, query)...)
+		assert.Equal(t, expected, actual, "sort: %+v, filter: %+v, limit: %d", order, filter, limit)
+
+		actual = append([]storage.Shipment{}, listAllBackward(t, s, tenantID, query)...)
+		assert.Equal(t, expected, actual, "backward, sort: %+v, filter: %+v, limit: %d", order, filter, limit)
+	}
+}
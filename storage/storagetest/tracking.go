@@ -0,0 +1,136 @@
+package storagetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lonnblad/shipment-service-backend/storage"
+)
+
+// TrackingEventConstructor returns a new and empty TrackingEventStorage,
+// it is called once for every test case.
+type TrackingEventConstructor func(t *testing.T) storage.TrackingEventStorage
+
+type trackingEventTestCase struct {
+	name string
+	test func(t *testing.T, s storage.TrackingEventStorage)
+}
+
+// RunTrackingEvents will run the conformance test suite against
+// the TrackingEventStorage returned by newStorage.
+func RunTrackingEvents(t *testing.T, newStorage TrackingEventConstructor) {
+	testCases := []trackingEventTestCase{
+		{name: "AppendAndList", test: testAppendAndListTrackingEvents},
+		{name: "Latest", test: testLatestTrackingEvent},
+		{name: "NoEvents", test: testNoTrackingEvents},
+		{name: "DuplicateID", test: testDuplicateTrackingEventID},
+		{name: "ShipmentIsolation", test: testTrackingEventShipmentIsolation},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			tc.test(t, newStorage(t))
+		})
+	}
+}
+
+// NewTrackingEvent returns a tracking event with a new ID for the shipment.
+func NewTrackingEvent(tenantID, shipmentID string, occurredAt time.Time) storage.TrackingEvent {
+	return storage.TrackingEvent{
+		ID:          uuid.New().String(),
+		TenantID:    tenantID,
+		ShipmentID:  shipmentID,
+		OccurredAt:  occurredAt.UTC(),
+		Location:    "Stockholm, SE",
+		Description: "Arrived at the sorting facility",
+		Source:      "carrier",
+	}
+}
+
+func testAppendAndListTrackingEvents(t *testing.T, s storage.TrackingEventStorage) {
+	ctx := context.Background()
+	tenantID, shipmentID := uuid.New().String(), uuid.New().String()
+	now := time.Now()
+
+	// The events are appended out of order, but listed in the order they occurred.
+	second := NewTrackingEvent(tenantID, shipmentID, now)
+	first := NewTrackingEvent(tenantID, shipmentID, now.Add(-time.Hour))
+	third := NewTrackingEvent(tenantID, shipmentID, now.Add(time.Hour))
+
+	for _, event := range []storage.TrackingEvent{second, first, third} {
+		require.NoError(t, s.AppendTrackingEvent(ctx, event))
+	}
+
+	events, err := s.ListTrackingEvents(ctx, tenantID, shipmentID)
+	require.NoError(t, err)
+	assert.Equal(t, []storage.TrackingEvent{first, second, third}, events)
+}
+
+func testLatestTrackingEvent(t *testing.T, s storage.TrackingEventStorage) {
+	ctx := context.Background()
+	tenantID, shipmentID := uuid.New().String(), uuid.New().String()
+	now := time.Now()
+
+	latest := NewTrackingEvent(tenantID, shipmentID, now)
+
+	require.NoError(t, s.AppendTrackingEvent(ctx, latest))
+	require.NoError(t, s.AppendTrackingEvent(ctx, NewTrackingEvent(tenantID, shipmentID, now.Add(-time.Minute))))
+
+	actual, err := s.LatestTrackingEvent(ctx, tenantID, shipmentID)
+	require.NoError(t, err)
+	assert.Equal(t, latest, actual)
+}
+
+func testNoTrackingEvents(t *testing.T, s storage.TrackingEventStorage) {
+	ctx := context.Background()
+	tenantID, shipmentID := uuid.New().String(), uuid.New().String()
+
+	events, err := s.ListTrackingEvents(ctx, tenantID, shipmentID)
+	require.NoError(t, err)
+	assert.Empty(t, events)
+
+	_, err = s.LatestTrackingEvent(ctx, tenantID, shipmentID)
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+}
+
+func testDuplicateTrackingEventID(t *testing.T, s storage.TrackingEventStorage) {
+	ctx := context.Background()
+	original := NewTrackingEvent(uuid.New().String(), uuid.New().String(), time.Now())
+
+	require.NoError(t, s.AppendTrackingEvent(ctx, original))
+
+	duplicate := original
+	duplicate.Description = "Delivered"
+
+	assert.ErrorIs(t, s.AppendTrackingEvent(ctx, duplicate), storage.ErrConflict)
+
+	events, err := s.ListTrackingEvents(ctx, original.TenantID, original.ShipmentID)
+	require.NoError(t, err)
+	assert.Equal(t, []storage.TrackingEvent{original}, events)
+}
+
+func testTrackingEventShipmentIsolation(t *testing.T, s storage.TrackingEventStorage) {
+	ctx := context.Background()
+	tenantID, shipmentID := uuid.New().String(), uuid.New().String()
+
+	event := NewTrackingEvent(tenantID, shipmentID, time.Now())
+	require.NoError(t, s.AppendTrackingEvent(ctx, event))
+
+	// Neither another shipment of the tenant, nor the same shipment ID
+	// of another tenant, may see the event.
+	for _, ids := range [][2]string{{tenantID, uuid.New().String()}, {uuid.New().String(), shipmentID}} {
+		events, err := s.ListTrackingEvents(ctx, ids[0], ids[1])
+		require.NoError(t, err)
+		assert.Empty(t, events)
+
+		_, err = s.LatestTrackingEvent(ctx, ids[0], ids[1])
+		assert.ErrorIs(t, err, storage.ErrNotFound)
+	}
+}